@@ -0,0 +1,57 @@
+package main
+
+import "context"
+import "math/rand"
+import "os"
+import "os/signal"
+import "syscall"
+import "time"
+
+import log "github.com/apex/log"
+import "github.com/vrischmann/envconfig"
+
+import "github.com/aaronwinter/tezosagora/pkg/agora"
+import "github.com/aaronwinter/tezosagora/pkg/build"
+
+func main() {
+	var config agora.Config
+	err := envconfig.Init(&config)
+	if err != nil {
+		panic(err)
+	}
+
+	if !config.IsProduction() {
+		log.SetLevel(log.DebugLevel)
+	} else {
+		log.SetLevel(log.WarnLevel)
+	}
+
+	rand.Seed(time.Now().UTC().UnixNano())
+	log.WithFields(build.Get()).Warn("starting")
+
+	service, err := agora.New(config)
+	if err != nil {
+		panic(err)
+	}
+	defer service.Close()
+
+	go func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, syscall.SIGTERM, os.Interrupt)
+		sig := <-signals
+		log.WithField("signal", sig).Warn("shutting down")
+
+		ctx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+		defer cancel()
+		err := service.Shutdown(ctx)
+		if err != nil {
+			log.WithError(err).Error("could not drain in-flight requests")
+		}
+	}()
+
+	err = service.Run()
+	if err != nil {
+		log.WithError(err).Fatal("failed to start web server")
+		panic(err)
+	}
+}
@@ -0,0 +1,317 @@
+package agora
+
+import "fmt"
+import "path"
+import "strings"
+
+import log "github.com/apex/log"
+import "github.com/bwmarrin/discordgo"
+
+import "github.com/aaronwinter/tezosagora/pkg/discord"
+import "github.com/aaronwinter/tezosagora/pkg/store"
+
+// adminPermissions restricts the admin commands to members who can manage
+// the server, unless overridden in the server integration settings.
+var adminPermissions int64 = discordgo.PermissionManageGuild
+
+var noDM = false
+
+// registerAdminCommands declares the moderation slash commands.
+func (s *Service) registerAdminCommands() {
+	s.Bot.Command(&discordgo.ApplicationCommand{
+		Name:                     "whois",
+		Description:              "Show the wallets registered by a member",
+		DefaultMemberPermissions: &adminPermissions,
+		DMPermission:             &noDM,
+		Options: []*discordgo.ApplicationCommandOption{{
+			Type:        discordgo.ApplicationCommandOptionUser,
+			Name:        "member",
+			Description: "The member to look up",
+			Required:    true,
+		}},
+	}, s.whoisCommand)
+
+	s.Bot.Command(&discordgo.ApplicationCommand{
+		Name:                     "wallet",
+		Description:              "Show who registered a wallet",
+		DefaultMemberPermissions: &adminPermissions,
+		DMPermission:             &noDM,
+		Options: []*discordgo.ApplicationCommandOption{{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "address",
+			Description: "The wallet address to look up",
+			Required:    true,
+		}},
+	}, s.walletCommand)
+
+	s.Bot.Command(&discordgo.ApplicationCommand{
+		Name:                     "revoke",
+		Description:              "Revoke the registration of a wallet or of a member",
+		DefaultMemberPermissions: &adminPermissions,
+		DMPermission:             &noDM,
+		Options: []*discordgo.ApplicationCommandOption{{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "address",
+			Description: "The wallet to revoke",
+		}, {
+			Type:        discordgo.ApplicationCommandOptionUser,
+			Name:        "member",
+			Description: "The member whose wallets to revoke",
+		}, {
+			Type:        discordgo.ApplicationCommandOptionBoolean,
+			Name:        "roles",
+			Description: "Also remove the roles granted by the bot",
+		}},
+	}, s.revokeCommand)
+
+	s.Bot.Command(&discordgo.ApplicationCommand{
+		Name:                     "release",
+		Description:              "Untie a wallet from its Discord account, so that another may claim it",
+		DefaultMemberPermissions: &adminPermissions,
+		DMPermission:             &noDM,
+		Options: []*discordgo.ApplicationCommandOption{{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "address",
+			Description: "The wallet to release",
+		}, {
+			Type:        discordgo.ApplicationCommandOptionUser,
+			Name:        "member",
+			Description: "The member whose wallet to release",
+		}, {
+			Type:        discordgo.ApplicationCommandOptionBoolean,
+			Name:        "roles",
+			Description: "Also remove the roles granted by the bot from the member",
+		}},
+	}, s.releaseCommand)
+
+	s.registerSetupCommand()
+	s.registerStaleCommand()
+}
+
+// describe formats a registration for the moderators of guildID.
+func (s *Service) describe(guildID string, reg *store.Registration) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "**%v**\n", reg.Wallet)
+	if len(reg.Linked) > 0 {
+		fmt.Fprintln(&b, s.t(guildID, "admin.linked", strings.Join(reg.Linked, ", ")))
+	}
+	if reg.DiscordID != "" {
+		fmt.Fprintln(&b, s.t(guildID, "admin.member", "<@"+reg.DiscordID+">"))
+	}
+	if !reg.Registered.IsZero() {
+		fmt.Fprintln(&b, s.t(guildID, "admin.registered", fmt.Sprintf("<t:%v:f>", reg.Registered.Unix())))
+	}
+	if !reg.Redeemed.IsZero() {
+		fmt.Fprintln(&b, s.t(guildID, "admin.redeemed", fmt.Sprintf("<t:%v:f>", reg.Redeemed.Unix())))
+	}
+	fmt.Fprintln(&b, s.t(guildID, "admin.invite", reg.Invite))
+	if !reg.Disqualified.IsZero() {
+		fmt.Fprintln(&b, s.t(guildID, "admin.disqualified", fmt.Sprintf("<t:%v:f>", reg.Disqualified.Unix())))
+	}
+	return b.String()
+}
+
+func (s *Service) whoisCommand(session *discordgo.Session, i *discordgo.InteractionCreate) {
+	user := i.ApplicationCommandData().Options[0].UserValue(nil)
+
+	var found []string
+	err := s.Store.Each(func(reg *store.Registration) error {
+		if reg.DiscordID == user.ID {
+			found = append(found, s.describe(i.GuildID, reg))
+		}
+		return nil
+	})
+	if err != nil {
+		log.WithError(err).Error("could not look registrations up")
+		discord.Reply(session, i, s.t(i.GuildID, "error"))
+		return
+	}
+
+	if len(found) == 0 {
+		discord.Reply(session, i, s.t(i.GuildID, "admin.no_wallet", "<@"+user.ID+">"))
+		return
+	}
+	discord.Reply(session, i, strings.Join(found, "\n"))
+}
+
+func (s *Service) walletCommand(session *discordgo.Session, i *discordgo.InteractionCreate) {
+	address := strings.TrimSpace(i.ApplicationCommandData().Options[0].StringValue())
+
+	reg, err := s.Store.Owner(address)
+	if err != nil {
+		log.WithError(err).Error("could not look registration up")
+		discord.Reply(session, i, s.t(i.GuildID, "error"))
+		return
+	}
+
+	if reg == nil {
+		discord.Reply(session, i, s.t(i.GuildID, "admin.not_registered", address))
+		return
+	}
+	discord.Reply(session, i, s.describe(i.GuildID, reg))
+}
+
+func (s *Service) revokeCommand(session *discordgo.Session, i *discordgo.InteractionCreate) {
+	var address string
+	var user *discordgo.User
+	roles := false
+	for _, o := range i.ApplicationCommandData().Options {
+		switch o.Name {
+		case "address":
+			address = strings.TrimSpace(o.StringValue())
+		case "member":
+			user = o.UserValue(nil)
+		case "roles":
+			roles = o.BoolValue()
+		}
+	}
+
+	var regs []*store.Registration
+	var err error
+	switch {
+	case address != "":
+		var reg *store.Registration
+		reg, err = s.Store.Owner(address)
+		if reg != nil {
+			regs = append(regs, reg)
+		}
+	case user != nil:
+		err = s.Store.Each(func(reg *store.Registration) error {
+			if reg.DiscordID == user.ID {
+				regs = append(regs, reg)
+			}
+			return nil
+		})
+	default:
+		discord.Reply(session, i, s.t(i.GuildID, "admin.address_or_member"))
+		return
+	}
+	if err != nil {
+		log.WithError(err).Error("could not look registrations up")
+		discord.Reply(session, i, s.t(i.GuildID, "error"))
+		return
+	}
+
+	if len(regs) == 0 {
+		discord.Reply(session, i, s.t(i.GuildID, "admin.not_found"))
+		return
+	}
+
+	var revoked []string
+	for _, reg := range regs {
+		err = s.revoke(reg, roles, "revoked by "+discord.User(i).Username)
+		if err != nil {
+			log.WithError(err).WithField("wallet", reg.Wallet).Error("could not revoke registration")
+			discord.Reply(session, i, s.t(i.GuildID, "admin.revoke_failed", reg.Wallet, err))
+			return
+		}
+		revoked = append(revoked, reg.Wallet)
+	}
+
+	log.WithFields(log.Fields{
+		"wallets": revoked,
+		"by":      discord.User(i).ID,
+	}).Warn("revoked registrations")
+	discord.Reply(session, i, s.t(i.GuildID, "admin.revoked", strings.Join(revoked, ", ")))
+}
+
+func (s *Service) releaseCommand(session *discordgo.Session, i *discordgo.InteractionCreate) {
+	var address string
+	var user *discordgo.User
+	roles := false
+	for _, o := range i.ApplicationCommandData().Options {
+		switch o.Name {
+		case "address":
+			address = strings.TrimSpace(o.StringValue())
+		case "member":
+			user = o.UserValue(nil)
+		case "roles":
+			roles = o.BoolValue()
+		}
+	}
+
+	if address == "" && user != nil {
+		reg, err := s.Store.ByDiscord(user.ID)
+		if err != nil {
+			log.WithError(err).Error("could not look registration up")
+			discord.Reply(session, i, s.t(i.GuildID, "error"))
+			return
+		}
+		if reg != nil {
+			address = reg.Wallet
+		}
+	}
+	if address == "" {
+		discord.Reply(session, i, s.t(i.GuildID, "admin.registered_or_member"))
+		return
+	}
+
+	reg, err := s.Store.Release(address)
+	if err != nil {
+		log.WithError(err).WithField("wallet", address).Error("could not release registration")
+		discord.Reply(session, i, s.t(i.GuildID, "error"))
+		return
+	}
+	if reg == nil || reg.DiscordID == "" {
+		discord.Reply(session, i, s.t(i.GuildID, "admin.not_tied", address))
+		return
+	}
+
+	if roles {
+		err = s.Roles.Sync(reg.DiscordID, nil, s.grantedRoles())
+		if err != nil {
+			log.WithError(err).WithField("user", reg.DiscordID).Error("could not remove roles")
+			discord.Reply(session, i, s.t(i.GuildID, "admin.release_failed", reg.Wallet, "<@"+reg.DiscordID+">", err))
+			return
+		}
+	}
+
+	log.WithFields(log.Fields{
+		"wallet": reg.Wallet,
+		"user":   reg.DiscordID,
+		"by":     discord.User(i).ID,
+	}).Warn("released registration")
+	discord.Reply(session, i, s.t(i.GuildID, "admin.released", reg.Wallet, "<@"+reg.DiscordID+">"))
+}
+
+// grantedRoles returns the roles the bot grants to verified members.
+func (s *Service) grantedRoles() []string {
+	var granted []string
+	if s.Config.RoleID != "" {
+		granted = append(granted, s.Config.RoleID)
+	}
+	for _, tier := range s.Tiers {
+		granted = append(granted, tier.ID)
+	}
+	return granted
+}
+
+// revoke deletes reg for reason, invalidating its invite if it wasn't used
+// yet and, when roles is set, removing the roles the bot grants from its
+// member.
+func (s *Service) revoke(reg *store.Registration, roles bool, reason string) error {
+	if reg.Redeemed.IsZero() && reg.Invite != "" {
+		_, err := s.Discord.InviteDelete(path.Base(reg.Invite))
+		if err != nil {
+			// most likely used or expired already
+			log.WithError(err).WithField("wallet", reg.Wallet).Debug("could not delete invite")
+		}
+	}
+
+	if roles && reg.DiscordID != "" {
+		err := s.Roles.Sync(reg.DiscordID, nil, s.grantedRoles())
+		if err != nil {
+			return err
+		}
+	}
+
+	err := s.Store.Delete(reg.Wallet)
+	if err != nil {
+		return err
+	}
+
+	if s.Audit != nil {
+		go s.Audit.Revoked(reg.Wallet, reason)
+	}
+	return nil
+}
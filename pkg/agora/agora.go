@@ -0,0 +1,635 @@
+package agora
+
+import "context"
+import "crypto/ed25519"
+import "encoding/hex"
+import "errors"
+import "fmt"
+import "text/template"
+import "time"
+import "math/big"
+import "net/http"
+import "os"
+import "strconv"
+import "strings"
+import "sync"
+import "sync/atomic"
+
+import log "github.com/apex/log"
+import "github.com/bwmarrin/discordgo"
+
+import "github.com/aaronwinter/tezosagora"
+import "github.com/aaronwinter/tezosagora/pkg/blocklist"
+import "github.com/aaronwinter/tezosagora/pkg/breaker"
+import "github.com/aaronwinter/tezosagora/pkg/cache"
+import "github.com/aaronwinter/tezosagora/pkg/captcha"
+import "github.com/aaronwinter/tezosagora/pkg/discord"
+import "github.com/aaronwinter/tezosagora/pkg/etherlink"
+import "github.com/aaronwinter/tezosagora/pkg/i18n"
+import "github.com/aaronwinter/tezosagora/pkg/kick"
+import "github.com/aaronwinter/tezosagora/pkg/payment"
+import "github.com/aaronwinter/tezosagora/pkg/pow"
+import "github.com/aaronwinter/tezosagora/pkg/ratelimit"
+import "github.com/aaronwinter/tezosagora/pkg/rpc"
+import "github.com/aaronwinter/tezosagora/pkg/rules"
+import "github.com/aaronwinter/tezosagora/pkg/siwt"
+import "github.com/aaronwinter/tezosagora/pkg/sweep"
+import "github.com/aaronwinter/tezosagora/pkg/store"
+import "github.com/aaronwinter/tezosagora/pkg/tezos"
+import "github.com/aaronwinter/tezosagora/pkg/ticket"
+import "github.com/aaronwinter/tezosagora/pkg/verify"
+import "github.com/aaronwinter/tezosagora/pkg/web"
+
+// Service is a fully wired TezosAgora instance.
+type Service struct {
+	Config   Config
+	Store    *store.Store
+	Discord  *discordgo.Session
+	Bot      *discord.Bot
+	Verifier *verify.Verifier
+	Web      *web.Server
+	RPC      *rpc.Server
+	Payments *payment.Watcher
+	Sweeper  *sweep.Sweeper
+	Kicker   *kick.Kicker
+	Events   *tezos.Events
+	Feed     *blocklist.Feed
+	TzKT     *tezos.TzKT
+	Roles    *discord.Roles
+	Audit    *discord.Audit
+	Branding discord.Branding
+	Catalog  *i18n.Catalog
+	Tiers    []rules.Tier
+
+	welcomeTemplate *template.Template
+	embedTemplate   *template.Template
+
+	backends rules.Backends
+	evm      rules.Rule
+	caches   []*cache.Cache
+	rechecks chan string
+	stop     chan struct{}
+
+	mu      sync.Mutex
+	servers []*http.Server
+	drained chan struct{}
+	jobs    sync.WaitGroup
+}
+
+// New opens the store and the Discord session and assembles the pipeline
+// described by config.
+func New(config Config) (*Service, error) {
+	db, err := store.Open(config.DBName)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.BotToken == "" && (config.WebhookURL == "" || config.NeedsBot() || config.OAuthClientID != "") {
+		db.Close()
+		return nil, errors.New("a bot token is needed unless only a webhook is used")
+	}
+	if config.BotToken != "" && config.ChannelID == "" && config.StaticInvite == "" {
+		db.Close()
+		return nil, errors.New("a channel ID is needed to create invites")
+	}
+	if config.Pprof && config.AdminToken == "" {
+		db.Close()
+		return nil, errors.New("an admin token is needed to serve pprof")
+	}
+	if config.GRPCPort != 0 && config.AdminToken == "" {
+		db.Close()
+		return nil, errors.New("an admin token is needed to serve gRPC")
+	}
+	if config.RequireSignature && config.SIWTDomain == "" {
+		db.Close()
+		return nil, errors.New("a SIWT domain is needed to require signatures")
+	}
+
+	proxy, err := proxyFunc(config.Proxy)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	session, err := discordgo.New(config.BotToken)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	proxyDiscord(session, proxy)
+
+	tezosHTTP := &http.Client{
+		Timeout:   config.TezosTimeout,
+		Transport: transport(proxy),
+	}
+	backends := rules.Backends{
+		Client: tezos.NewClient(config.TezosURL...),
+		TzKT:   tezos.NewTzKT(config.TzKTURL...),
+		RPC:    tezos.NewRPC(config.RPCURL...),
+		Level:  config.SnapshotLevel,
+	}
+	backoff := tezos.Backoff{
+		MaxAttempts: config.TezosRetries,
+		Base:        config.TezosBackoff,
+		Max:         config.TezosMaxBackoff,
+	}
+	for _, endpoints := range []*tezos.Endpoints{backends.Client.Endpoints, backends.TzKT.Endpoints, backends.RPC.Endpoints} {
+		endpoints.Backoff = backoff
+	}
+	backends.Client.HTTP = tezosHTTP
+	backends.TzKT.HTTP = tezosHTTP
+	backends.RPC.HTTP = tezosHTTP
+
+	parsed, err := rules.Parse(config.Rules, backends)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	tiers, err := rules.ParseTiers(config.Tiers, backends)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	channels, err := rules.ParseTiers(config.ChannelTiers, backends)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	invites, err := rules.ParseTiers(config.InviteTiers, backends)
+	if err == nil {
+		err = resolveVanity(session, config.GuildID, invites)
+	}
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	welcome, err := loadWelcome(config.WelcomeFile)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	embed, err := loadEmbed(config.EmbedFile)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	if config.Nickname != "" && config.Nickname != "set" && config.Nickname != "suffix" {
+		db.Close()
+		return nil, fmt.Errorf("invalid nickname mode %q", config.Nickname)
+	}
+	catalog, err := i18n.New()
+	if err == nil && config.LocalesDir != "" {
+		err = catalog.LoadDir(config.LocalesDir)
+	}
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	if !catalog.Has(config.Language) {
+		db.Close()
+		return nil, fmt.Errorf("unknown language %q", config.Language)
+	}
+	assets := tezosagora.WWW()
+	if config.AssetsDir != "" {
+		assets = os.DirFS(config.AssetsDir)
+	}
+	if config.ThemeDir != "" {
+		_, err = os.Stat(config.ThemeDir)
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+		assets = web.Theme(os.DirFS(config.ThemeDir), assets)
+	}
+	color, err := strconv.ParseInt(strings.TrimPrefix(config.EmbedColor, "#"), 16, 32)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("invalid embed color %q", config.EmbedColor)
+	}
+
+	var rule rules.Rule = parsed
+	if config.MembersBigMap >= 0 {
+		rule = rules.All{parsed, rules.BigMapMember{
+			RPC:      backends.RPC,
+			BigMapID: config.MembersBigMap,
+			KeyHash:  config.MembersKeyHash,
+			Level:    config.SnapshotLevel,
+		}}
+	}
+
+	var evm rules.Rule
+	if config.EtherlinkRPCURL != "" {
+		client := etherlink.NewClient(config.EtherlinkRPCURL)
+		client.HTTP = tezosHTTP
+		min, _ := new(big.Float).Mul(big.NewFloat(config.EtherlinkMinBalance), big.NewFloat(1e18)).Int(nil)
+		evm = rules.EVMBalance{Client: client, Min: min}
+		rule = rules.Networks{Tezos: rule, Etherlink: evm}
+	}
+
+	var caches []*cache.Cache
+	if config.CacheTTL > 0 {
+		backends.TzKT.Cache = cache.New(config.CacheTTL)
+		reports := cache.New(config.CacheTTL)
+		rule = rules.Cached{Rule: rule, Cache: reports}
+		caches = append(caches, backends.TzKT.Cache, reports)
+	}
+
+	inviter := discord.NewInviter(session, config.ChannelID, config.DiscordURL)
+	inviter.MinAge = config.InviteMinAge
+	inviter.MaxAge = config.InviteMaxAge
+	inviter.MaxUses = config.InviteMaxUses
+	inviter.Unique = config.InviteUnique
+	inviter.Temporary = config.InviteTemporary
+	inviter.Fixed = config.StaticInvite
+	inviter.Queue.MaxWait = config.InviteQueueWait
+	if config.InviteExpiresAt != "" {
+		inviter.ExpiresAt, err = time.Parse(time.RFC3339, config.InviteExpiresAt)
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+
+	verifier := &verify.Verifier{
+		Store:            db,
+		Rule:             rule,
+		Inviter:          inviter,
+		RequireSignature: config.RequireSignature,
+		Etherlink:        config.EtherlinkRPCURL != "",
+		Channels:         channels,
+		Invites:          invites,
+		ReissueInvites:   config.TrackInvites,
+	}
+	blocked := blocklist.New()
+	for _, address := range config.Blocklist {
+		blocked.Add(address, "exchange or custodial address")
+	}
+	if config.BlocklistFile != "" {
+		err = blocked.LoadFile(config.BlocklistFile, "exchange or custodial address")
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+	chain := blocklist.Chain{blocked}
+
+	var feed *blocklist.Feed
+	if config.BlocklistFeedURL != "" {
+		feed = blocklist.NewFeed(config.BlocklistFeedURL)
+		feed.HTTP = tezosHTTP
+		err = feed.Refresh()
+		if err != nil {
+			log.WithError(err).WithField("url", feed.URL).Error("could not load blocklist feed")
+		}
+		chain = append(chain, feed)
+	}
+	if config.SyncBans || config.AdminToken != "" || len(config.Admins) > 0 {
+		// holds the bans of members, and the wallets blocked by admins
+		chain = append(chain, db)
+	}
+	verifier.Blocklist = chain
+	verifier.Paused = new(atomic.Bool)
+	verifier.Paused.Store(config.Maintenance)
+	if config.MaxInFlight > 0 {
+		verifier.Slots = make(chan struct{}, config.MaxInFlight)
+	}
+
+	if config.WalletAttempts > 0 {
+		verifier.Attempts = ratelimit.New(config.WalletAttemptsWindow/time.Duration(config.WalletAttempts), config.WalletAttempts)
+	}
+	if config.BreakerThreshold > 0 {
+		verifier.Breaker = breaker.New("tezos", config.BreakerThreshold, config.BreakerCooldown)
+		verifier.Breaker.Failure = tezos.Temporary
+	}
+	if config.SIWTDomain != "" {
+		verifier.SIWT = siwt.NewVerifier(config.SIWTDomain, config.SIWTMaxAge)
+	}
+
+	s := &Service{
+		Config:   config,
+		Store:    db,
+		Discord:  session,
+		Verifier: verifier,
+		Web:      web.NewServer(verifier, assets),
+		Feed:     feed,
+		TzKT:     backends.TzKT,
+		Roles:    &discord.Roles{Session: session, GuildID: config.GuildID},
+		Catalog:  catalog,
+		Tiers:    tiers,
+
+		welcomeTemplate: welcome,
+		embedTemplate:   embed,
+		backends:        backends,
+		evm:             evm,
+		caches:          caches,
+		rechecks:        make(chan string, recheckQueue),
+		stop:            make(chan struct{}),
+		drained:         make(chan struct{}),
+	}
+	s.Branding = discord.Branding{
+		Color:        int(color),
+		ThumbnailURL: config.EmbedThumbnailURL,
+		Footer:       config.EmbedFooter,
+	}
+	s.Web.AdminToken = config.AdminToken
+	s.Web.Admins = config.Admins
+	if config.IPRateInterval > 0 {
+		s.Web.IPLimiter = ratelimit.New(config.IPRateInterval, config.IPRateBurst)
+	}
+	if config.CaptchaProvider != "" {
+		s.Web.Captcha, err = captcha.New(config.CaptchaProvider, config.CaptchaSiteKey, config.CaptchaSecret)
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+	if config.PowDifficulty > 0 {
+		s.Web.Pow = pow.New(config.PowDifficulty, config.PowTTL)
+	}
+	if config.TicketSecret != "" {
+		s.Web.Tickets = ticket.New([]byte(config.TicketSecret), config.TicketTTL)
+	}
+	s.Web.PublicURL = config.PublicURL
+	if config.SuspicionInterval > 0 {
+		s.Web.Suspicion = ratelimit.New(config.SuspicionInterval, config.SuspicionBurst)
+	}
+	s.Web.BotChecks = config.BotChecks
+	s.Web.ResubmitWindow = config.ResubmitWindow
+	s.Web.MinFillTime = config.MinFillTime
+	s.Web.CSRF = config.CSRF
+	if config.AccessLog != "" {
+		s.Web.AccessLog, err = accessLog(config.AccessLog)
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+	s.Web.TrustedProxies, err = web.ParseProxies(config.TrustedProxies)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	s.Web.Reload = !config.IsProduction()
+	s.Web.Catalog = catalog
+	s.Web.InviteURL = config.DiscordURL
+	s.Web.Language = config.Language
+	s.Web.CORSOrigins = config.CORSOrigins
+	s.Web.CORSMethods = config.CORSMethods
+	s.Web.Metrics = config.Metrics
+	s.Web.Pprof = config.Pprof
+	s.Web.SplitAdmin = config.AdminListen != ""
+	s.Web.Ready = s.readiness()
+	s.Web.MaxBodySize = config.MaxBodySize
+	s.Web.HSTS = config.HSTS
+	s.Web.CSP = config.CSP
+	s.Web.Guild = s.guildVerifier
+	s.Web.Name = config.Name
+	switch {
+	case config.WebhookURL != "":
+		s.Audit, err = discord.NewWebhookAudit(session, config.WebhookURL)
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+	case config.AuditChannelID != "":
+		s.Audit = &discord.Audit{Session: session, ChannelID: config.AuditChannelID}
+	}
+	if config.OAuthClientID != "" {
+		linker := discord.NewLinker(session, config.OAuthClientID, config.OAuthClientSecret, config.OAuthRedirectURL, config.GuildID, config.RoleID)
+		if config.LinkedRoles {
+			linker.Metadata = s.roleMetadata
+			err = linker.RegisterMetadata()
+			if err != nil {
+				log.WithError(err).Error("could not register linked roles metadata")
+			}
+		}
+		linker.Claim = func(wallet, userID string) error {
+			_, err := db.Claim(wallet, userID)
+			return err
+		}
+		s.Web.Linker = linker
+	}
+	s.Web.OnDiscordLinked = s.memberLinked
+	if config.GRPCPort != 0 {
+		s.RPC = rpc.NewServer(verifier)
+		s.RPC.Token = config.AdminToken
+		s.RPC.Guild = s.guildVerifier
+	}
+	s.Web.OnRevoke = func(reg *store.Registration, reason string) error {
+		return s.revoke(reg, config.BotToken != "", reason)
+	}
+
+	if config.NeedsBot() {
+		s.Bot = discord.NewBot(session, config.GuildID)
+		s.Bot.Gateway = config.NeedsGateway()
+		s.Bot.ShardCount = config.ShardCount
+		s.Bot.ShardIDs = config.ShardIDs
+		if config.InteractionsPublicKey != "" {
+			key, err := hex.DecodeString(config.InteractionsPublicKey)
+			if err != nil || len(key) != ed25519.PublicKeySize {
+				db.Close()
+				return nil, fmt.Errorf("invalid interactions public key %q", config.InteractionsPublicKey)
+			}
+			s.Bot.PublicKey = key
+			s.Web.Interactions = s.Bot
+		}
+		s.registerCommands()
+	}
+	if config.DMVerification {
+		s.Bot.OnDM = newDMFlow(s).handle
+	}
+	if config.SyncBans {
+		session.Identify.Intents |= discordgo.IntentsGuildBans
+		s.Bot.AddHandler(s.memberBanned)
+		s.Bot.AddHandler(s.memberUnbanned)
+	}
+	if config.TrackInvites {
+		tracker := discord.NewTracker(session, config.GuildID)
+		tracker.OnRedeemed = s.inviteRedeemed
+		tracker.Register(s.Bot)
+	}
+	if config.KickGrace > 0 || config.PendingRoleID != "" {
+		session.Identify.Intents |= discordgo.IntentsGuildMembers
+		s.Bot.AddHandler(s.memberJoined)
+	}
+	if config.KickGrace > 0 {
+		s.Bot.AddHandler(s.memberLeft)
+		s.Kicker = &kick.Kicker{
+			Store:   db,
+			Session: session,
+			GuildID: config.GuildID,
+			Grace:   config.KickGrace,
+			Guilds:  s.servedGuilds,
+		}
+		if config.RemindAfter > 0 {
+			s.Kicker.RemindAfter = config.RemindAfter
+			s.Kicker.OnRemind = s.remind
+		}
+	}
+
+	if config.PaymentAddress != "" {
+		s.Payments = payment.NewWatcher(backends.TzKT, config.PaymentAddress, config.PaymentTimeout, config.PaymentConfirmations)
+		s.Payments.OnConfirmed = s.registerPaid
+		s.Payments.OnSeen = s.paymentSeen
+		s.Payments.MaxPending = config.PaymentMaxPending
+		s.Web.Payments = s.Payments
+		if config.PaymentRateInterval > 0 {
+			s.Web.PaymentLimiter = ratelimit.New(config.PaymentRateInterval, config.PaymentRateBurst)
+		}
+	}
+
+	s.Sweeper = &sweep.Sweeper{
+		Store:    db,
+		Verifier: verifier,
+		Pause:    config.SweepPause,
+		Guilds:   s.guildVerifiers,
+	}
+	if config.SweepRevoke {
+		s.Sweeper.OnChange = s.revokeDisqualified
+	}
+	if len(tiers) > 0 {
+		s.Sweeper.OnChecked = func(reg *store.Registration, _ rules.Report) {
+			s.applyTiers(reg)
+		}
+	}
+
+	if config.TzKTEventsURL != "" {
+		s.Events = tezos.NewEvents(config.TzKTEventsURL)
+		s.Events.Dialer = dialer(proxy)
+		s.Events.OnChange = s.holdingsChanged
+	}
+	verifier.OnLinked = s.walletLinked
+	verifier.OnRegistered = s.registered
+	verifier.OnRejected = s.rejected
+
+	return s, nil
+}
+
+func (s *Service) revokeDisqualified(reg *store.Registration, report rules.Report) {
+	if report.Passed {
+		return
+	}
+
+	err := s.revoke(reg, false, "disqualified by a sweep")
+	if err != nil {
+		log.WithError(err).WithField("wallet", reg.Wallet).Error("could not revoke registration")
+		return
+	}
+	log.WithField("wallet", reg.Wallet).Warn("revoked registration of disqualified wallet")
+}
+
+func (s *Service) registerPaid(wallet string) {
+	s.Web.Publish(wallet, web.Event{Key: "web.payment.confirmed"})
+	result, err := s.Verifier.Register(context.Background(), wallet)
+	s.Web.Publish(wallet, web.ResultEvent(result, err))
+	if err != nil {
+		log.WithError(err).WithField("wallet", wallet).Error("could not register paid wallet")
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"wallet":  wallet,
+		"outcome": result.Outcome,
+	}).Debug("registered paid wallet")
+}
+
+// paymentSeen tells the payment page of wallet how far its transfer is from
+// being confirmed.
+func (s *Service) paymentSeen(wallet string, confirmations int64) {
+	s.Web.Publish(wallet, web.Event{
+		Key:  "web.payment.seen",
+		Args: []interface{}{confirmations, s.Config.PaymentConfirmations},
+	})
+}
+
+// walletLinked follows a newly linked wallet and re-checks the combined
+// holdings of its registration.
+func (s *Service) walletLinked(wallet, linked string) {
+	if s.Events != nil && !etherlink.ValidAddress(linked) {
+		s.Events.Watch(linked)
+	}
+
+	err := s.Sweeper.Recheck(wallet)
+	if err != nil {
+		log.WithError(err).WithField("wallet", wallet).Error("could not re-check wallet")
+	}
+}
+
+// Run starts the background jobs and serves the web frontend until it
+// fails, or until Shutdown is done.
+func (s *Service) Run() error {
+	if s.Payments != nil {
+		s.spawn(func() { s.Payments.Run(s.Config.PaymentPollInterval, s.stop) })
+	}
+	if s.Config.SweepInterval > 0 {
+		s.spawn(func() { s.Sweeper.Run(s.Config.SweepInterval, s.stop) })
+	}
+	if s.Events != nil {
+		s.spawn(func() { s.Events.Run(s.registeredWallets, s.stop) })
+		s.spawn(s.runRechecks)
+	}
+	if s.Feed != nil {
+		s.spawn(func() { s.Feed.Run(s.Config.BlocklistFeedInterval, s.stop) })
+	}
+	if s.Kicker != nil {
+		s.spawn(func() { s.Kicker.Run(s.Config.KickInterval, s.stop) })
+	}
+	if s.Bot != nil {
+		err := s.Bot.Open()
+		if err != nil {
+			return err
+		}
+	}
+	if s.Config.VerifyChannelID != "" {
+		err := s.postVerifyButton(s.Config.GuildID, s.Config.VerifyChannelID)
+		if err != nil {
+			log.WithError(err).Error("could not post verification button")
+		}
+	}
+	if s.Config.TicketSecret != "" {
+		s.spawn(func() { s.runTicketPrune(ticketPruneInterval) })
+	}
+	if s.Config.PresenceInterval > 0 {
+		s.spawn(func() { s.runPresence(s.Config.PresenceInterval) })
+	}
+	if s.RPC != nil {
+		go func() {
+			err := s.RPC.ListenAndServe(fmt.Sprintf(":%v", s.Config.GRPCPort))
+			if err != nil {
+				log.WithError(err).Fatal("failed to serve grpc")
+			}
+		}()
+	}
+
+	if s.Config.AdminListen != "" {
+		go func() {
+			err := s.serveAdmin()
+			if err != nil {
+				log.WithError(err).Fatal("failed to serve admin endpoints")
+			}
+		}()
+	}
+
+	return s.serve(s.Web.Handler())
+}
+
+// spawn runs job in the background, Close waiting for it to return.
+func (s *Service) spawn(job func()) {
+	s.jobs.Add(1)
+	go func() {
+		defer s.jobs.Done()
+		job()
+	}()
+}
+
+// Close stops the background jobs and waits for them to return, disconnects
+// from Discord and releases the store.
+func (s *Service) Close() error {
+	close(s.stop)
+	s.jobs.Wait()
+	if s.Bot != nil {
+		s.Bot.Close()
+	}
+	return s.Store.Close()
+}
@@ -0,0 +1,28 @@
+package agora
+
+import "github.com/aaronwinter/tezosagora/pkg/verify"
+
+var rejections = map[verify.Outcome]string{
+	verify.InvalidSignature: "invalid signature",
+	verify.Blocked:          "blocklisted address",
+	verify.NotEligible:      "does not meet the requirements",
+}
+
+// registered follows newly registered wallets.
+func (s *Service) registered(wallet, inviteURL string) {
+	s.Web.Record(wallet, verify.Registered)
+	if s.Events != nil {
+		s.Events.Watch(wallet)
+	}
+	if s.Audit != nil {
+		go s.Audit.Registered(wallet, inviteURL)
+	}
+}
+
+// rejected reports failed verifications.
+func (s *Service) rejected(wallet string, outcome verify.Outcome) {
+	s.Web.Record(wallet, outcome)
+	if s.Audit != nil {
+		go s.Audit.Rejected(wallet, rejections[outcome])
+	}
+}
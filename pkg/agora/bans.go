@@ -0,0 +1,80 @@
+package agora
+
+import "time"
+
+import log "github.com/apex/log"
+import "github.com/bwmarrin/discordgo"
+
+import "github.com/aaronwinter/tezosagora/pkg/store"
+
+// memberBanned blocks the wallets of banned members, so that they can't
+// come back with another account.
+func (s *Service) memberBanned(session *discordgo.Session, b *discordgo.GuildBanAdd) {
+	if b.GuildID != s.Config.GuildID || b.User == nil {
+		return
+	}
+
+	reg, err := s.Store.ByDiscord(b.User.ID)
+	if err != nil || reg == nil {
+		log.WithError(err).WithField("user", b.User.ID).Debug("banned member has no registration")
+		return
+	}
+
+	var reason string
+	ban, err := session.GuildBan(b.GuildID, b.User.ID)
+	if err == nil {
+		reason = ban.Reason
+	}
+
+	for _, wallet := range reg.Wallets() {
+		err = s.Store.PutBan(&store.Ban{
+			Wallet: wallet,
+			UserID: b.User.ID,
+			Reason: reason,
+			Banned: time.Now().UTC(),
+		})
+		if err != nil {
+			log.WithError(err).WithField("wallet", wallet).Error("could not block wallet of banned member")
+			continue
+		}
+		log.WithFields(log.Fields{
+			"wallet": wallet,
+			"user":   b.User.ID,
+		}).Warn("blocked wallet of banned member")
+	}
+
+	if s.Audit != nil {
+		go s.Audit.Blocked(reg.Wallet, "member banned")
+	}
+}
+
+// memberUnbanned lifts the block of the wallets of unbanned members.
+func (s *Service) memberUnbanned(_ *discordgo.Session, b *discordgo.GuildBanRemove) {
+	if b.GuildID != s.Config.GuildID || b.User == nil {
+		return
+	}
+
+	var wallets []string
+	err := s.Store.EachBan(func(ban *store.Ban) error {
+		if ban.UserID == b.User.ID {
+			wallets = append(wallets, ban.Wallet)
+		}
+		return nil
+	})
+	if err != nil {
+		log.WithError(err).WithField("user", b.User.ID).Error("could not look bans up")
+		return
+	}
+
+	for _, wallet := range wallets {
+		err = s.Store.DeleteBan(wallet)
+		if err != nil {
+			log.WithError(err).WithField("wallet", wallet).Error("could not unblock wallet")
+			continue
+		}
+		log.WithFields(log.Fields{
+			"wallet": wallet,
+			"user":   b.User.ID,
+		}).Info("unblocked wallet of unbanned member")
+	}
+}
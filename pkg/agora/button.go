@@ -0,0 +1,171 @@
+package agora
+
+import "fmt"
+import "strings"
+
+import log "github.com/apex/log"
+import "github.com/bwmarrin/discordgo"
+
+import "github.com/aaronwinter/tezosagora/pkg/discord"
+import "github.com/aaronwinter/tezosagora/pkg/verify"
+
+// Custom IDs of the verification button, its modal and the address input.
+const (
+	verifyButtonID = "agora:verify"
+	verifyModalID  = "agora:verify:modal"
+	addressInputID = "address"
+)
+
+// buttonReplies holds the keys of the messages explaining failed
+// verifications.
+var buttonReplies = map[verify.Outcome]string{
+	verify.BadInput:        "verify.bad_input",
+	verify.NotEligible:     "verify.not_eligible",
+	verify.Unavailable:     "verify.unavailable",
+	verify.Blocked:         "verify.blocked",
+	verify.TooManyAttempts: "verify.too_many_attempts",
+}
+
+// registerButton routes the interactions of the verification button.
+func (s *Service) registerButton() {
+	s.Bot.Component(verifyButtonID, s.verifyButton)
+	s.Bot.Component(verifyModalID, s.verifyModal)
+}
+
+// postVerifyButton posts the verification button in channelID of guildID,
+// unless one of the last messages there already carries it.
+func (s *Service) postVerifyButton(guildID, channelID string) error {
+	me, err := s.Discord.User("@me")
+	if err != nil {
+		return err
+	}
+
+	messages, err := s.Discord.ChannelMessages(channelID, 50, "", "", "")
+	if err != nil {
+		return err
+	}
+	for _, m := range messages {
+		if m.Author != nil && m.Author.ID == me.ID && hasComponent(m.Components, verifyButtonID) {
+			return nil
+		}
+	}
+
+	_, err = s.Discord.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		Content: s.t(guildID, "button.prompt"),
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    s.t(guildID, "button.label"),
+					Style:    discordgo.PrimaryButton,
+					CustomID: verifyButtonID,
+				},
+			}},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	log.WithField("channel", channelID).Info("posted verification button")
+	return nil
+}
+
+func hasComponent(components []discordgo.MessageComponent, customID string) bool {
+	for _, c := range components {
+		row, ok := c.(*discordgo.ActionsRow)
+		if !ok {
+			continue
+		}
+		for _, c := range row.Components {
+			button, ok := c.(*discordgo.Button)
+			if ok && button.CustomID == customID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// verifyButton opens the address modal, or hands out a one-time link to the
+// verification page when wallets have to sign.
+func (s *Service) verifyButton(session *discordgo.Session, i *discordgo.InteractionCreate) {
+	user := discord.User(i)
+
+	if s.Verifier.SignatureRequired() {
+		if i.GuildID != "" && i.GuildID != s.Config.GuildID && s.Config.PublicURL != "" {
+			discord.Reply(session, i, s.t(i.GuildID, "button.web", fmt.Sprintf("%v/?guild=%v", strings.TrimSuffix(s.Config.PublicURL, "/"), i.GuildID)))
+			return
+		}
+		if s.Config.PublicURL == "" {
+			discord.Reply(session, i, s.t(i.GuildID, "button.dm"))
+			return
+		}
+
+		link, err := s.ticketLink(user.ID)
+		if err != nil {
+			log.WithError(err).WithField("user", user.ID).Error("could not store verification ticket")
+			discord.Reply(session, i, s.t(i.GuildID, "error"))
+			return
+		}
+		discord.ReplyEmbed(session, i, s.linkEmbed(i.GuildID, link))
+		return
+	}
+
+	err := session.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID: verifyModalID,
+			Title:    s.t(i.GuildID, "modal.title"),
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					discordgo.TextInput{
+						CustomID:    addressInputID,
+						Label:       s.t(i.GuildID, "modal.address"),
+						Style:       discordgo.TextInputShort,
+						Placeholder: "tz1...",
+						Required:    true,
+						MinLength:   36,
+						MaxLength:   42,
+					},
+				}},
+			},
+		},
+	})
+	if err != nil {
+		log.WithError(err).WithField("user", user.ID).Warn("could not open verification modal")
+	}
+}
+
+// verifyModal verifies the address submitted in the modal and ties it to the
+// member who submitted it.
+func (s *Service) verifyModal(session *discordgo.Session, i *discordgo.InteractionCreate) {
+	user := discord.User(i)
+	address := strings.TrimSpace(discord.ModalValue(i, addressInputID))
+
+	err := discord.Defer(session, i)
+	if err != nil {
+		log.WithError(err).WithField("user", user.ID).Warn("could not acknowledge verification modal")
+		return
+	}
+
+	reply := "error"
+	result, err := s.verifyMember(i.GuildID, user.ID, verify.Request{Address: address})
+	switch {
+	case claimReplies[err] != "":
+		reply = claimReplies[err]
+	case err != nil:
+	case result.Outcome == verify.Registered || result.Outcome == verify.AlreadyRegistered:
+		err = discord.EditReplyEmbed(session, i, s.verifiedEmbed(i.GuildID, result))
+		if err != nil {
+			log.WithError(err).WithField("user", user.ID).Warn("could not answer verification modal")
+		}
+		return
+	case buttonReplies[result.Outcome] != "":
+		reply = buttonReplies[result.Outcome]
+	}
+
+	err = discord.EditReply(session, i, s.t(i.GuildID, reply))
+	if err != nil {
+		log.WithError(err).WithField("user", user.ID).Warn("could not answer verification modal")
+	}
+}
@@ -0,0 +1,161 @@
+package agora
+
+import "context"
+import "crypto/rand"
+import "encoding/hex"
+import "fmt"
+import "time"
+
+import log "github.com/apex/log"
+import "github.com/bwmarrin/discordgo"
+
+import "github.com/aaronwinter/tezosagora/pkg/discord"
+import "github.com/aaronwinter/tezosagora/pkg/store"
+import "github.com/aaronwinter/tezosagora/pkg/verify"
+import "github.com/aaronwinter/tezosagora/pkg/web"
+
+// registerCommands declares the slash commands of the bot.
+func (s *Service) registerCommands() {
+	if s.Config.PublicURL != "" {
+		s.Bot.Command(&discordgo.ApplicationCommand{
+			Name:        "verify",
+			Description: "Get a link to verify your Tezos wallet",
+		}, s.verifyCommand)
+	}
+	if s.Config.VerifyChannelID != "" || s.Config.AdminCommands {
+		s.registerButton()
+	}
+	if s.Config.AdminCommands {
+		s.registerAdminCommands()
+	}
+}
+
+// verifyCommand DMs the user a one-time link to the verification page,
+// which ties the wallet verified there to their Discord account.
+func (s *Service) verifyCommand(session *discordgo.Session, i *discordgo.InteractionCreate) {
+	user := discord.User(i)
+
+	link, err := s.ticketLink(user.ID)
+	if err != nil {
+		log.WithError(err).WithField("user", user.ID).Error("could not store verification ticket")
+		discord.Reply(session, i, s.t(i.GuildID, "error"))
+		return
+	}
+
+	embed := s.linkEmbed(i.GuildID, link)
+	if s.Config.VerifyThreads && i.GuildID != "" {
+		s.verifyThread(session, i, embed)
+		return
+	}
+
+	err = discord.DMEmbed(session, user.ID, embed)
+	if err != nil {
+		log.WithError(err).WithField("user", user.ID).Warn("could not DM verification link")
+		discord.Reply(session, i, s.t(i.GuildID, "command.dm_failed"))
+		return
+	}
+
+	discord.Reply(session, i, s.t(i.GuildID, "command.dm_sent"))
+}
+
+// ticketLink returns a one-time link to the verification page tying the
+// wallet verified there to userID, signed when Tickets are.
+func (s *Service) ticketLink(userID string) (string, error) {
+	if s.Web.Tickets != nil {
+		token, _ := s.Web.Tickets.Issue(userID, "", 0)
+		return web.TicketURL(s.Config.PublicURL, token), nil
+	}
+
+	b := make([]byte, 16)
+	rand.Read(b)
+	token := hex.EncodeToString(b)
+
+	err := s.Store.PutTicket(token, userID, time.Now().Add(s.Config.TicketTTL))
+	if err != nil {
+		return "", err
+	}
+	return web.TicketURL(s.Config.PublicURL, token), nil
+}
+
+// claimReplies holds the keys of the messages explaining failed claims.
+var claimReplies = map[error]string{
+	store.ErrWalletClaimed:  "claim.wallet",
+	store.ErrAccountClaimed: "claim.account",
+}
+
+// verifyMember runs req through the pipeline of guildID, the default one
+// when empty, and ties a successful registration to userID. Claims breaking
+// the one account per wallet rule fail with the error of store.Claim, see
+// claimReplies.
+func (s *Service) verifyMember(guildID, userID string, req verify.Request) (verify.Result, error) {
+	verifier, err := s.memberVerifier(guildID)
+	if err != nil {
+		return verify.Result{}, err
+	}
+
+	// the pipeline logs carry the member rather than a request ID
+	ctx := log.NewContext(context.Background(), log.WithField("user", userID))
+	result, err := verifier.Verify(ctx, req)
+	if err != nil {
+		return result, err
+	}
+	if result.Outcome != verify.Registered && result.Outcome != verify.AlreadyRegistered {
+		return result, nil
+	}
+
+	reg, err := verifier.Store.Claim(result.Wallet, userID)
+	if err == store.ErrWalletClaimed || err == store.ErrAccountClaimed {
+		return result, err
+	}
+	if err != nil || reg == nil {
+		log.WithError(err).WithField("wallet", result.Wallet).Error("could not record Discord user")
+		return result, fmt.Errorf("could not record Discord user of %v", result.Wallet)
+	}
+
+	if verifier == s.Verifier {
+		guildID = ""
+	}
+	s.memberLinked(guildID, reg)
+
+	return result, nil
+}
+
+// memberVerifier returns the pipeline of guildID, or the default pipeline if
+// the guild has no settings of its own.
+func (s *Service) memberVerifier(guildID string) (*verify.Verifier, error) {
+	if guildID == "" || guildID == s.Config.GuildID {
+		return s.Verifier, nil
+	}
+
+	verifier, err := s.guildVerifier(guildID)
+	if err != nil || verifier == nil {
+		return s.Verifier, err
+	}
+	return verifier, nil
+}
+
+// discordLinked swaps the pending role for the member role and grants the
+// tier roles once reg got tied to a Discord account.
+func (s *Service) discordLinked(reg *store.Registration) {
+	var grant, revoke []string
+	if s.Config.RoleID != "" {
+		grant = append(grant, s.Config.RoleID)
+	}
+	if s.Config.PendingRoleID != "" {
+		revoke = append(revoke, s.Config.PendingRoleID)
+	}
+	if len(grant)+len(revoke) > 0 {
+		err := s.Roles.Sync(reg.DiscordID, grant, revoke)
+		if err != nil {
+			log.WithError(err).WithField("wallet", reg.Wallet).Error("could not grant member role")
+		}
+	}
+
+	s.applyTiers(reg)
+	if s.Config.Nickname != "" {
+		s.tagNickname(reg)
+	}
+	if s.Config.VerifyThreads {
+		s.closeThread(reg.DiscordID, s.t("", "thread.verified"))
+	}
+}
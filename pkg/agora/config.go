@@ -0,0 +1,389 @@
+package agora
+
+import "time"
+
+// Config holds the service settings. Field tags follow envconfig so the
+// struct can be filled straight from the environment.
+type Config struct {
+	// BotToken and ChannelID, where invites lead, may only be left out
+	// with WebhookURL, see there.
+	BotToken  string `envconfig:"optional"`
+	ChannelID string `envconfig:"optional"`
+
+	// Name is the name of the community, as offered on the web form next to
+	// the listed guilds.
+	Name string `envconfig:"default=Tezos Agora"`
+
+	DBName      string `envconfig:"default=pubkeyhashes.db"`
+	DiscordURL  string `envconfig:"default=https://discord.gg"`
+	Environment string `envconfig:"default=development"`
+
+	// Invites expire after a random duration between InviteMinAge and
+	// InviteMaxAge, a fixed one when both are equal, never when
+	// InviteMaxAge is 0, or at InviteExpiresAt (RFC 3339) when set.
+	// InviteMaxUses 0 allows any number of uses.
+	InviteMinAge    time.Duration `envconfig:"default=2h"`
+	InviteMaxAge    time.Duration `envconfig:"default=23h59m59s"`
+	InviteExpiresAt string        `envconfig:"optional"`
+	InviteMaxUses   int           `envconfig:"default=1"`
+	InviteUnique    bool          `envconfig:"default=true"`
+	// InviteTemporary hands out temporary memberships, which Discord drops
+	// when members go offline before they got a role, such as RoleID
+	// through OAuth2, /verify or invite tracking.
+	InviteTemporary bool `envconfig:"default=false"`
+	// InviteQueueWait is how long invites wait out Discord rate limits
+	// before users are told to come back later.
+	InviteQueueWait time.Duration `envconfig:"default=10s"`
+
+	// Proxy is the URL of the proxy used for calls to Tezos APIs and to
+	// Discord. When empty, HTTPS_PROXY and friends are honored.
+	Proxy string `envconfig:"optional"`
+
+	// TezosURL, RPCURL and TzKTURL accept comma separated lists of
+	// equivalent endpoints to fail over between.
+	TezosURL     []string      `envconfig:"default=https://check.tezos.com"`
+	RPCURL       []string      `envconfig:"default=https://mainnet.api.tez.ie"`
+	TzKTURL      []string      `envconfig:"default=https://api.tzkt.io"`
+	TezosTimeout time.Duration `envconfig:"default=10s"`
+	// TezosRetries caps the attempts at reaching any Tezos endpoint, with
+	// jittered exponential backoff starting at TezosBackoff in between.
+	TezosRetries    int           `envconfig:"default=3"`
+	TezosBackoff    time.Duration `envconfig:"default=200ms"`
+	TezosMaxBackoff time.Duration `envconfig:"default=5s"`
+	// After BreakerThreshold consecutive failed verifications, requests
+	// fail fast for BreakerCooldown. 0 disables the breaker.
+	BreakerThreshold int           `envconfig:"default=5"`
+	BreakerCooldown  time.Duration `envconfig:"default=30s"`
+	// MaxInFlight caps the verifications evaluating the gating rules at
+	// once, those beyond being turned away as unavailable with a
+	// Retry-After. 0 disables the cap.
+	MaxInFlight int `envconfig:"default=0"`
+	// CacheTTL is how long wallet lookups are remembered, 0 disables it.
+	CacheTTL time.Duration `envconfig:"default=5m"`
+
+	Port int `envconfig:"default=8080"`
+	// ReadTimeout and WriteTimeout bound the time to read a request and
+	// to answer it, which includes the Tezos lookups and their retries.
+	// IdleTimeout closes kept-alive connections left unused.
+	ReadTimeout  time.Duration `envconfig:"default=10s"`
+	WriteTimeout time.Duration `envconfig:"default=1m"`
+	IdleTimeout  time.Duration `envconfig:"default=2m"`
+	// MaxBodySize caps the size of request bodies, in bytes.
+	MaxBodySize int64 `envconfig:"default=1048576"`
+	// ShutdownTimeout is how long in-flight requests get to complete once
+	// asked to stop.
+	ShutdownTimeout time.Duration `envconfig:"default=15s"`
+	// Listen overrides Port with an address such as 127.0.0.1:8080, or a
+	// unix socket such as unix:/run/agora.sock.
+	Listen string `envconfig:"optional"`
+	// AdminListen, an address or unix socket like Listen, serves the admin
+	// endpoints, /metrics and /debug/pprof/ there rather than on Listen.
+	// Admins then authenticate with AdminToken, not with Discord.
+	AdminListen string `envconfig:"optional"`
+	// AutocertDomains serves HTTPS on TLSPort with certificates from
+	// Let's Encrypt for these domains, cached in AutocertDir. Port or Listen
+	// then only answers the ACME challenges and redirects to HTTPS, so it
+	// must be reachable as port 80.
+	AutocertDomains []string `envconfig:"optional"`
+	AutocertDir     string   `envconfig:"default=autocert"`
+	AutocertEmail   string   `envconfig:"optional"`
+	TLSPort         int      `envconfig:"default=443"`
+	// GRPCPort enables the gRPC service on that port, on every interface,
+	// for internal services checking or registering wallets. It takes
+	// AdminToken, which calls must bear, as registrations made through it
+	// skip the rate limits, CAPTCHA and ticket checks of the web frontend.
+	GRPCPort int `envconfig:"default=0"`
+
+	// IPRateInterval caps invite requests from a client IP to one per
+	// interval, after a burst of IPRateBurst. 0 disables the limit.
+	IPRateInterval time.Duration `envconfig:"default=0"`
+	IPRateBurst    int           `envconfig:"default=5"`
+
+	// WalletAttempts caps how often an unregistered wallet may be checked
+	// against the gating rules within WalletAttemptsWindow, from any channel.
+	// 0 disables the limit.
+	WalletAttempts       int           `envconfig:"default=0"`
+	WalletAttemptsWindow time.Duration `envconfig:"default=1h"`
+
+	// CaptchaProvider, one of hcaptcha, recaptcha or turnstile, requires
+	// a solved CAPTCHA on the invite form when set.
+	CaptchaProvider string `envconfig:"optional"`
+	CaptchaSiteKey  string `envconfig:"optional"`
+	CaptchaSecret   string `envconfig:"optional"`
+
+	// PowDifficulty, in leading zero bits, requires browsers to solve a
+	// proof of work challenge valid for PowTTL before submitting the invite
+	// form. 0 disables it; each bit doubles the expected work.
+	PowDifficulty int           `envconfig:"default=0"`
+	PowTTL        time.Duration `envconfig:"default=5m"`
+
+	// SuspicionInterval, when set, only requires the CAPTCHA and proof of
+	// work from client IPs that sent more than SuspicionBurst invite
+	// requests or failed verifications, one being forgiven per interval.
+	SuspicionInterval time.Duration `envconfig:"default=0"`
+	SuspicionBurst    int           `envconfig:"default=5"`
+
+	// BotChecks silently rejects invite forms looking like they were sent
+	// by bots, such as those submitted within MinFillTime.
+	BotChecks   bool          `envconfig:"default=false"`
+	MinFillTime time.Duration `envconfig:"default=2s"`
+
+	// CSRF requires a token fetched by the landing page on invite forms.
+	CSRF bool `envconfig:"default=false"`
+
+	// ResubmitWindow answers the invite forms submitted again from the
+	// same page within that window, as double clicks do, with the response
+	// to the first submission rather than verifying the wallet again. 0
+	// disables it.
+	ResubmitWindow time.Duration `envconfig:"default=0"`
+
+	// HSTS enables Strict-Transport-Security with that max-age, for sites
+	// only served over HTTPS. CSP overrides the Content-Security-Policy
+	// allowing the bundled pages, their fonts and the CAPTCHA widget.
+	HSTS time.Duration `envconfig:"default=0"`
+	CSP  string        `envconfig:"optional"`
+
+	// TrustedProxies are the addresses or CIDR ranges of the reverse
+	// proxies in front of the service, whose X-Forwarded-For and X-Real-IP
+	// headers are then believed for rate limiting and logging. Once set,
+	// peers connected through a unix Listen socket are trusted too.
+	TrustedProxies []string `envconfig:"optional"`
+
+	// CORSOrigins lets frontends hosted on these origins, or any with "*",
+	// call the JSON API from the browser with CORSMethods.
+	CORSOrigins []string `envconfig:"optional"`
+	CORSMethods []string `envconfig:"default=GET;POST"`
+
+	// AssetsDir serves the landing page, assets and templates from that
+	// directory rather than the ones bundled in the binary. Outside of
+	// production, templates are read again on each request.
+	AssetsDir string `envconfig:"optional"`
+
+	// ThemeDir brands the web frontend with the files of that directory,
+	// which take precedence over the assets and templates. See web.Theme
+	// for the data handed to the templates.
+	ThemeDir string `envconfig:"optional"`
+
+	// AccessLog writes one JSON line per HTTP request to that file, or to
+	// the standard output when "-", apart from the application logs.
+	AccessLog string `envconfig:"optional"`
+
+	// Metrics exposes Prometheus metrics at /metrics, behind AdminToken
+	// when it is set.
+	Metrics bool `envconfig:"default=false"`
+
+	// Pprof serves the runtime profiles at /debug/pprof/, which requires
+	// AdminToken.
+	Pprof bool `envconfig:"default=false"`
+
+	// Maintenance starts the service with invites paused and a maintenance
+	// page served instead of the landing page, which the admin API can
+	// toggle at runtime.
+	Maintenance bool `envconfig:"default=false"`
+
+	// AdminToken protects the moderation endpoints, which are disabled
+	// when it is empty.
+	AdminToken string `envconfig:"optional"`
+	// Admins are the Discord users who may also use the moderation
+	// endpoints once logged in at /admin/login, which takes OAuthClientID.
+	Admins []string `envconfig:"optional"`
+
+	// RequireSignature needs SIWTDomain, for the signed messages to be
+	// bound to this service and to a nonce it issued.
+	RequireSignature bool `envconfig:"default=false"`
+
+	// OAuthClientID enables granting RoleID in GuildID to the Discord
+	// account of verified wallets through OAuth2, on top of invites. The
+	// redirect URL must point at /discord/callback.
+	OAuthClientID     string `envconfig:"optional"`
+	OAuthClientSecret string `envconfig:"optional"`
+	OAuthRedirectURL  string `envconfig:"optional"`
+	GuildID           string `envconfig:"optional"`
+	RoleID            string `envconfig:"optional"`
+	// PendingRoleID is granted to members joining GuildID until their
+	// wallet is tied to their account, when it's swapped for RoleID. It
+	// needs the privileged server members intent.
+	PendingRoleID string `envconfig:"optional"`
+	// LinkedRoles publishes whether a Discord account holds a verified
+	// wallet, and its balance, for Discord linked roles. It needs
+	// OAuthClientID, the application's, and can replace RoleID.
+	LinkedRoles bool `envconfig:"default=false"`
+	// Nickname tags linked Discord accounts of wallets with a .tez domain in
+	// GuildID: "set" replaces their nickname with the domain, "suffix"
+	// appends it to their name. It needs the Manage Nicknames permission.
+	Nickname string `envconfig:"optional"`
+	// Tiers grants further roles by holdings to linked Discord accounts,
+	// see rules.ParseTiers for the syntax.
+	Tiers string `envconfig:"optional"`
+	// NotifyTiers DMs members when changes of their holdings, noticed by
+	// sweeps or TzKT events, move them up or down the tiers.
+	NotifyTiers bool `envconfig:"default=false"`
+	// ChannelTiers sends wallets to the channel of the first tier they
+	// qualify for instead of ChannelID, with the same syntax as Tiers.
+	ChannelTiers string `envconfig:"optional"`
+	// InviteTiers hands out to wallets qualifying for a tier its invite
+	// code, of an invite that doesn't expire, or vanity for the vanity URL
+	// of GuildID, rather than a new invite, with the same syntax as Tiers.
+	// Registrations are still recorded, but TrackInvites can't tell who used
+	// such invites.
+	InviteTiers string `envconfig:"optional"`
+
+	// PublicURL is where the web frontend is reachable. It enables the
+	// /verify slash command, which DMs links to it valid for TicketTTL.
+	PublicURL string        `envconfig:"optional"`
+	TicketTTL time.Duration `envconfig:"default=15m"`
+	// TicketSecret signs the one-time links instead of recording them,
+	// and lets the admin API issue links for a Discord user or a campaign,
+	// such as ones sent by email. It must be shared by all instances.
+	TicketSecret string `envconfig:"optional"`
+	// VerifyThreads makes /verify open a private thread per user, where
+	// moderators who can manage threads may help, instead of DMing the
+	// link. The thread is archived once verification completes.
+	VerifyThreads bool `envconfig:"default=false"`
+	// DMVerification lets users verify by sending their address to the bot
+	// in DMs and replying with a signature of the challenge they get, which
+	// stays valid for TicketTTL.
+	DMVerification bool `envconfig:"default=false"`
+	// VerifyChannelID is where the bot posts a persistent Verify button,
+	// which asks for the wallet address in a modal, or hands out a one-time
+	// link to PublicURL when signatures are required.
+	VerifyChannelID string `envconfig:"optional"`
+	// ShardCount splits the gateway connection over that many shards, as
+	// recommended by Discord when 0, for very large or many guilds. With
+	// ShardIDs, this instance only runs those shards, and others the rest.
+	ShardCount int   `envconfig:"default=1"`
+	ShardIDs   []int `envconfig:"optional"`
+	// InteractionsPublicKey, the hex encoded public key of the application,
+	// has Discord send slash commands, buttons and modals over HTTP to
+	// /discord/interactions, which must be set as the interactions endpoint
+	// URL, so that they work without a gateway connection.
+	InteractionsPublicKey string `envconfig:"optional"`
+	// AdminCommands registers the moderation slash commands, such as
+	// /whois and /wallet, for members who can manage the server.
+	AdminCommands bool `envconfig:"default=false"`
+
+	// KickGrace enables kicking the members of GuildID who have no
+	// qualifying wallet tied to their account KickGrace after joining, or
+	// after their wallet got disqualified. Checks run every KickInterval.
+	// It needs the privileged server members intent.
+	KickGrace    time.Duration `envconfig:"default=0"`
+	KickInterval time.Duration `envconfig:"default=10m"`
+	// RemindAfter DMs those members, RemindAfter into their grace period,
+	// a reminder with a one-time link to PublicURL when it is set.
+	RemindAfter time.Duration `envconfig:"default=0"`
+	// SyncBans blocks the wallets of members banned from GuildID until they
+	// get unbanned.
+	SyncBans bool `envconfig:"default=false"`
+	// TrackInvites ties registrations to the member who joined GuildID with
+	// their invite, and lets registered wallets whose invite died unused get
+	// a fresh one. It needs the privileged server members intent and the
+	// Manage Server permission.
+	TrackInvites bool `envconfig:"default=false"`
+	// WelcomeFile is a text/template DMed to members who joined with an
+	// invite of the bot, see welcomeData for the fields. It needs
+	// TrackInvites.
+	WelcomeFile string `envconfig:"optional"`
+	// Language is the code of the language the bot speaks, see the i18n
+	// package for the built-in ones, unless /setup set another one for a
+	// guild. LocalesDir adds JSON catalogs, or overrides built-in messages.
+	Language   string `envconfig:"default=en"`
+	LocalesDir string `envconfig:"optional"`
+	// EmbedColor (hex), EmbedThumbnailURL and EmbedFooter brand the embeds
+	// members get from the bot. EmbedFile is a text/template for the
+	// description of the embed of verified wallets, see embedData for the
+	// fields.
+	EmbedColor        string `envconfig:"default=2ecc71"`
+	EmbedThumbnailURL string `envconfig:"optional"`
+	EmbedFooter       string `envconfig:"optional"`
+	EmbedFile         string `envconfig:"optional"`
+	// AuditChannelID is a moderator channel where registrations, failed
+	// verifications and revocations are posted.
+	AuditChannelID string `envconfig:"optional"`
+	// WebhookURL posts the same events to a Discord webhook instead. It
+	// allows running without BotToken, for communities that can't add a
+	// bot: no invite is created then and verified wallets get StaticInvite,
+	// if any, while admins or automation handle the rest.
+	WebhookURL   string `envconfig:"optional"`
+	StaticInvite string `envconfig:"optional"`
+	// PresenceInterval enables showing the number of verified wallets as
+	// the bot status, refreshed at that interval.
+	PresenceInterval time.Duration `envconfig:"default=0"`
+
+	// EtherlinkRPCURL enables Etherlink (EVM) addresses, looked up through
+	// that JSON-RPC endpoint. They must hold at least EtherlinkMinBalance
+	// XTZ, or merely exist when it is 0.
+	EtherlinkRPCURL     string  `envconfig:"optional"`
+	EtherlinkMinBalance float64 `envconfig:"default=0"`
+
+	// Rules is the gating expression, see rules.Parse for the syntax.
+	Rules string `envconfig:"default=exists"`
+	// SnapshotLevel evaluates balance, token, big map and view checks at a
+	// past block level, for communities whose eligibility was fixed in time.
+	SnapshotLevel int64 `envconfig:"default=0"`
+
+	// Blocklist and BlocklistFile list exchange and custodial addresses
+	// that may not register. The file holds one address per line,
+	// optionally followed by a comma and a reason.
+	Blocklist     []string `envconfig:"optional"`
+	BlocklistFile string   `envconfig:"optional"`
+	// BlocklistFeedURL points at a remote denylist of flagged addresses,
+	// as JSON or CSV, refreshed every BlocklistFeedInterval.
+	BlocklistFeedURL      string        `envconfig:"optional"`
+	BlocklistFeedInterval time.Duration `envconfig:"default=1h"`
+
+	// MembersBigMap, when not negative, only lets in wallets found as keys
+	// of that big map. MembersKeyHash is set for big maps keyed by key_hash.
+	MembersBigMap  int64 `envconfig:"default=-1"`
+	MembersKeyHash bool  `envconfig:"default=false"`
+
+	// SweepInterval enables periodic re-verification of registered wallets.
+	// With SweepRevoke, registrations of wallets that no longer qualify are
+	// deleted rather than only flagged.
+	SweepInterval time.Duration `envconfig:"default=0"`
+	SweepPause    time.Duration `envconfig:"default=100ms"`
+	SweepRevoke   bool          `envconfig:"default=false"`
+	// TzKTEventsURL subscribes to TzKT's WebSocket API, e.g.
+	// wss://api.tzkt.io/v1/ws, to re-check wallets as soon as their
+	// holdings change.
+	TzKTEventsURL string `envconfig:"optional"`
+
+	// SIWTDomain enables Sign-In with Tezos for the given domain.
+	SIWTDomain string        `envconfig:"optional"`
+	SIWTMaxAge time.Duration `envconfig:"default=10m"`
+
+	// PaymentAddress enables proof of ownership by micro-payment to this
+	// address, watched through the TzKT API.
+	PaymentAddress       string        `envconfig:"optional"`
+	PaymentTimeout       time.Duration `envconfig:"default=1h"`
+	PaymentPollInterval  time.Duration `envconfig:"default=30s"`
+	PaymentConfirmations int64         `envconfig:"default=2"`
+	// PaymentMaxPending caps the payment challenges pending at once, and
+	// each client IP may ask for a challenge every PaymentRateInterval,
+	// after a burst of PaymentRateBurst.
+	PaymentMaxPending   int           `envconfig:"default=1000"`
+	PaymentRateInterval time.Duration `envconfig:"default=1m"`
+	PaymentRateBurst    int           `envconfig:"default=3"`
+}
+
+// IsProduction reports whether the service runs in production.
+func (c Config) IsProduction() bool {
+	return c.Environment == "production"
+}
+
+// NeedsBot reports whether a feature answering Discord interactions or
+// events is enabled.
+func (c Config) NeedsBot() bool {
+	return c.hasInteractions() || c.NeedsGateway()
+}
+
+// NeedsGateway reports whether a feature needing the Discord gateway
+// connection is enabled.
+func (c Config) NeedsGateway() bool {
+	return c.DMVerification || c.KickGrace > 0 || c.PendingRoleID != "" || c.SyncBans ||
+		c.TrackInvites || c.PresenceInterval > 0 ||
+		(c.InteractionsPublicKey == "" && c.hasInteractions())
+}
+
+func (c Config) hasInteractions() bool {
+	return c.PublicURL != "" || c.AdminCommands || c.VerifyChannelID != ""
+}
@@ -0,0 +1,154 @@
+package agora
+
+import "fmt"
+import "strings"
+import "sync"
+import "time"
+
+import log "github.com/apex/log"
+import "github.com/bwmarrin/discordgo"
+
+import "github.com/aaronwinter/tezosagora/pkg/etherlink"
+import "github.com/aaronwinter/tezosagora/pkg/siwt"
+import "github.com/aaronwinter/tezosagora/pkg/verify"
+
+// dmReplies holds the keys of the messages explaining failed
+// verifications.
+var dmReplies = map[verify.Outcome]string{
+	verify.BadInput:         "dm.bad_input",
+	verify.InvalidSignature: "dm.invalid_signature",
+	verify.NotEligible:      "verify.not_eligible",
+	verify.Unavailable:      "verify.unavailable",
+	verify.Blocked:          "verify.blocked",
+	verify.TooManyAttempts:  "verify.too_many_attempts",
+}
+
+// challenge is a message a user was asked to sign in DMs.
+type challenge struct {
+	Address string
+	Message string
+	Expires time.Time
+}
+
+// dmFlow walks users through verification in direct messages: they send
+// their address, sign the challenge they get back and reply with the
+// signature.
+type dmFlow struct {
+	s *Service
+
+	mu         sync.Mutex
+	challenges map[string]challenge
+}
+
+func newDMFlow(s *Service) *dmFlow {
+	return &dmFlow{s: s, challenges: make(map[string]challenge)}
+}
+
+func (f *dmFlow) reply(session *discordgo.Session, m *discordgo.MessageCreate, content string) {
+	_, err := session.ChannelMessageSend(m.ChannelID, content)
+	if err != nil {
+		log.WithError(err).WithField("user", m.Author.ID).Warn("could not reply in DMs")
+	}
+}
+
+// handle answers a direct message.
+func (f *dmFlow) handle(session *discordgo.Session, m *discordgo.MessageCreate) {
+	content := strings.TrimSpace(m.Content)
+	userID := m.Author.ID
+	verifier := f.s.Verifier
+
+	if verifier.ValidAddress(content) {
+		if !verifier.SignatureRequired() {
+			f.verify(session, m, verify.Request{Address: content})
+			return
+		}
+
+		c, err := f.challenge(content)
+		if err != nil {
+			log.WithError(err).WithField("user", userID).Error("could not create DM challenge")
+			f.reply(session, m, f.s.t("", "error"))
+			return
+		}
+
+		f.mu.Lock()
+		f.challenges[userID] = c
+		f.mu.Unlock()
+
+		howto := f.s.t("", "dm.sign_tezos")
+		if etherlink.ValidAddress(content) {
+			howto = f.s.t("", "dm.sign_etherlink")
+		}
+		f.reply(session, m, fmt.Sprintf("%v\n```\n%v\n```", howto, c.Message))
+		return
+	}
+
+	f.mu.Lock()
+	c, ok := f.challenges[userID]
+	delete(f.challenges, userID)
+	f.mu.Unlock()
+
+	if !ok || time.Now().After(c.Expires) {
+		f.reply(session, m, f.s.t("", "dm.hello"))
+		return
+	}
+
+	req := verify.Request{Address: c.Address, Message: c.Message}
+	fields := strings.Fields(content)
+	switch {
+	case etherlink.ValidAddress(c.Address) && len(fields) == 1:
+		req.Signature = fields[0]
+	case len(fields) == 2:
+		req.PublicKey, req.Signature = fields[0], fields[1]
+	default:
+		f.reply(session, m, f.s.t("", dmReplies[verify.BadInput]))
+		return
+	}
+
+	f.verify(session, m, req)
+}
+
+// challenge returns the SIWT message the owner of address has to sign.
+func (f *dmFlow) challenge(address string) (challenge, error) {
+	c := challenge{Address: address, Expires: time.Now().Add(f.s.Config.TicketTTL)}
+
+	nonce, err := f.s.Verifier.SIWT.Nonce()
+	if err != nil {
+		return c, err
+	}
+	m := siwt.Message{
+		Domain:    f.s.Config.SIWTDomain,
+		Address:   address,
+		Statement: "Verify my wallet on Discord.",
+		URI:       "https://" + f.s.Config.SIWTDomain,
+		Version:   "1",
+		ChainID:   "NetXdQprcVkpaWU",
+		Nonce:     nonce,
+		IssuedAt:  time.Now().UTC(),
+	}
+	c.Message = siwt.Prefix + m.String()
+	return c, nil
+}
+
+// verify runs req through the pipeline and ties a successful registration
+// to the author of m.
+func (f *dmFlow) verify(session *discordgo.Session, m *discordgo.MessageCreate, req verify.Request) {
+	result, err := f.s.verifyMember("", m.Author.ID, req)
+	if claimReplies[err] != "" {
+		f.reply(session, m, f.s.t("", claimReplies[err]))
+		return
+	}
+	if err != nil {
+		f.reply(session, m, f.s.t("", "error"))
+		return
+	}
+
+	if result.Outcome != verify.Registered && result.Outcome != verify.AlreadyRegistered {
+		f.reply(session, m, f.s.t("", dmReplies[result.Outcome]))
+		return
+	}
+
+	_, err = session.ChannelMessageSendEmbed(m.ChannelID, f.s.verifiedEmbed("", result))
+	if err != nil {
+		log.WithError(err).WithField("user", m.Author.ID).Warn("could not reply in DMs")
+	}
+}
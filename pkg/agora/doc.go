@@ -0,0 +1,5 @@
+// Package agora wires the tezos, store, rules, discord, verify and web
+// packages together into the TezosAgora service. Programs embedding
+// wallet-gated access control can either use Service as a whole or assemble
+// the underlying packages themselves.
+package agora
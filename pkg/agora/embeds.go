@@ -0,0 +1,98 @@
+package agora
+
+import "bytes"
+import "context"
+import "text/template"
+import "time"
+
+import log "github.com/apex/log"
+import "github.com/bwmarrin/discordgo"
+
+import "github.com/aaronwinter/tezosagora/pkg/discord"
+import "github.com/aaronwinter/tezosagora/pkg/rules"
+import "github.com/aaronwinter/tezosagora/pkg/verify"
+
+// embedData is handed to the embed template.
+type embedData struct {
+	Wallet string
+	// Short is the truncated wallet address.
+	Short  string
+	Tier   string
+	Invite string
+	// Expires is when the invite expires, the zero time if never.
+	Expires time.Time
+}
+
+// verifiedEmbed presents the outcome of a successful verification in the
+// language of guildID.
+func (s *Service) verifiedEmbed(guildID string, result verify.Result) *discordgo.MessageEmbed {
+	data := embedData{
+		Wallet: result.Wallet,
+		Short:  discord.ShortAddress(result.Wallet),
+		Tier:   s.tier(result.Wallet),
+		Invite: result.Invite,
+	}
+
+	expires, err := discord.InviteExpiry(s.Discord, result.Invite)
+	if err != nil {
+		log.WithError(err).WithField("wallet", result.Wallet).Warn("could not look up invite expiry")
+	}
+	data.Expires = expires
+
+	description := s.t(guildID, "embed.verified.description")
+	if s.embedTemplate != nil {
+		var b bytes.Buffer
+		err = s.embedTemplate.Execute(&b, data)
+		if err != nil {
+			log.WithError(err).Error("could not render embed")
+		} else {
+			description = b.String()
+		}
+	}
+
+	fields := []*discordgo.MessageEmbedField{discord.Field(s.t(guildID, "embed.wallet"), data.Short)}
+	if data.Tier != "" {
+		fields = append(fields, discord.Field(s.t(guildID, "embed.tier"), data.Tier))
+	}
+	fields = append(fields, discord.Field(s.t(guildID, "embed.invite"), data.Invite))
+	if !expires.IsZero() {
+		fields = append(fields, discord.Field(s.t(guildID, "embed.expires"), discord.Countdown(expires)))
+	}
+
+	return s.Branding.Embed(s.t(guildID, "embed.verified"), description, fields...)
+}
+
+// linkEmbed presents a one-time link to the verification page in the
+// language of guildID.
+func (s *Service) linkEmbed(guildID, link string) *discordgo.MessageEmbed {
+	embed := s.Branding.Embed(s.t(guildID, "embed.link"),
+		s.t(guildID, "embed.link.description", discord.Countdown(time.Now().Add(s.Config.TicketTTL))))
+	embed.URL = link
+	return embed
+}
+
+// tier returns the name of the first tier the holdings of wallet qualify
+// for, if any.
+func (s *Service) tier(wallet string) string {
+	wallets := []string{wallet}
+	reg, err := s.Store.Owner(wallet)
+	if err == nil && reg != nil {
+		wallets = reg.Wallets()
+	}
+
+	for _, tier := range s.Tiers {
+		report, err := rules.EvaluateGroup(context.Background(), tier.Rule, wallets)
+		if err == nil && report.Passed {
+			return tier.Name
+		}
+	}
+	return ""
+}
+
+// loadEmbed parses the embed template at path, if any.
+func loadEmbed(path string) (*template.Template, error) {
+	if path == "" {
+		return nil, nil
+	}
+	return template.ParseFiles(path)
+}
@@ -0,0 +1,72 @@
+package agora
+
+import log "github.com/apex/log"
+
+import "github.com/aaronwinter/tezosagora/pkg/etherlink"
+import "github.com/aaronwinter/tezosagora/pkg/store"
+
+// registeredWallets lists the wallets to subscribe to on the TzKT events,
+// registered with any guild.
+func (s *Service) registeredWallets() ([]string, error) {
+	ids, err := s.servedGuilds()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var wallets []string
+	add := func(reg *store.Registration) error {
+		for _, wallet := range reg.Wallets() {
+			if !etherlink.ValidAddress(wallet) && !seen[wallet] {
+				seen[wallet] = true
+				wallets = append(wallets, wallet)
+			}
+		}
+		return nil
+	}
+
+	err = s.Store.Each(add)
+	for _, id := range ids {
+		if err != nil {
+			break
+		}
+		err = s.Store.Guild(id).Each(add)
+	}
+	return wallets, err
+}
+
+// recheckQueue is how many wallets reported by TzKT can wait for their
+// re-check before further reports are dropped, left to the sweeps.
+const recheckQueue = 256
+
+// holdingsChanged queues wallet for a re-check with fresh data as soon as
+// TzKT reports a change in its balance or tokens. It is called from the
+// events read loop, which must not wait for the checks.
+func (s *Service) holdingsChanged(wallet string) {
+	for _, c := range s.caches {
+		c.Delete(wallet)
+	}
+
+	select {
+	case s.rechecks <- wallet:
+	default:
+		log.WithField("wallet", wallet).Warn("too many pending re-checks, dropping holdings change")
+	}
+}
+
+// runRechecks re-checks the wallets queued by holdingsChanged until the
+// service stops.
+func (s *Service) runRechecks() {
+	for {
+		select {
+		case <-s.stop:
+			return
+		case wallet := <-s.rechecks:
+			log.WithField("wallet", wallet).Debug("holdings changed, re-checking")
+			err := s.Sweeper.Recheck(wallet)
+			if err != nil {
+				log.WithError(err).WithField("wallet", wallet).Error("could not re-check wallet")
+			}
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package agora
+
+import "errors"
+import "fmt"
+import "time"
+
+import log "github.com/apex/log"
+import "github.com/bwmarrin/discordgo"
+
+import "github.com/aaronwinter/tezosagora/pkg/discord"
+import "github.com/aaronwinter/tezosagora/pkg/rules"
+import "github.com/aaronwinter/tezosagora/pkg/store"
+import "github.com/aaronwinter/tezosagora/pkg/verify"
+
+// guildVerifier returns the pipeline of the guild id, built from its stored
+// settings on top of the default one, or nil if the guild is unknown. The
+// hooks of the default pipeline are left out.
+func (s *Service) guildVerifier(id string) (*verify.Verifier, error) {
+	g, err := s.Store.GetGuild(id)
+	if err != nil || g == nil {
+		return nil, err
+	}
+
+	v := *s.Verifier
+	v.Store = s.Store.Guild(id)
+	v.OnRegistered = nil
+	v.OnLinked = nil
+	v.OnRejected = nil
+	v.Channels = nil
+	v.Invites = nil
+
+	inviter := &discord.Inviter{}
+	*inviter = *s.Verifier.Inviter
+	inviter.ChannelID = g.ChannelID
+	inviter.Fixed = ""
+	if g.InviteMaxAge != 0 {
+		inviter.MinAge = time.Duration(g.InviteMaxAge) * time.Second
+		inviter.MaxAge = inviter.MinAge
+	}
+	if g.InviteMaxUses != 0 {
+		inviter.MaxUses = g.InviteMaxUses
+	}
+	v.Inviter = inviter
+
+	if g.Rules != "" {
+		rule, err := rules.Parse(g.Rules, s.backends)
+		if err != nil {
+			return nil, err
+		}
+		if s.evm != nil {
+			rule = rules.Networks{Tezos: rule, Etherlink: s.evm}
+		}
+		v.Rule = rule
+	}
+
+	return &v, nil
+}
+
+// servedGuilds returns the IDs of the guilds with stored settings, served
+// next to the default one.
+func (s *Service) servedGuilds() ([]string, error) {
+	guilds, err := s.Store.Guilds()
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for _, g := range guilds {
+		if g.ID != s.Config.GuildID {
+			ids = append(ids, g.ID)
+		}
+	}
+	return ids, nil
+}
+
+// guildVerifiers returns the pipelines of the served guilds by guild ID.
+func (s *Service) guildVerifiers() (map[string]*verify.Verifier, error) {
+	ids, err := s.servedGuilds()
+	if err != nil {
+		return nil, err
+	}
+
+	verifiers := make(map[string]*verify.Verifier, len(ids))
+	for _, id := range ids {
+		v, err := s.guildVerifier(id)
+		if err != nil {
+			return nil, err
+		}
+		if v != nil {
+			verifiers[id] = v
+		}
+	}
+	return verifiers, nil
+}
+
+// memberLinked grants the member roles of guildID, the default guild when
+// empty, once reg got tied to a Discord account.
+func (s *Service) memberLinked(guildID string, reg *store.Registration) {
+	if guildID == "" || guildID == s.Config.GuildID {
+		s.discordLinked(reg)
+		return
+	}
+
+	g, err := s.Store.GetGuild(guildID)
+	if err != nil {
+		log.WithError(err).WithField("guild", guildID).Error("could not load guild")
+		return
+	}
+	if g == nil || g.RoleID == "" {
+		return
+	}
+
+	roles := &discord.Roles{Session: s.Discord, GuildID: guildID}
+	err = roles.Sync(reg.DiscordID, []string{g.RoleID}, nil)
+	if err != nil {
+		log.WithError(err).WithField("guild", guildID).Error("could not grant member role")
+	}
+}
+
+// resolveVanity replaces the vanity ID of invite tiers with the vanity code
+// of guildID.
+func resolveVanity(session *discordgo.Session, guildID string, tiers []rules.Tier) error {
+	for i := range tiers {
+		if tiers[i].ID != "vanity" {
+			continue
+		}
+		if guildID == "" {
+			return errors.New("a guild ID is needed for vanity invites")
+		}
+
+		g, err := session.Guild(guildID)
+		if err != nil {
+			return err
+		}
+		if g.VanityURLCode == "" {
+			return fmt.Errorf("guild %v has no vanity URL", guildID)
+		}
+		tiers[i].ID = g.VanityURLCode
+	}
+	return nil
+}
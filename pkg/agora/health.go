@@ -0,0 +1,31 @@
+package agora
+
+import "errors"
+
+import "github.com/aaronwinter/tezosagora/pkg/tezos"
+
+// errTezosDown is reported while every endpoint of a Tezos API is failing.
+var errTezosDown = errors.New("no Tezos endpoint is reachable")
+
+// readiness returns the checks of /readyz: the database can be read, the
+// bot is connected to Discord and the Tezos APIs are reachable, as far as
+// the recent lookups tell.
+func (s *Service) readiness() map[string]func() error {
+	return map[string]func() error{
+		"db": s.Store.Ping,
+		"tezos": func() error {
+			for _, endpoints := range []*tezos.Endpoints{s.backends.Client.Endpoints, s.backends.TzKT.Endpoints, s.backends.RPC.Endpoints} {
+				if !endpoints.Healthy() {
+					return errTezosDown
+				}
+			}
+			return nil
+		},
+		"discord": func() error {
+			if s.Bot == nil {
+				return nil
+			}
+			return s.Bot.Ready()
+		},
+	}
+}
@@ -0,0 +1,20 @@
+package agora
+
+// language returns the language of guildID, the one of the service if it
+// is empty or has none set.
+func (s *Service) language(guildID string) string {
+	if guildID == "" || guildID == s.Config.GuildID {
+		return s.Config.Language
+	}
+
+	g, err := s.Store.GetGuild(guildID)
+	if err != nil || g == nil || !s.Catalog.Has(g.Language) {
+		return s.Config.Language
+	}
+	return g.Language
+}
+
+// t translates the message key for guildID, see language.
+func (s *Service) t(guildID, key string, args ...interface{}) string {
+	return s.Catalog.T(s.language(guildID), key, args...)
+}
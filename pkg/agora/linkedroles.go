@@ -0,0 +1,43 @@
+package agora
+
+import "context"
+import "fmt"
+
+import "github.com/aaronwinter/tezosagora/pkg/discord"
+import "github.com/aaronwinter/tezosagora/pkg/etherlink"
+
+// roleMetadata describes the registration owning wallet for Discord linked
+// roles.
+func (s *Service) roleMetadata(wallet string) (map[string]string, error) {
+	reg, err := s.Store.Owner(wallet)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := map[string]string{
+		discord.MetadataVerified: "0",
+		discord.MetadataBalance:  "0",
+	}
+	if reg == nil {
+		return metadata, nil
+	}
+
+	if reg.Disqualified.IsZero() {
+		metadata[discord.MetadataVerified] = "1"
+	}
+
+	var balance int64
+	for _, w := range reg.Wallets() {
+		if etherlink.ValidAddress(w) {
+			continue
+		}
+		b, err := s.TzKT.BalanceAt(context.Background(), w, 0)
+		if err != nil {
+			return nil, err
+		}
+		balance += b
+	}
+	metadata[discord.MetadataBalance] = fmt.Sprint(balance / 1e6)
+
+	return metadata, nil
+}
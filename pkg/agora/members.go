@@ -0,0 +1,125 @@
+package agora
+
+import "time"
+
+import log "github.com/apex/log"
+import "github.com/bwmarrin/discordgo"
+
+import "github.com/aaronwinter/tezosagora/pkg/discord"
+import "github.com/aaronwinter/tezosagora/pkg/store"
+
+// memberJoined starts tracking members joining a served guild and marks the
+// unverified ones of the default guild as pending.
+func (s *Service) memberJoined(_ *discordgo.Session, m *discordgo.GuildMemberAdd) {
+	if m.User == nil || m.User.Bot {
+		return
+	}
+
+	if s.Kicker != nil {
+		db, err := s.memberStore(m.GuildID)
+		if err == nil && db != nil {
+			err = db.PutMember(&store.Member{UserID: m.User.ID, Joined: time.Now().UTC()})
+		}
+		if err != nil {
+			log.WithError(err).WithField("user", m.User.ID).Error("could not track member")
+		}
+	}
+	if s.Config.PendingRoleID != "" && m.GuildID == s.Config.GuildID {
+		s.markPending(m.User.ID)
+	}
+}
+
+// memberStore returns the store tracking the members of guildID, or nil if
+// the guild isn't served.
+func (s *Service) memberStore(guildID string) (*store.Store, error) {
+	if guildID == s.Config.GuildID {
+		return s.Store, nil
+	}
+
+	g, err := s.Store.GetGuild(guildID)
+	if err != nil || g == nil {
+		return nil, err
+	}
+	return s.Store.Guild(guildID), nil
+}
+
+// markPending grants the pending role to userID, or the member roles if
+// their wallet is already verified.
+func (s *Service) markPending(userID string) {
+	reg, err := s.Store.ByDiscord(userID)
+	if err != nil {
+		log.WithError(err).WithField("user", userID).Error("could not look up registration")
+		return
+	}
+	if reg != nil && reg.Disqualified.IsZero() {
+		s.discordLinked(reg)
+		return
+	}
+
+	err = s.Roles.Sync(userID, []string{s.Config.PendingRoleID}, nil)
+	if err != nil {
+		log.WithError(err).WithField("user", userID).Error("could not grant pending role")
+	}
+}
+
+// memberLeft stops tracking members leaving a served guild.
+func (s *Service) memberLeft(_ *discordgo.Session, m *discordgo.GuildMemberRemove) {
+	if m.User == nil {
+		return
+	}
+
+	db, err := s.memberStore(m.GuildID)
+	if err == nil && db != nil {
+		err = db.DeleteMember(m.User.ID)
+	}
+	if err != nil {
+		log.WithError(err).WithField("user", m.User.ID).Error("could not forget member")
+	}
+}
+
+// remind DMs userID to verify before they get kicked at kickAt.
+func (s *Service) remind(userID string, kickAt time.Time) error {
+	message := s.t("", "remind", discord.Countdown(kickAt))
+	if s.Config.PublicURL == "" {
+		return discord.DM(s.Discord, userID, message)
+	}
+
+	link, err := s.ticketLink(userID)
+	if err != nil {
+		return err
+	}
+	embed := s.linkEmbed("", link)
+	embed.Description = message + "\n" + embed.Description
+	return discord.DMEmbed(s.Discord, userID, embed)
+}
+
+// inviteRedeemed ties the registration an invite was handed to with the
+// member who used it.
+func (s *Service) inviteRedeemed(code, userID string) {
+	reg, err := s.Store.InviteOwner(code)
+	if err != nil || reg == nil {
+		log.WithError(err).WithField("code", code).Debug("redeemed invite is not ours")
+		return
+	}
+
+	claimed, err := s.Store.Claim(reg.Wallet, userID)
+	if err != nil || claimed == nil {
+		log.WithError(err).WithFields(log.Fields{
+			"wallet": reg.Wallet,
+			"user":   userID,
+			"owner":  reg.DiscordID,
+		}).Warn("could not tie invite to the member who used it")
+		return
+	}
+
+	reg = claimed
+	reg.Redeemed = time.Now().UTC()
+	err = s.Store.Put(reg)
+	if err != nil {
+		log.WithError(err).WithField("wallet", reg.Wallet).Error("could not record invite redemption")
+		return
+	}
+
+	s.discordLinked(reg)
+	s.welcome(reg)
+}
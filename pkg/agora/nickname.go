@@ -0,0 +1,33 @@
+package agora
+
+import "context"
+
+import log "github.com/apex/log"
+
+import "github.com/aaronwinter/tezosagora/pkg/etherlink"
+import "github.com/aaronwinter/tezosagora/pkg/store"
+
+// tagNickname tags the Discord account of reg with the domain of the first
+// of its wallets that has one.
+func (s *Service) tagNickname(reg *store.Registration) {
+	for _, wallet := range reg.Wallets() {
+		if etherlink.ValidAddress(wallet) {
+			continue
+		}
+
+		domain, err := s.TzKT.Domain(context.Background(), wallet)
+		if err != nil {
+			log.WithError(err).WithField("wallet", wallet).Warn("could not resolve domain")
+			return
+		}
+		if domain == "" {
+			continue
+		}
+
+		err = s.Roles.Tag(reg.DiscordID, domain, s.Config.Nickname == "suffix")
+		if err != nil {
+			log.WithError(err).WithField("wallet", reg.Wallet).Warn("could not tag nickname")
+		}
+		return
+	}
+}
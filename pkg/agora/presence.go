@@ -0,0 +1,52 @@
+package agora
+
+import "strconv"
+import "time"
+
+import log "github.com/apex/log"
+
+import "github.com/aaronwinter/tezosagora/pkg/store"
+
+// thousands formats n with comma separators, e.g. 3,214.
+func thousands(n int) string {
+	s := strconv.Itoa(n)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
+
+// updatePresence shows the number of verified wallets as the bot status.
+func (s *Service) updatePresence() error {
+	count := 0
+	err := s.Store.Each(func(reg *store.Registration) error {
+		if reg.Disqualified.IsZero() {
+			count++
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.Bot.UpdateWatchStatus(s.t("", "presence", thousands(count)))
+}
+
+// runPresence refreshes the bot status every interval until stop is closed.
+func (s *Service) runPresence(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		err := s.updatePresence()
+		if err != nil {
+			log.WithError(err).Error("could not update presence")
+		}
+
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
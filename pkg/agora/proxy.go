@@ -0,0 +1,47 @@
+package agora
+
+import "net/http"
+import "net/url"
+
+import "github.com/bwmarrin/discordgo"
+import "github.com/gorilla/websocket"
+
+// proxyFunc returns the proxy selection used for outbound calls: the
+// configured proxy if any, the HTTPS_PROXY/HTTP_PROXY/NO_PROXY environment
+// otherwise.
+func proxyFunc(proxy string) (func(*http.Request) (*url.URL, error), error) {
+	if proxy == "" {
+		return http.ProxyFromEnvironment, nil
+	}
+
+	u, err := url.Parse(proxy)
+	if err != nil {
+		return nil, err
+	}
+	return http.ProxyURL(u), nil
+}
+
+// transport returns an HTTP transport going through proxy.
+func transport(proxy func(*http.Request) (*url.URL, error)) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = proxy
+	return t
+}
+
+// proxyDiscord routes both the REST and the gateway connections of session
+// through proxy.
+func proxyDiscord(session *discordgo.Session, proxy func(*http.Request) (*url.URL, error)) {
+	session.Client = &http.Client{
+		Timeout:   session.Client.Timeout,
+		Transport: transport(proxy),
+	}
+
+	session.Dialer = dialer(proxy)
+}
+
+// dialer returns a websocket dialer going through proxy.
+func dialer(proxy func(*http.Request) (*url.URL, error)) *websocket.Dialer {
+	d := *websocket.DefaultDialer
+	d.Proxy = proxy
+	return &d
+}
@@ -0,0 +1,155 @@
+package agora
+
+import "context"
+import "fmt"
+import "net"
+import "net/http"
+import "os"
+import "strings"
+
+import log "github.com/apex/log"
+import "github.com/apex/log/handlers/json"
+import "golang.org/x/crypto/acme/autocert"
+
+// listen listens on addr, either a TCP address or a unix socket path
+// prefixed with "unix:".
+func listen(addr string) (net.Listener, error) {
+	path, ok := strings.CutPrefix(addr, "unix:")
+	if !ok {
+		return net.Listen("tcp", addr)
+	}
+
+	// left behind by a previous run
+	os.Remove(path)
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	err = os.Chmod(path, 0660)
+	if err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// serve serves handler over HTTP on the listen address, or over HTTPS on
+// TLSPort with certificates provisioned by Let's Encrypt when
+// AutocertDomains is set, in which case the listen address answers the ACME
+// challenges and redirects to HTTPS.
+func (s *Service) serve(handler http.Handler) error {
+	addr := s.Config.Listen
+	if addr == "" {
+		addr = fmt.Sprintf(":%v", s.Config.Port)
+	}
+	l, err := listen(addr)
+	if err != nil {
+		return err
+	}
+	if len(s.Config.AutocertDomains) == 0 {
+		return s.drain(s.track(s.server(handler)).Serve(l))
+	}
+
+	manager := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(s.Config.AutocertDomains...),
+		Cache:      autocert.DirCache(s.Config.AutocertDir),
+		Email:      s.Config.AutocertEmail,
+	}
+
+	go func() {
+		err := s.track(s.server(manager.HTTPHandler(nil))).Serve(l)
+		if err != http.ErrServerClosed {
+			log.WithError(err).Fatal("failed to serve acme challenges")
+		}
+	}()
+
+	server := s.track(s.server(handler))
+	server.Addr = fmt.Sprintf(":%v", s.Config.TLSPort)
+	server.TLSConfig = manager.TLSConfig()
+	return s.drain(server.ListenAndServeTLS("", ""))
+}
+
+// serveAdmin serves the admin endpoints over HTTP on AdminListen.
+func (s *Service) serveAdmin() error {
+	l, err := listen(s.Config.AdminListen)
+	if err != nil {
+		return err
+	}
+	return s.drain(s.track(s.server(s.Web.AdminHandler())).Serve(l))
+}
+
+// server returns an http.Server for handler with the configured timeouts.
+func (s *Service) server(handler http.Handler) *http.Server {
+	return &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: s.Config.ReadTimeout,
+		ReadTimeout:       s.Config.ReadTimeout,
+		WriteTimeout:      s.Config.WriteTimeout,
+		IdleTimeout:       s.Config.IdleTimeout,
+	}
+}
+
+// track records server for Shutdown.
+func (s *Service) track(server *http.Server) *http.Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.servers = append(s.servers, server)
+	return server
+}
+
+// drain waits for Shutdown to be done when err tells the server was shut
+// down, and returns err otherwise.
+func (s *Service) drain(err error) error {
+	if err != http.ErrServerClosed {
+		return err
+	}
+	<-s.drained
+	return nil
+}
+
+// Shutdown stops accepting connections and waits for the in-flight requests
+// and gRPC calls to complete, or for ctx to be done. Close must still be
+// called to stop the background jobs and release the store.
+func (s *Service) Shutdown(ctx context.Context) error {
+	defer close(s.drained)
+
+	s.mu.Lock()
+	servers := s.servers
+	s.mu.Unlock()
+
+	var err error
+	for _, server := range servers {
+		if e := server.Shutdown(ctx); e != nil {
+			err = e
+		}
+	}
+
+	if s.RPC != nil {
+		stopped := make(chan struct{})
+		go func() {
+			s.RPC.Stop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+	}
+	return err
+}
+
+// accessLog returns a logger writing JSON lines to the file at path, or to
+// the standard output when path is "-".
+func accessLog(path string) (log.Interface, error) {
+	w := os.Stdout
+	if path != "-" {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0640)
+		if err != nil {
+			return nil, err
+		}
+		w = f
+	}
+	return &log.Logger{Handler: json.New(w), Level: log.InfoLevel}, nil
+}
@@ -0,0 +1,165 @@
+package agora
+
+import "fmt"
+import "strings"
+
+import log "github.com/apex/log"
+import "github.com/bwmarrin/discordgo"
+
+import "github.com/aaronwinter/tezosagora/pkg/discord"
+import "github.com/aaronwinter/tezosagora/pkg/rules"
+import "github.com/aaronwinter/tezosagora/pkg/store"
+
+// registerSetupCommand declares /setup, which saves the settings of the
+// guild it's used in. It needs global commands to reach other guilds than
+// GuildID, which is configured through the environment.
+func (s *Service) registerSetupCommand() {
+	textChannels := []discordgo.ChannelType{discordgo.ChannelTypeGuildText}
+	var languages []*discordgo.ApplicationCommandOptionChoice
+	for _, lang := range s.Catalog.Languages() {
+		languages = append(languages, &discordgo.ApplicationCommandOptionChoice{Name: lang, Value: lang})
+	}
+	s.Bot.Command(&discordgo.ApplicationCommand{
+		Name:                     "setup",
+		Description:              "Set where verified wallets are invited to and who qualifies",
+		DefaultMemberPermissions: &adminPermissions,
+		DMPermission:             &noDM,
+		Options: []*discordgo.ApplicationCommandOption{{
+			Type:         discordgo.ApplicationCommandOptionChannel,
+			Name:         "channel",
+			Description:  "The channel invites lead to",
+			ChannelTypes: textChannels,
+			Required:     true,
+		}, {
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "rules",
+			Description: "The gating rules, e.g. balance(100), the service's when empty",
+		}, {
+			Type:        discordgo.ApplicationCommandOptionRole,
+			Name:        "role",
+			Description: "The role members get when verifying with the Verify button",
+		}, {
+			Type:         discordgo.ApplicationCommandOptionChannel,
+			Name:         "verify_channel",
+			Description:  "A channel to post the Verify button in",
+			ChannelTypes: textChannels,
+		}, {
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "name",
+			Description: "The name of the community on the website",
+		}, {
+			Type:        discordgo.ApplicationCommandOptionBoolean,
+			Name:        "listed",
+			Description: "Offer the community on the website",
+		}, {
+			Type:        discordgo.ApplicationCommandOptionInteger,
+			Name:        "invite_hours",
+			Description: "How long invites stay valid",
+		}, {
+			Type:        discordgo.ApplicationCommandOptionInteger,
+			Name:        "invite_uses",
+			Description: "How many members may join with an invite",
+		}, {
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "language",
+			Description: "The language of the bot",
+			Choices:     languages,
+		}},
+	}, s.setupCommand)
+}
+
+func (s *Service) setupCommand(session *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.GuildID == s.Config.GuildID {
+		discord.Reply(session, i, s.t(i.GuildID, "setup.environment"))
+		return
+	}
+
+	g, err := s.Store.GetGuild(i.GuildID)
+	if err != nil {
+		log.WithError(err).WithField("guild", i.GuildID).Error("could not load guild")
+		discord.Reply(session, i, s.t(i.GuildID, "error"))
+		return
+	}
+	if g == nil {
+		g = &store.Guild{ID: i.GuildID}
+	}
+
+	var verifyChannel string
+	for _, o := range i.ApplicationCommandData().Options {
+		switch o.Name {
+		case "channel":
+			g.ChannelID = o.ChannelValue(nil).ID
+		case "rules":
+			g.Rules = strings.TrimSpace(o.StringValue())
+		case "role":
+			g.RoleID = o.RoleValue(nil, "").ID
+		case "verify_channel":
+			verifyChannel = o.ChannelValue(nil).ID
+		case "name":
+			g.Name = o.StringValue()
+		case "listed":
+			g.Listed = o.BoolValue()
+		case "invite_hours":
+			g.InviteMaxAge = int(o.IntValue()) * 3600
+		case "invite_uses":
+			g.InviteMaxUses = int(o.IntValue())
+		case "language":
+			g.Language = o.StringValue()
+		}
+	}
+
+	if g.Rules != "" {
+		_, err = rules.Parse(g.Rules, s.backends)
+		if err != nil {
+			discord.Reply(session, i, s.t(i.GuildID, "setup.invalid_rules", err))
+			return
+		}
+	}
+
+	err = s.Store.PutGuild(g)
+	if err != nil {
+		log.WithError(err).WithField("guild", g.ID).Error("could not save guild")
+		discord.Reply(session, i, s.t(i.GuildID, "error"))
+		return
+	}
+	log.WithFields(log.Fields{
+		"guild": g.ID,
+		"by":    discord.User(i).ID,
+	}).Info("saved guild settings")
+
+	if verifyChannel != "" {
+		err = s.postVerifyButton(i.GuildID, verifyChannel)
+		if err != nil {
+			log.WithError(err).WithField("channel", verifyChannel).Error("could not post verification button")
+			discord.Reply(session, i, s.t(i.GuildID, "setup.button_failed", "<#"+verifyChannel+">", err))
+			return
+		}
+	}
+
+	discord.Reply(session, i, s.t(i.GuildID, "setup.saved")+"\n"+s.describeGuild(g))
+}
+
+// describeGuild formats the settings of a guild for its admins.
+func (s *Service) describeGuild(g *store.Guild) string {
+	var b strings.Builder
+	fmt.Fprintln(&b, s.t(g.ID, "setup.channel", "<#"+g.ChannelID+">"))
+	if g.Rules != "" {
+		fmt.Fprintln(&b, s.t(g.ID, "setup.rules", "`"+g.Rules+"`"))
+	}
+	if g.RoleID != "" {
+		fmt.Fprintln(&b, s.t(g.ID, "setup.role", "<@&"+g.RoleID+">"))
+	}
+	if g.Listed {
+		fmt.Fprintln(&b, s.t(g.ID, "setup.listed", g.Name))
+	}
+	if g.InviteMaxAge != 0 {
+		fmt.Fprintln(&b, s.t(g.ID, "setup.invite_hours", g.InviteMaxAge/3600))
+	}
+	if g.InviteMaxUses != 0 {
+		fmt.Fprintln(&b, s.t(g.ID, "setup.invite_uses", g.InviteMaxUses))
+	}
+	if g.Language != "" {
+		fmt.Fprintln(&b, s.t(g.ID, "setup.language", g.Language))
+	}
+	return b.String()
+}
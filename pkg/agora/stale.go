@@ -0,0 +1,131 @@
+package agora
+
+import "errors"
+import "fmt"
+import "strings"
+
+import log "github.com/apex/log"
+import "github.com/bwmarrin/discordgo"
+
+import "github.com/aaronwinter/tezosagora/pkg/discord"
+import "github.com/aaronwinter/tezosagora/pkg/store"
+
+// maxReply keeps replies within the message length limit of Discord.
+const maxReply = 1900
+
+// stale is a registration that no longer serves anyone.
+type stale struct {
+	reg    *store.Registration
+	reason string
+	// left is set when the member is gone, so there is no role to remove.
+	left bool
+}
+
+// registerStaleCommand declares /stale.
+func (s *Service) registerStaleCommand() {
+	s.Bot.Command(&discordgo.ApplicationCommand{
+		Name:                     "stale",
+		Description:              "List registrations whose member left or whose wallet no longer qualifies",
+		DefaultMemberPermissions: &adminPermissions,
+		DMPermission:             &noDM,
+		Options: []*discordgo.ApplicationCommandOption{{
+			Type:        discordgo.ApplicationCommandOptionBoolean,
+			Name:        "revoke",
+			Description: "Revoke them all, removing the roles of members still there",
+		}},
+	}, s.staleCommand)
+}
+
+// staleRegistrations returns the registrations tied to members who left
+// GuildID, and the ones disqualified by a sweep.
+func (s *Service) staleRegistrations() ([]stale, error) {
+	var regs []*store.Registration
+	err := s.Store.Each(func(reg *store.Registration) error {
+		regs = append(regs, reg)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var found []stale
+	for _, reg := range regs {
+		if !reg.Disqualified.IsZero() {
+			found = append(found, stale{reg, "no longer qualifies", false})
+			continue
+		}
+		if reg.DiscordID == "" || s.Config.GuildID == "" {
+			continue
+		}
+
+		_, err = s.Discord.GuildMember(s.Config.GuildID, reg.DiscordID)
+		var restErr *discordgo.RESTError
+		if errors.As(err, &restErr) && restErr.Message != nil && restErr.Message.Code == discordgo.ErrCodeUnknownMember {
+			found = append(found, stale{reg, "member left", true})
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	return found, nil
+}
+
+func (s *Service) staleCommand(session *discordgo.Session, i *discordgo.InteractionCreate) {
+	revoke := false
+	for _, o := range i.ApplicationCommandData().Options {
+		if o.Name == "revoke" {
+			revoke = o.BoolValue()
+		}
+	}
+
+	err := discord.Defer(session, i)
+	if err != nil {
+		log.WithError(err).Warn("could not acknowledge command")
+		return
+	}
+
+	found, err := s.staleRegistrations()
+	if err != nil {
+		log.WithError(err).Error("could not look stale registrations up")
+		discord.EditReply(session, i, s.t(i.GuildID, "error"))
+		return
+	}
+	if len(found) == 0 {
+		discord.EditReply(session, i, s.t(i.GuildID, "stale.none"))
+		return
+	}
+
+	var b strings.Builder
+	shown := 0
+	for n, st := range found {
+		if revoke {
+			err = s.revoke(st.reg, !st.left, "stale, "+st.reason)
+			if err != nil {
+				log.WithError(err).WithField("wallet", st.reg.Wallet).Error("could not revoke registration")
+				fmt.Fprintln(&b, s.t(i.GuildID, "admin.revoke_failed", st.reg.Wallet, err))
+				break
+			}
+		}
+
+		reason := s.t(i.GuildID, "stale.disqualified")
+		if st.left {
+			reason = s.t(i.GuildID, "stale.left")
+		}
+		line := fmt.Sprintf("%v: %v\n", st.reg.Wallet, reason)
+		if shown == n && b.Len()+len(line) <= maxReply {
+			b.WriteString(line)
+			shown++
+		}
+	}
+	if shown < len(found) && err == nil {
+		fmt.Fprintln(&b, s.t(i.GuildID, "stale.more", len(found)-shown))
+	}
+
+	if revoke && err == nil {
+		log.WithFields(log.Fields{
+			"count": len(found),
+			"by":    discord.User(i).ID,
+		}).Warn("revoked stale registrations")
+		b.WriteString(s.t(i.GuildID, "stale.revoked"))
+	}
+	discord.EditReply(session, i, b.String())
+}
@@ -0,0 +1,54 @@
+package agora
+
+import log "github.com/apex/log"
+import "github.com/bwmarrin/discordgo"
+
+import "github.com/aaronwinter/tezosagora/pkg/discord"
+
+// verifyThread posts embed in a private thread opened for the author of i,
+// replacing their previous one.
+func (s *Service) verifyThread(session *discordgo.Session, i *discordgo.InteractionCreate, embed *discordgo.MessageEmbed) {
+	user := discord.User(i)
+	s.closeThread(user.ID, s.t(i.GuildID, "thread.replaced"))
+
+	thread, err := discord.OpenThread(session, i.ChannelID, user.ID, "verify-"+user.Username)
+	if err != nil {
+		log.WithError(err).WithField("user", user.ID).Error("could not open verification thread")
+		discord.Reply(session, i, s.t(i.GuildID, "error"))
+		return
+	}
+
+	err = s.Store.PutThread(user.ID, thread.ID)
+	if err != nil {
+		log.WithError(err).WithField("user", user.ID).Error("could not record verification thread")
+	}
+
+	_, err = session.ChannelMessageSendComplex(thread.ID, &discordgo.MessageSend{
+		Content: s.t(i.GuildID, "thread.help", "<@"+user.ID+">"),
+		Embeds:  []*discordgo.MessageEmbed{embed},
+	})
+	if err != nil {
+		log.WithError(err).WithField("user", user.ID).Warn("could not post in verification thread")
+	}
+
+	discord.Reply(session, i, s.t(i.GuildID, "thread.continue", "<#"+thread.ID+">"))
+}
+
+// closeThread posts message in the verification thread of userID, if any,
+// and archives it.
+func (s *Service) closeThread(userID, message string) {
+	threadID, err := s.Store.TakeThread(userID)
+	if err != nil || threadID == "" {
+		return
+	}
+
+	_, err = s.Discord.ChannelMessageSend(threadID, message)
+	if err != nil {
+		log.WithError(err).WithField("user", userID).Warn("could not post in verification thread")
+	}
+
+	err = discord.CloseThread(s.Discord, threadID)
+	if err != nil {
+		log.WithError(err).WithField("user", userID).Warn("could not archive verification thread")
+	}
+}
@@ -0,0 +1,31 @@
+package agora
+
+import "time"
+
+import log "github.com/apex/log"
+
+// ticketPruneInterval is how often the records of expired signed tickets
+// are deleted.
+const ticketPruneInterval = time.Hour
+
+// runTicketPrune deletes the records of the signed tickets which expired
+// every interval until stop is closed.
+func (s *Service) runTicketPrune(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := s.Store.PruneTickets()
+		if err != nil {
+			log.WithError(err).Error("could not prune used tickets")
+		} else if n > 0 {
+			log.WithField("count", n).Debug("pruned used tickets")
+		}
+
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package agora
+
+import "context"
+import "strings"
+
+import log "github.com/apex/log"
+
+import "github.com/aaronwinter/tezosagora/pkg/discord"
+import "github.com/aaronwinter/tezosagora/pkg/rules"
+import "github.com/aaronwinter/tezosagora/pkg/store"
+
+// applyTiers grants the Discord account of reg the roles of the tiers its
+// wallets qualify for and revokes the others, telling the member about the
+// changes if NotifyTiers is set.
+func (s *Service) applyTiers(reg *store.Registration) {
+	if len(s.Tiers) == 0 || reg.DiscordID == "" {
+		return
+	}
+
+	var grant, revoke []string
+	for _, tier := range s.Tiers {
+		report, err := rules.EvaluateGroup(context.Background(), tier.Rule, reg.Wallets())
+		if err != nil {
+			log.WithError(err).WithFields(log.Fields{
+				"wallet": reg.Wallet,
+				"tier":   tier.Name,
+			}).Error("could not evaluate tier")
+			return
+		}
+
+		if report.Passed {
+			grant = append(grant, tier.ID)
+		} else {
+			revoke = append(revoke, tier.ID)
+		}
+	}
+
+	granted, revoked, err := s.Roles.Update(reg.DiscordID, grant, revoke)
+	if err != nil {
+		log.WithError(err).WithField("wallet", reg.Wallet).Error("could not sync tier roles")
+	}
+
+	if s.Config.NotifyTiers && len(granted)+len(revoked) > 0 {
+		s.notifyTiers(reg, granted, revoked)
+	}
+}
+
+// notifyTiers DMs the member of reg the tiers they reached and lost.
+func (s *Service) notifyTiers(reg *store.Registration, granted, revoked []string) {
+	names := make(map[string]string, len(s.Tiers))
+	for _, tier := range s.Tiers {
+		names[tier.ID] = tier.Name
+	}
+	list := func(ids []string) string {
+		var tiers []string
+		for _, id := range ids {
+			tiers = append(tiers, "**"+names[id]+"**")
+		}
+		return strings.Join(tiers, ", ")
+	}
+
+	var lines []string
+	if len(granted) > 0 {
+		lines = append(lines, s.t("", "tiers.granted", list(granted)))
+	}
+	if len(revoked) > 0 {
+		lines = append(lines, s.t("", "tiers.revoked", list(revoked)))
+	}
+
+	err := discord.DM(s.Discord, reg.DiscordID, strings.Join(lines, "\n"))
+	if err != nil {
+		log.WithError(err).WithField("user", reg.DiscordID).Warn("could not notify tier change")
+	}
+}
@@ -0,0 +1,48 @@
+package agora
+
+import "bytes"
+import "text/template"
+
+import log "github.com/apex/log"
+
+import "github.com/aaronwinter/tezosagora/pkg/discord"
+import "github.com/aaronwinter/tezosagora/pkg/store"
+
+// welcomeData is handed to the welcome template.
+type welcomeData struct {
+	// Mention pings the member.
+	Mention  string
+	Wallet   string
+	Verified bool
+}
+
+// welcome DMs the member who joined with the invite of reg.
+func (s *Service) welcome(reg *store.Registration) {
+	if s.welcomeTemplate == nil {
+		return
+	}
+
+	var b bytes.Buffer
+	err := s.welcomeTemplate.Execute(&b, welcomeData{
+		Mention:  "<@" + reg.DiscordID + ">",
+		Wallet:   reg.Wallet,
+		Verified: reg.Disqualified.IsZero(),
+	})
+	if err != nil {
+		log.WithError(err).Error("could not render welcome message")
+		return
+	}
+
+	err = discord.DM(s.Discord, reg.DiscordID, b.String())
+	if err != nil {
+		log.WithError(err).WithField("user", reg.DiscordID).Warn("could not send welcome message")
+	}
+}
+
+// loadWelcome parses the welcome template at path, if any.
+func loadWelcome(path string) (*template.Template, error) {
+	if path == "" {
+		return nil, nil
+	}
+	return template.ParseFiles(path)
+}
@@ -0,0 +1,117 @@
+// Package blocklist keeps track of addresses that may not register, such as
+// exchange hot wallets and custodial accounts: holding funds there says
+// nothing about community membership.
+package blocklist
+
+import "bufio"
+import "io"
+import "os"
+import "strings"
+import "sync"
+
+// Checker tells whether an address is blocked, and why.
+type Checker interface {
+	Blocked(address string) (reason string, blocked bool, err error)
+}
+
+// List is an in-memory set of blocked addresses. It is safe for concurrent
+// use.
+type List struct {
+	mu      sync.RWMutex
+	entries map[string]string
+}
+
+// New returns an empty List.
+func New() *List {
+	return &List{entries: make(map[string]string)}
+}
+
+// Add blocks address for reason.
+func (l *List) Add(address, reason string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.entries[address] = reason
+}
+
+// Replace swaps the whole content of the list.
+func (l *List) Replace(entries map[string]string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.entries = entries
+}
+
+// Len returns the number of blocked addresses.
+func (l *List) Len() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return len(l.entries)
+}
+
+// Blocked implements Checker.
+func (l *List) Blocked(address string) (string, bool, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	reason, ok := l.entries[address]
+	return reason, ok, nil
+}
+
+// Read parses a blocklist with one address per line, optionally followed by
+// a comma and the reason it is blocked. Empty lines and lines starting with
+// # are ignored. Entries without a reason get defaultReason.
+func Read(r io.Reader, defaultReason string) (map[string]string, error) {
+	entries := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.SplitN(line, ",", 2)
+		reason := defaultReason
+		if len(fields) == 2 && strings.TrimSpace(fields[1]) != "" {
+			reason = strings.TrimSpace(fields[1])
+		}
+		entries[strings.TrimSpace(fields[0])] = reason
+	}
+	return entries, scanner.Err()
+}
+
+// LoadFile adds the entries of the blocklist file at path to l.
+func (l *List) LoadFile(path, defaultReason string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	entries, err := Read(f, defaultReason)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for address, reason := range entries {
+		l.entries[address] = reason
+	}
+	return nil
+}
+
+// Chain blocks an address as soon as one of its checkers does.
+type Chain []Checker
+
+// Blocked implements Checker.
+func (c Chain) Blocked(address string) (string, bool, error) {
+	for _, checker := range c {
+		reason, blocked, err := checker.Blocked(address)
+		if err != nil || blocked {
+			return reason, blocked, err
+		}
+	}
+	return "", false, nil
+}
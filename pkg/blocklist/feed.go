@@ -0,0 +1,123 @@
+package blocklist
+
+import "bytes"
+import "encoding/json"
+import "fmt"
+import "io"
+import "net/http"
+import "time"
+
+import log "github.com/apex/log"
+
+// Feed keeps List in sync with a remote denylist. The feed is either a JSON
+// array of addresses or of {"address": ..., "reason": ...} objects, or CSV
+// lines of address and optional reason.
+type Feed struct {
+	URL  string
+	HTTP *http.Client
+	List *List
+	// Reason is used for entries that don't carry their own.
+	Reason string
+}
+
+// NewFeed returns a Feed filling a new List from url.
+func NewFeed(url string) *Feed {
+	return &Feed{
+		URL:    url,
+		HTTP:   http.DefaultClient,
+		List:   New(),
+		Reason: fmt.Sprintf("listed by %v", url),
+	}
+}
+
+// Blocked implements Checker.
+func (f *Feed) Blocked(address string) (string, bool, error) {
+	return f.List.Blocked(address)
+}
+
+// Refresh downloads the feed and replaces the list with its content. On
+// failure the previous content is kept.
+func (f *Feed) Refresh() error {
+	resp, err := f.HTTP.Get(f.URL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("blocklist: %v returned %v", f.URL, resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64<<20))
+	if err != nil {
+		return err
+	}
+
+	entries, err := f.parse(body)
+	if err != nil {
+		return err
+	}
+
+	f.List.Replace(entries)
+	log.WithFields(log.Fields{
+		"url":     f.URL,
+		"entries": len(entries),
+	}).Info("refreshed blocklist feed")
+	return nil
+}
+
+func (f *Feed) parse(body []byte) (map[string]string, error) {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 || trimmed[0] != '[' {
+		entries, err := Read(bytes.NewReader(body), f.Reason)
+		delete(entries, "address") // CSV header
+		return entries, err
+	}
+
+	var raw []json.RawMessage
+	err := json.Unmarshal(trimmed, &raw)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make(map[string]string, len(raw))
+	for _, r := range raw {
+		var address string
+		if json.Unmarshal(r, &address) == nil {
+			entries[address] = f.Reason
+			continue
+		}
+
+		var entry struct {
+			Address string `json:"address"`
+			Reason  string `json:"reason"`
+		}
+		err := json.Unmarshal(r, &entry)
+		if err != nil || entry.Address == "" {
+			return nil, fmt.Errorf("blocklist: bad feed entry %s", r)
+		}
+		if entry.Reason == "" {
+			entry.Reason = f.Reason
+		}
+		entries[entry.Address] = entry.Reason
+	}
+	return entries, nil
+}
+
+// Run refreshes the feed every interval until stop is closed.
+func (f *Feed) Run(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			err := f.Refresh()
+			if err != nil {
+				log.WithError(err).WithField("url", f.URL).Error("could not refresh blocklist feed")
+			}
+		}
+	}
+}
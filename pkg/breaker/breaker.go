@@ -0,0 +1,140 @@
+// Package breaker implements a circuit breaker: after enough consecutive
+// failures calls are rejected outright for a cooldown period, then a single
+// trial call decides whether to close the circuit again.
+package breaker
+
+import "errors"
+import "sync"
+import "time"
+
+import log "github.com/apex/log"
+
+// ErrOpen is returned by Do while the circuit is open.
+var ErrOpen = errors.New("circuit breaker is open")
+
+// State is the state of a Breaker.
+type State int
+
+// Breaker states.
+const (
+	Closed State = iota
+	Open
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	}
+	return "closed"
+}
+
+// Breaker opens after Threshold consecutive failures and stays open for
+// Cooldown.
+type Breaker struct {
+	Name      string
+	Threshold int
+	Cooldown  time.Duration
+	// Failure, when set, tells which errors are failures. Others mean the
+	// upstream answered, if not what was hoped for, and count as successes.
+	Failure func(err error) bool
+
+	mu       sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// New returns a closed Breaker.
+func New(name string, threshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{
+		Name:      name,
+		Threshold: threshold,
+		Cooldown:  cooldown,
+	}
+}
+
+func (b *Breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case Open:
+		if time.Since(b.openedAt) < b.Cooldown {
+			return false
+		}
+		b.state = HalfOpen
+		b.probing = true
+		log.WithField("breaker", b.Name).Info("circuit half-open, probing")
+		return true
+	case HalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+	}
+	return true
+}
+
+func (b *Breaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	if err == nil || b.Failure != nil && !b.Failure(err) {
+		if b.state != Closed {
+			log.WithField("breaker", b.Name).Info("circuit closed")
+		}
+		b.state = Closed
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.state == HalfOpen || b.failures >= b.Threshold {
+		if b.state != Open {
+			log.WithError(err).WithField("breaker", b.Name).Warn("circuit open")
+		}
+		b.state = Open
+		b.openedAt = time.Now()
+	}
+}
+
+// Do runs f unless the circuit is open, in which case it returns ErrOpen.
+func (b *Breaker) Do(f func() error) error {
+	if !b.allow() {
+		return ErrOpen
+	}
+
+	err := f()
+	b.record(err)
+	return err
+}
+
+// State returns the current state of the circuit.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state
+}
+
+// RetryAfter returns how long until an open circuit lets a trial call
+// through.
+func (b *Breaker) RetryAfter() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state != Open {
+		return 0
+	}
+	left := b.Cooldown - time.Since(b.openedAt)
+	if left < 0 {
+		return 0
+	}
+	return left
+}
@@ -0,0 +1,42 @@
+package breaker
+
+import "errors"
+import "fmt"
+import "net/http"
+import "testing"
+import "time"
+
+import "github.com/aaronwinter/tezosagora/pkg/tezos"
+
+func TestRefusalsDontTrip(t *testing.T) {
+	b := New("test", 3, time.Minute)
+	b.Failure = tezos.Temporary
+
+	for _, code := range []int{http.StatusBadRequest, http.StatusNotFound, http.StatusBadRequest, http.StatusNotFound} {
+		refused := fmt.Errorf("rule: %w", &tezos.StatusError{Status: http.StatusText(code), Code: code})
+		err := b.Do(func() error { return refused })
+		if err != refused {
+			t.Fatalf("got %v, want the refusal", err)
+		}
+	}
+	if b.State() != Closed {
+		t.Fatalf("circuit %v after refusals, want closed", b.State())
+	}
+}
+
+func TestUnavailabilityTrips(t *testing.T) {
+	b := New("test", 3, time.Minute)
+	b.Failure = tezos.Temporary
+
+	down := &tezos.StatusError{Status: "503 Service Unavailable", Code: http.StatusServiceUnavailable}
+	for i := 0; i < 3; i++ {
+		b.Do(func() error { return down })
+	}
+	if b.State() != Open {
+		t.Fatalf("circuit %v after failures, want open", b.State())
+	}
+	err := b.Do(func() error { return nil })
+	if !errors.Is(err, ErrOpen) {
+		t.Fatalf("got %v, want ErrOpen", err)
+	}
+}
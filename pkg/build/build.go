@@ -0,0 +1,68 @@
+// Package build tells which build of the service is running. Release builds
+// set its variables with the linker, as in
+//
+//	go build -ldflags "-X github.com/aaronwinter/tezosagora/pkg/build.Version=v1.2.0
+//	  -X github.com/aaronwinter/tezosagora/pkg/build.Commit=$(git rev-parse HEAD)
+//	  -X github.com/aaronwinter/tezosagora/pkg/build.Date=$(date -u +%FT%TZ)"
+//
+// Otherwise, Commit and Date come from the version control information Go
+// embeds in binaries built from a checkout, when available.
+package build
+
+import "runtime"
+import "runtime/debug"
+
+import log "github.com/apex/log"
+
+// Set by the linker.
+var (
+	Version = "dev"
+	Commit  string
+	Date    string
+)
+
+// Info describes the running build.
+type Info struct {
+	Version string `json:"version"`
+	Commit  string `json:"commit,omitempty"`
+	Date    string `json:"date,omitempty"`
+	// Modified tells that the checkout had uncommitted changes.
+	Modified bool   `json:"modified,omitempty"`
+	Go       string `json:"go"`
+}
+
+// Get returns the Info of the running build.
+func Get() Info {
+	info := Info{Version: Version, Commit: Commit, Date: Date, Go: runtime.Version()}
+	if info.Commit != "" {
+		return info
+	}
+
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	for _, setting := range bi.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			info.Commit = setting.Value
+		case "vcs.time":
+			if info.Date == "" {
+				info.Date = setting.Value
+			}
+		case "vcs.modified":
+			info.Modified = setting.Value == "true"
+		}
+	}
+	return info
+}
+
+// Fields returns Info as log fields, for log.WithFields.
+func (i Info) Fields() log.Fields {
+	return log.Fields{
+		"version": i.Version,
+		"commit":  i.Commit,
+		"date":    i.Date,
+		"go":      i.Go,
+	}
+}
@@ -0,0 +1,75 @@
+// Package cache provides a small in-memory cache whose entries expire after
+// a fixed TTL.
+package cache
+
+import "sync"
+import "time"
+
+type entry struct {
+	value   interface{}
+	expires time.Time
+}
+
+// Cache maps string keys to values for TTL. It is safe for concurrent use.
+type Cache struct {
+	TTL time.Duration
+
+	mu      sync.Mutex
+	entries map[string]entry
+	purged  time.Time
+}
+
+// New returns an empty Cache keeping entries for ttl.
+func New(ttl time.Duration) *Cache {
+	return &Cache{
+		TTL:     ttl,
+		entries: make(map[string]entry),
+		purged:  time.Now(),
+	}
+}
+
+// Get returns the value stored under key, if it has not expired.
+func (c *Cache) Get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok || time.Now().After(e.expires) {
+		return nil, false
+	}
+	return e.value, true
+}
+
+// Set stores value under key.
+func (c *Cache) Set(key string, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(c.purged) > c.TTL {
+		for k, e := range c.entries {
+			if now.After(e.expires) {
+				delete(c.entries, k)
+			}
+		}
+		c.purged = now
+	}
+
+	c.entries[key] = entry{value: value, expires: now.Add(c.TTL)}
+}
+
+// Delete forgets key.
+func (c *Cache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, key)
+}
+
+// Len returns the number of entries, expired or not.
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.entries)
+}
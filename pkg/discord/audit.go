@@ -0,0 +1,85 @@
+package discord
+
+import "errors"
+import "strings"
+import "time"
+
+import log "github.com/apex/log"
+import "github.com/bwmarrin/discordgo"
+
+// Embed colors of the audit events.
+const (
+	colorRegistered = 0x2ecc71
+	colorRejected   = 0xe67e22
+	colorRevoked    = 0xe74c3c
+)
+
+// ErrBadWebhook is returned for URLs that aren't Discord webhooks.
+var ErrBadWebhook = errors.New("discord: not a webhook URL")
+
+// Audit posts an embed to a moderator channel for every registration
+// event, as a live audit trail.
+type Audit struct {
+	Session   *discordgo.Session
+	ChannelID string
+	// WebhookID and WebhookToken, when set, post through that webhook
+	// instead, which needs no bot.
+	WebhookID    string
+	WebhookToken string
+}
+
+// NewWebhookAudit returns an Audit posting to the webhook at url, such as
+// https://discord.com/api/webhooks/<id>/<token>.
+func NewWebhookAudit(session *discordgo.Session, url string) (*Audit, error) {
+	parts := strings.Split(strings.TrimSuffix(url, "/"), "/")
+	if len(parts) < 3 || parts[len(parts)-3] != "webhooks" {
+		return nil, ErrBadWebhook
+	}
+
+	return &Audit{
+		Session:      session,
+		WebhookID:    parts[len(parts)-2],
+		WebhookToken: parts[len(parts)-1],
+	}, nil
+}
+
+func (a *Audit) post(title string, color int, fields ...*discordgo.MessageEmbedField) {
+	embed := &discordgo.MessageEmbed{
+		Title:     title,
+		Color:     color,
+		Fields:    fields,
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+	}
+
+	var err error
+	if a.WebhookID != "" {
+		_, err = a.Session.WebhookExecute(a.WebhookID, a.WebhookToken, false, &discordgo.WebhookParams{
+			Embeds: []*discordgo.MessageEmbed{embed},
+		})
+	} else {
+		_, err = a.Session.ChannelMessageSendEmbed(a.ChannelID, embed)
+	}
+	if err != nil {
+		log.WithError(err).WithField("title", title).Error("could not post audit event")
+	}
+}
+
+// Registered reports that wallet obtained inviteURL.
+func (a *Audit) Registered(wallet, inviteURL string) {
+	a.post("Wallet registered", colorRegistered, Field("Wallet", wallet), Field("Invite", inviteURL))
+}
+
+// Rejected reports that wallet failed verification for reason.
+func (a *Audit) Rejected(wallet, reason string) {
+	a.post("Verification failed", colorRejected, Field("Wallet", wallet), Field("Reason", reason))
+}
+
+// Revoked reports that the registration of wallet was revoked for reason.
+func (a *Audit) Revoked(wallet, reason string) {
+	a.post("Registration revoked", colorRevoked, Field("Wallet", wallet), Field("Reason", reason))
+}
+
+// Blocked reports that wallet may no longer register for reason.
+func (a *Audit) Blocked(wallet, reason string) {
+	a.post("Wallet blocked", colorRevoked, Field("Wallet", wallet), Field("Reason", reason))
+}
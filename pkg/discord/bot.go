@@ -0,0 +1,239 @@
+package discord
+
+import "crypto/ed25519"
+import "encoding/json"
+import "fmt"
+import "net/http"
+
+import log "github.com/apex/log"
+import "github.com/bwmarrin/discordgo"
+
+// A CommandHandler answers an application command interaction.
+type CommandHandler func(s *discordgo.Session, i *discordgo.InteractionCreate)
+
+// Bot dispatches the interactions of the slash commands it registered in
+// GuildID, or globally when GuildID is empty, and of its components.
+type Bot struct {
+	Session *discordgo.Session
+	GuildID string
+
+	// PublicKey, when set, has interactions received over HTTP through
+	// ServeHTTP, signed with this application key, rather than through the
+	// gateway. Open then only connects to the gateway if Gateway is set,
+	// which OnDM and other session handlers need.
+	PublicKey ed25519.PublicKey
+	Gateway   bool
+
+	// ShardCount splits the gateway connection over that many shards, as
+	// recommended by Discord when 0. ShardIDs are the shards this process
+	// runs, all of them when empty.
+	ShardCount int
+	ShardIDs   []int
+
+	// OnDM, when set, is called for every direct message sent to the bot.
+	OnDM func(s *discordgo.Session, m *discordgo.MessageCreate)
+
+	commands   []*discordgo.ApplicationCommand
+	handlers   map[string]CommandHandler
+	components map[string]CommandHandler
+	events     []interface{}
+	shards     []*discordgo.Session
+}
+
+// NewBot returns a Bot without commands.
+func NewBot(session *discordgo.Session, guildID string) *Bot {
+	return &Bot{
+		Session:    session,
+		GuildID:    guildID,
+		ShardCount: 1,
+		handlers:   make(map[string]CommandHandler),
+		components: make(map[string]CommandHandler),
+	}
+}
+
+// Command registers cmd, answered by h. It must be called before Open.
+func (b *Bot) Command(cmd *discordgo.ApplicationCommand, h CommandHandler) {
+	b.commands = append(b.commands, cmd)
+	b.handlers[cmd.Name] = h
+}
+
+// Component routes the interactions of the buttons and modals whose custom
+// ID is customID to h.
+func (b *Bot) Component(customID string, h CommandHandler) {
+	b.components[customID] = h
+}
+
+// Open connects to the gateway, unless interactions come over HTTP and
+// nothing else needs it, and publishes the commands.
+func (b *Bot) Open() error {
+	if b.PublicKey == nil || b.Gateway {
+		b.AddHandler(b.dispatch)
+		if b.OnDM != nil {
+			b.Session.Identify.Intents |= discordgo.IntentsDirectMessages
+			b.AddHandler(b.dispatchDM)
+		}
+
+		err := b.openShards()
+		if err != nil {
+			return err
+		}
+	}
+
+	me, err := b.Session.User("@me")
+	if err == nil {
+		_, err = b.Session.ApplicationCommandBulkOverwrite(me.ID, b.GuildID, b.commands)
+	}
+	if err != nil {
+		b.Close()
+		return err
+	}
+
+	log.WithField("commands", len(b.commands)).Info("published Discord commands")
+	return nil
+}
+
+// Ready returns an error unless every shard is connected to the gateway.
+func (b *Bot) Ready() error {
+	for i, session := range b.shards {
+		session.RLock()
+		ready := session.DataReady
+		session.RUnlock()
+		if !ready {
+			return fmt.Errorf("discord: shard %v is disconnected", i)
+		}
+	}
+	return nil
+}
+
+// Close disconnects the shards from the gateway.
+func (b *Bot) Close() error {
+	var err error
+	for _, session := range b.shards {
+		if e := session.Close(); e != nil {
+			err = e
+		}
+	}
+	b.shards = nil
+	return err
+}
+
+// ServeHTTP answers Discord's HTTP interactions requests, which must carry a
+// valid signature by PublicKey.
+func (b *Bot) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if b.PublicKey == nil || !discordgo.VerifyInteraction(r, b.PublicKey) {
+		http.Error(w, "invalid request signature", http.StatusUnauthorized)
+		return
+	}
+
+	i := &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{}}
+	err := json.NewDecoder(r.Body).Decode(i.Interaction)
+	if err != nil {
+		http.Error(w, "bad interaction", http.StatusBadRequest)
+		return
+	}
+
+	if i.Type == discordgo.InteractionPing {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(discordgo.InteractionResponse{Type: discordgo.InteractionResponsePong})
+		return
+	}
+
+	// Handlers answer through the callback endpoint, so the request only
+	// has to be acknowledged once they are done.
+	b.dispatch(b.Session, i)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (b *Bot) dispatch(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	var h CommandHandler
+	switch i.Type {
+	case discordgo.InteractionApplicationCommand:
+		h = b.handlers[i.ApplicationCommandData().Name]
+	case discordgo.InteractionMessageComponent:
+		h = b.components[i.MessageComponentData().CustomID]
+	case discordgo.InteractionModalSubmit:
+		h = b.components[i.ModalSubmitData().CustomID]
+	}
+
+	if h != nil {
+		h(s, i)
+	}
+}
+
+func (b *Bot) dispatchDM(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if m.GuildID != "" || m.Author == nil || m.Author.Bot {
+		return
+	}
+	b.OnDM(s, m)
+}
+
+// User returns the user behind an interaction, be it in a guild or in DMs.
+func User(i *discordgo.InteractionCreate) *discordgo.User {
+	if i.Member != nil {
+		return i.Member.User
+	}
+	return i.User
+}
+
+// Reply answers an interaction with a message only its author can see.
+func Reply(s *discordgo.Session, i *discordgo.InteractionCreate, content string) error {
+	return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// DM sends content to userID in a direct message.
+func DM(s *discordgo.Session, userID, content string) error {
+	channel, err := s.UserChannelCreate(userID)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.ChannelMessageSend(channel.ID, content)
+	return err
+}
+
+// Defer acknowledges an interaction whose answer, only visible to its
+// author, takes longer than Discord waits for. It is given with EditReply.
+func Defer(s *discordgo.Session, i *discordgo.InteractionCreate) error {
+	return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// EditReply sets the answer to a deferred interaction.
+func EditReply(s *discordgo.Session, i *discordgo.InteractionCreate, content string) error {
+	_, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content: &content,
+	})
+	return err
+}
+
+// ModalValue returns the value of the text input customID in a submitted
+// modal.
+func ModalValue(i *discordgo.InteractionCreate, customID string) string {
+	for _, c := range i.ModalSubmitData().Components {
+		row, ok := c.(*discordgo.ActionsRow)
+		if !ok {
+			continue
+		}
+		for _, c := range row.Components {
+			input, ok := c.(*discordgo.TextInput)
+			if ok && input.CustomID == customID {
+				return input.Value
+			}
+		}
+	}
+	return ""
+}
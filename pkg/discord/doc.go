@@ -0,0 +1,3 @@
+// Package discord creates the Discord invites handed out to verified wallets
+// and links wallets to Discord accounts.
+package discord
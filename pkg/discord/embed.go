@@ -0,0 +1,94 @@
+package discord
+
+import "fmt"
+import "path"
+import "time"
+
+import "github.com/bwmarrin/discordgo"
+
+// Branding is the look of the embeds members get from the bot.
+type Branding struct {
+	Color        int
+	ThumbnailURL string
+	Footer       string
+}
+
+// Embed returns an embed in the colors of b.
+func (b Branding) Embed(title, description string, fields ...*discordgo.MessageEmbedField) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:       title,
+		Description: description,
+		Color:       b.Color,
+		Fields:      fields,
+	}
+	if b.ThumbnailURL != "" {
+		embed.Thumbnail = &discordgo.MessageEmbedThumbnail{URL: b.ThumbnailURL}
+	}
+	if b.Footer != "" {
+		embed.Footer = &discordgo.MessageEmbedFooter{Text: b.Footer}
+	}
+	return embed
+}
+
+// Field returns an inline embed field, showing a dash for empty values
+// which Discord rejects.
+func Field(name, value string) *discordgo.MessageEmbedField {
+	if value == "" {
+		value = "-"
+	}
+	return &discordgo.MessageEmbedField{Name: name, Value: value, Inline: true}
+}
+
+// ShortAddress truncates address to its first and last characters.
+func ShortAddress(address string) string {
+	if len(address) <= 14 {
+		return address
+	}
+	return address[:8] + "…" + address[len(address)-4:]
+}
+
+// Countdown formats t as a timestamp Discord shows relative to now.
+func Countdown(t time.Time) string {
+	return fmt.Sprintf("<t:%v:R>", t.Unix())
+}
+
+// InviteExpiry returns when the invite at inviteURL expires, or the zero
+// time if it never does.
+func InviteExpiry(s *discordgo.Session, inviteURL string) (time.Time, error) {
+	invite, err := s.InviteComplex(path.Base(inviteURL), "", false, true)
+	if err != nil || invite.ExpiresAt == nil {
+		return time.Time{}, err
+	}
+	return *invite.ExpiresAt, nil
+}
+
+// DMEmbed sends embed to userID in a direct message.
+func DMEmbed(s *discordgo.Session, userID string, embed *discordgo.MessageEmbed) error {
+	channel, err := s.UserChannelCreate(userID)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.ChannelMessageSendEmbed(channel.ID, embed)
+	return err
+}
+
+// ReplyEmbed answers an interaction with an embed only its author can see.
+func ReplyEmbed(s *discordgo.Session, i *discordgo.InteractionCreate, embed *discordgo.MessageEmbed) error {
+	return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{embed},
+			Flags:  discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// EditReplyEmbed sets the answer to a deferred interaction to embed.
+func EditReplyEmbed(s *discordgo.Session, i *discordgo.InteractionCreate, embed *discordgo.MessageEmbed) error {
+	embeds := []*discordgo.MessageEmbed{embed}
+	_, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Embeds: &embeds,
+	})
+	return err
+}
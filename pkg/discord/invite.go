@@ -0,0 +1,133 @@
+package discord
+
+import "context"
+import "errors"
+import "fmt"
+import "math/rand"
+import "path"
+import "time"
+
+import log "github.com/apex/log"
+import "github.com/bwmarrin/discordgo"
+
+import "github.com/aaronwinter/tezosagora/pkg/metrics"
+
+// ErrInvitesClosed is returned once the fixed expiry of invites has passed.
+var ErrInvitesClosed = errors.New("discord: invites expiry has passed")
+
+// Inviter generates invites to a Discord channel, by default single use
+// ones expiring after a random duration between two hours and a day.
+type Inviter struct {
+	Session   *discordgo.Session
+	ChannelID string
+	URL       string
+
+	// Invites expire after a random duration between MinAge and MaxAge, a
+	// fixed one when both are equal, or never when MaxAge is 0.
+	MinAge time.Duration
+	MaxAge time.Duration
+	// ExpiresAt, when set, makes every invite expire at that time instead,
+	// e.g. at the end of an event.
+	ExpiresAt time.Time
+	// MaxUses is how many members may join with an invite, 0 for any.
+	MaxUses int
+	// Unique stops Discord from handing out an existing invite with the
+	// same settings instead of a new one.
+	Unique bool
+	// Temporary grants temporary membership: Discord removes members who
+	// joined with the invite when they go offline, unless they were given a
+	// role in the meantime.
+	Temporary bool
+	// Fixed, when set or when ChannelID is empty, is handed out instead of
+	// creating invites, e.g. an invite managed by admins when the service
+	// runs without a bot.
+	Fixed string
+	// Queue spreads bursts of invites over time, it is shared by copies of
+	// the Inviter.
+	Queue *Queue
+}
+
+// NewInviter returns an Inviter creating invites to channelID, formatted
+// relative to url, e.g. https://discord.gg.
+func NewInviter(session *discordgo.Session, channelID, url string) *Inviter {
+	return &Inviter{
+		Session:   session,
+		ChannelID: channelID,
+		URL:       url,
+		MinAge:    2 * time.Hour,
+		MaxAge:    86399 * time.Second,
+		MaxUses:   1,
+		Unique:    true,
+		Queue:     NewQueue(10 * time.Second),
+	}
+}
+
+// maxAge returns the lifetime of the next invite in seconds.
+func (i *Inviter) maxAge() (int, error) {
+	if !i.ExpiresAt.IsZero() {
+		age := int(time.Until(i.ExpiresAt).Seconds())
+		if age <= 0 {
+			return 0, ErrInvitesClosed
+		}
+		return age, nil
+	}
+
+	age := i.MaxAge
+	if i.MaxAge > i.MinAge {
+		age = i.MinAge + time.Duration(rand.Int63n(int64(i.MaxAge-i.MinAge)))
+	}
+	return int(age.Seconds()), nil
+}
+
+// Create generates a new invite and returns its URL.
+func (i *Inviter) Create(ctx context.Context) (string, error) {
+	return i.CreateIn(ctx, i.ChannelID)
+}
+
+// CreateIn generates a new invite to channelID rather than to the default
+// channel and returns its URL.
+func (i *Inviter) CreateIn(ctx context.Context, channelID string) (string, error) {
+	if i.Fixed != "" || channelID == "" {
+		return i.Fixed, nil
+	}
+
+	expiration, err := i.maxAge()
+	if err != nil {
+		return "", err
+	}
+
+	invite := discordgo.Invite{
+		MaxAge:    expiration,
+		MaxUses:   i.MaxUses,
+		Unique:    i.Unique,
+		Temporary: i.Temporary,
+	}
+	var inv *discordgo.Invite
+	start := time.Now()
+	err = i.Queue.Do(ctx, func(options ...discordgo.RequestOption) (err error) {
+		inv, err = i.Session.ChannelInviteCreate(channelID, invite, options...)
+		return err
+	})
+	metrics.InviteCreations.WithLabelValues(metrics.Result(err)).Observe(metrics.Since(start))
+	if err != nil {
+		log.FromContext(ctx).WithError(err).WithField("channelID", channelID).Error("could not generate invite link")
+		return "", err
+	}
+
+	inviteURL := fmt.Sprintf("%v/%v", i.URL, inv.Code)
+	return inviteURL, nil
+}
+
+// Dead reports whether the invite at inviteURL expired or was used up.
+func (i *Inviter) Dead(inviteURL string) (bool, error) {
+	if inviteURL == "" || inviteURL == i.Fixed {
+		return false, nil
+	}
+
+	_, err := i.Session.Invite(path.Base(inviteURL))
+	var restErr *discordgo.RESTError
+	if errors.As(err, &restErr) && restErr.Message != nil && restErr.Message.Code == discordgo.ErrCodeUnknownInvite {
+		return true, nil
+	}
+	return false, err
+}
@@ -0,0 +1,268 @@
+package discord
+
+import "context"
+import "crypto/hmac"
+import "crypto/rand"
+import "crypto/sha256"
+import "encoding/base64"
+import "encoding/json"
+import "errors"
+import "fmt"
+import "net/http"
+import "net/url"
+import "strconv"
+import "strings"
+import "time"
+
+import log "github.com/apex/log"
+import "github.com/bwmarrin/discordgo"
+
+// ErrBadState is returned for OAuth2 callbacks whose state was not issued by
+// the Linker or expired.
+var ErrBadState = errors.New("discord: invalid or expired OAuth2 state")
+
+// stateTTL is how long users have to authorize the application.
+const stateTTL = 15 * time.Minute
+
+// Linker ties verified wallets to Discord accounts through the OAuth2
+// authorization code flow, then adds the account to the guild with RoleID.
+// Unlike invite links, this tells who actually joined.
+type Linker struct {
+	Session      *discordgo.Session
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	GuildID      string
+	// RoleID may be left empty when only linked roles are used.
+	RoleID string
+
+	// Metadata, when set, enables Discord linked roles: it returns the
+	// role connection metadata of a wallet, keyed as in
+	// RoleConnectionMetadata, which is pushed each time a user connects.
+	Metadata func(wallet string) (map[string]string, error)
+	// Claim, when set, is called once the user is identified, before they
+	// get anything. An error aborts the flow and is returned by Complete.
+	Claim func(wallet, userID string) error
+
+	key []byte
+}
+
+// NewLinker returns a Linker for the OAuth2 application clientID. States it
+// issues are only valid for the lifetime of the process.
+func NewLinker(session *discordgo.Session, clientID, clientSecret, redirectURL, guildID, roleID string) *Linker {
+	key := make([]byte, 32)
+	rand.Read(key)
+	return &Linker{
+		Session:      session,
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectURL:  redirectURL,
+		GuildID:      guildID,
+		RoleID:       roleID,
+		key:          key,
+	}
+}
+
+func (l *Linker) sign(payload string) string {
+	mac := hmac.New(sha256.New, l.key)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// state binds wallet to the authorization request.
+func (l *Linker) state(wallet string) string {
+	payload := fmt.Sprintf("%v.%v", wallet, time.Now().Add(stateTTL).Unix())
+	return payload + "." + l.sign(payload)
+}
+
+// checkState returns the wallet bound to state.
+func (l *Linker) checkState(state string) (string, error) {
+	i := strings.LastIndex(state, ".")
+	if i < 0 || !hmac.Equal([]byte(state[i+1:]), []byte(l.sign(state[:i]))) {
+		return "", ErrBadState
+	}
+
+	parts := strings.SplitN(state[:i], ".", 2)
+	if len(parts) != 2 {
+		return "", ErrBadState
+	}
+	expires, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return "", ErrBadState
+	}
+	return parts[0], nil
+}
+
+// AuthURL returns the Discord authorization URL to send the owner of wallet
+// to.
+func (l *Linker) AuthURL(wallet string) string {
+	query := url.Values{}
+	query.Set("client_id", l.ClientID)
+	query.Set("response_type", "code")
+	query.Set("redirect_uri", l.RedirectURL)
+	scope := "identify"
+	if l.RoleID != "" {
+		scope += " guilds.join"
+	}
+	if l.Metadata != nil {
+		scope += " role_connections.write"
+	}
+	query.Set("scope", scope)
+	query.Set("state", l.state(wallet))
+	return discordgo.EndpointDiscord + "oauth2/authorize?" + query.Encode()
+}
+
+// exchange trades an authorization code for an access token.
+func (l *Linker) exchange(code string) (string, error) {
+	form := url.Values{}
+	form.Set("client_id", l.ClientID)
+	form.Set("client_secret", l.ClientSecret)
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", l.RedirectURL)
+
+	resp, err := l.Session.Client.PostForm(discordgo.EndpointOAuth2+"token", form)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("discord: token exchange failed: %v", resp.Status)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&token)
+	return token.AccessToken, err
+}
+
+// Complete finishes the authorization started with AuthURL: it identifies
+// the Discord user, adds them to the guild if needed, grants them RoleID and
+// updates their linked roles metadata.
+// It returns the wallet the flow was started for and the Discord user ID.
+func (l *Linker) Complete(ctx context.Context, code, state string) (string, string, error) {
+	wallet, err := l.checkState(state)
+	if err == nil && wallet == loginWallet {
+		err = ErrBadState
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	token, err := l.exchange(code)
+	if err != nil {
+		log.FromContext(ctx).WithError(err).WithField("wallet", wallet).Error("could not exchange OAuth2 code")
+		return "", "", err
+	}
+
+	session, err := l.userSession(token)
+	if err != nil {
+		return "", "", err
+	}
+	user, err := session.User("@me")
+	if err != nil {
+		log.FromContext(ctx).WithError(err).WithField("wallet", wallet).Error("could not identify Discord user")
+		return "", "", err
+	}
+
+	if l.Claim != nil {
+		err = l.Claim(wallet, user.ID)
+		if err != nil {
+			log.FromContext(ctx).WithError(err).WithFields(log.Fields{
+				"wallet": wallet,
+				"user":   user.ID,
+			}).Warn("refused to link Discord user")
+			return "", "", err
+		}
+	}
+
+	if l.RoleID != "" {
+		// joins the guild with the role, or does nothing if already a member
+		err = l.Session.GuildMemberAdd(l.GuildID, user.ID, &discordgo.GuildMemberAddParams{
+			AccessToken: token,
+			Roles:       []string{l.RoleID},
+		})
+		if err != nil {
+			log.FromContext(ctx).WithError(err).WithField("user", user.ID).Error("could not add member to guild")
+			return "", "", err
+		}
+
+		err = l.Session.GuildMemberRoleAdd(l.GuildID, user.ID, l.RoleID)
+		if err != nil {
+			log.FromContext(ctx).WithError(err).WithField("user", user.ID).Error("could not grant role")
+			return "", "", err
+		}
+	}
+
+	if l.Metadata != nil {
+		err = l.updateRoleConnection(session, wallet)
+		if err != nil {
+			log.FromContext(ctx).WithError(err).WithField("user", user.ID).Error("could not update role connection")
+			return "", "", err
+		}
+	}
+
+	log.FromContext(ctx).WithFields(log.Fields{
+		"wallet": wallet,
+		"user":   user.ID,
+	}).Info("linked Discord user")
+
+	return wallet, user.ID, nil
+}
+
+// userSession returns a session acting on behalf of the user who granted
+// token.
+func (l *Linker) userSession(token string) (*discordgo.Session, error) {
+	session, err := discordgo.New("Bearer " + token)
+	if err != nil {
+		return nil, err
+	}
+	session.Client = l.Session.Client
+	return session, nil
+}
+
+// loginWallet stands for the wallet in the states of LoginURL, which no
+// address can be.
+const loginWallet = "login"
+
+// LoginURL returns the Discord authorization URL identifying a user without
+// linking any wallet, for them to log in.
+func (l *Linker) LoginURL() string {
+	query := url.Values{}
+	query.Set("client_id", l.ClientID)
+	query.Set("response_type", "code")
+	query.Set("redirect_uri", l.RedirectURL)
+	query.Set("scope", "identify")
+	query.Set("state", l.state(loginWallet))
+	return discordgo.EndpointDiscord + "oauth2/authorize?" + query.Encode()
+}
+
+// IsLogin reports whether state was issued by LoginURL rather than AuthURL.
+func (l *Linker) IsLogin(state string) bool {
+	wallet, err := l.checkState(state)
+	return err == nil && wallet == loginWallet
+}
+
+// Identify finishes the authorization started with LoginURL and returns the
+// ID of the Discord user.
+func (l *Linker) Identify(code, state string) (string, error) {
+	if !l.IsLogin(state) {
+		return "", ErrBadState
+	}
+
+	token, err := l.exchange(code)
+	if err != nil {
+		return "", err
+	}
+	session, err := l.userSession(token)
+	if err != nil {
+		return "", err
+	}
+	user, err := session.User("@me")
+	if err != nil {
+		return "", err
+	}
+	return user.ID, nil
+}
@@ -0,0 +1,66 @@
+package discord
+
+import "context"
+import "errors"
+import "fmt"
+import "time"
+
+import log "github.com/apex/log"
+import "github.com/bwmarrin/discordgo"
+
+// RateLimited is returned when Discord rate limits would hold a call in a
+// Queue up for longer than it allows.
+type RateLimited struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimited) Error() string {
+	return fmt.Sprintf("discord: rate limited, retry after %v", e.RetryAfter)
+}
+
+// Queue runs calls to Discord one at a time, waiting out 429 responses for
+// up to MaxWait, so that bursts don't fail halfway through. Buckets are
+// already honored by the rate limiter of the session.
+type Queue struct {
+	MaxWait time.Duration
+
+	turn chan struct{}
+}
+
+// NewQueue returns a Queue holding calls up for at most maxWait.
+func NewQueue(maxWait time.Duration) *Queue {
+	return &Queue{
+		MaxWait: maxWait,
+		turn:    make(chan struct{}, 1),
+	}
+}
+
+// Do runs call once the calls queued before it are done, retrying it while
+// it is rate limited. call must pass the options it gets to the session.
+func (q *Queue) Do(ctx context.Context, call func(options ...discordgo.RequestOption) error) error {
+	deadline := time.Now().Add(q.MaxWait)
+
+	select {
+	case q.turn <- struct{}{}:
+	case <-time.After(q.MaxWait):
+		return &RateLimited{RetryAfter: q.MaxWait}
+	}
+	defer func() { <-q.turn }()
+
+	for {
+		err := call(discordgo.WithRetryOnRatelimit(false))
+		var limit *discordgo.RateLimitError
+		if !errors.As(err, &limit) {
+			return err
+		}
+
+		if time.Now().Add(limit.RetryAfter).After(deadline) {
+			return &RateLimited{RetryAfter: limit.RetryAfter}
+		}
+		log.FromContext(ctx).WithFields(log.Fields{
+			"url":   limit.URL,
+			"retry": limit.RetryAfter,
+		}).Debug("rate limited, waiting")
+		time.Sleep(limit.RetryAfter)
+	}
+}
@@ -0,0 +1,132 @@
+package discord
+
+import "sync"
+import "time"
+
+import log "github.com/apex/log"
+import "github.com/bwmarrin/discordgo"
+
+// invite is what Tracker remembers of an invite.
+type invite struct {
+	uses    int
+	expires time.Time
+}
+
+// Tracker finds out which invite a new member of GuildID used, by comparing
+// the uses of the guild invites before and after they joined. Single use
+// invites vanish once redeemed, so a code that disappeared before expiring
+// counts as used too.
+type Tracker struct {
+	Session *discordgo.Session
+	GuildID string
+	// OnRedeemed is called with the code of the invite a member joined
+	// with.
+	OnRedeemed func(code, userID string)
+
+	mu      sync.Mutex
+	invites map[string]invite
+}
+
+// NewTracker returns a Tracker for guildID.
+func NewTracker(session *discordgo.Session, guildID string) *Tracker {
+	return &Tracker{
+		Session: session,
+		GuildID: guildID,
+		invites: make(map[string]invite),
+	}
+}
+
+// Register hooks the tracker to the gateway events of bot. It needs the
+// server members and invites intents.
+func (t *Tracker) Register(bot *Bot) {
+	bot.Session.Identify.Intents |= discordgo.IntentsGuildMembers | discordgo.IntentsGuildInvites
+	bot.AddHandler(t.ready)
+	bot.AddHandler(t.inviteCreated)
+	bot.AddHandler(t.memberJoined)
+}
+
+// snapshot lists the current invites of the guild.
+func (t *Tracker) snapshot() (map[string]invite, error) {
+	invites, err := t.Session.GuildInvites(t.GuildID)
+	if err != nil {
+		return nil, err
+	}
+
+	current := make(map[string]invite, len(invites))
+	for _, inv := range invites {
+		i := invite{uses: inv.Uses}
+		if inv.MaxAge > 0 {
+			i.expires = inv.CreatedAt.Add(time.Duration(inv.MaxAge) * time.Second)
+		}
+		current[inv.Code] = i
+	}
+	return current, nil
+}
+
+func (t *Tracker) ready(_ *discordgo.Session, _ *discordgo.Ready) {
+	current, err := t.snapshot()
+	if err != nil {
+		log.WithError(err).Error("could not list guild invites")
+		return
+	}
+
+	t.mu.Lock()
+	t.invites = current
+	t.mu.Unlock()
+}
+
+func (t *Tracker) inviteCreated(_ *discordgo.Session, e *discordgo.InviteCreate) {
+	if e.GuildID != t.GuildID {
+		return
+	}
+
+	i := invite{uses: e.Uses}
+	if e.MaxAge > 0 {
+		i.expires = e.CreatedAt.Add(time.Duration(e.MaxAge) * time.Second)
+	}
+
+	t.mu.Lock()
+	t.invites[e.Code] = i
+	t.mu.Unlock()
+}
+
+func (t *Tracker) memberJoined(_ *discordgo.Session, m *discordgo.GuildMemberAdd) {
+	if m.GuildID != t.GuildID || m.User == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	current, err := t.snapshot()
+	if err != nil {
+		log.WithError(err).WithField("user", m.User.ID).Error("could not list guild invites")
+		return
+	}
+
+	now := time.Now()
+	var used []string
+	for code, before := range t.invites {
+		after, ok := current[code]
+		if ok && after.uses > before.uses || !ok && (before.expires.IsZero() || now.Before(before.expires)) {
+			used = append(used, code)
+		}
+	}
+	t.invites = current
+
+	if len(used) != 1 {
+		log.WithFields(log.Fields{
+			"user":       m.User.ID,
+			"candidates": len(used),
+		}).Warn("could not tell which invite a member used")
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"user": m.User.ID,
+		"code": used[0],
+	}).Info("member joined with invite")
+	if t.OnRedeemed != nil {
+		t.OnRedeemed(used[0], m.User.ID)
+	}
+}
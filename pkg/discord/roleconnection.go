@@ -0,0 +1,49 @@
+package discord
+
+import "github.com/bwmarrin/discordgo"
+
+// Metadata keys of the linked roles connection.
+const (
+	MetadataVerified = "verified"
+	MetadataBalance  = "balance"
+)
+
+// RoleConnectionMetadata is what the application tells Discord about its
+// users, for server admins to use in linked role requirements.
+var RoleConnectionMetadata = []*discordgo.ApplicationRoleConnectionMetadata{
+	{
+		Type:        discordgo.ApplicationRoleConnectionMetadataBooleanEqual,
+		Key:         MetadataVerified,
+		Name:        "Verified Tezos holder",
+		Description: "Holds a wallet meeting the server requirements",
+	},
+	{
+		Type:        discordgo.ApplicationRoleConnectionMetadataIntegerGreaterThanOrEqual,
+		Key:         MetadataBalance,
+		Name:        "XTZ balance",
+		Description: "Tez held across the linked wallets",
+	},
+}
+
+// RegisterMetadata publishes RoleConnectionMetadata for the application.
+// It only needs to happen once, but is idempotent.
+func (l *Linker) RegisterMetadata() error {
+	_, err := l.Session.ApplicationRoleConnectionMetadataUpdate(l.ClientID, RoleConnectionMetadata)
+	return err
+}
+
+// updateRoleConnection attaches the metadata of wallet to the user owning
+// the user session.
+func (l *Linker) updateRoleConnection(user *discordgo.Session, wallet string) error {
+	metadata, err := l.Metadata(wallet)
+	if err != nil {
+		return err
+	}
+
+	_, err = user.UserApplicationRoleConnectionUpdate(l.ClientID, &discordgo.ApplicationRoleConnection{
+		PlatformName:     "Tezos",
+		PlatformUsername: wallet,
+		Metadata:         metadata,
+	})
+	return err
+}
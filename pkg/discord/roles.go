@@ -0,0 +1,114 @@
+package discord
+
+import "strings"
+import "unicode/utf8"
+
+import log "github.com/apex/log"
+import "github.com/bwmarrin/discordgo"
+
+// Roles manages the roles of guild members.
+type Roles struct {
+	Session *discordgo.Session
+	GuildID string
+}
+
+// Sync makes sure the member userID holds the roles of grant and none of
+// revoke, only touching the ones that differ.
+func (r *Roles) Sync(userID string, grant, revoke []string) error {
+	_, _, err := r.Update(userID, grant, revoke)
+	return err
+}
+
+// Update is Sync, returning the roles it actually granted and revoked.
+func (r *Roles) Update(userID string, grant, revoke []string) (granted, revoked []string, err error) {
+	member, err := r.Session.GuildMember(r.GuildID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	held := make(map[string]bool, len(member.Roles))
+	for _, role := range member.Roles {
+		held[role] = true
+	}
+
+	for _, role := range grant {
+		if held[role] {
+			continue
+		}
+		err = r.Session.GuildMemberRoleAdd(r.GuildID, userID, role)
+		if err != nil {
+			return granted, revoked, err
+		}
+		granted = append(granted, role)
+		log.WithFields(log.Fields{
+			"user": userID,
+			"role": role,
+		}).Info("granted role")
+	}
+
+	for _, role := range revoke {
+		if !held[role] {
+			continue
+		}
+		err = r.Session.GuildMemberRoleRemove(r.GuildID, userID, role)
+		if err != nil {
+			return granted, revoked, err
+		}
+		revoked = append(revoked, role)
+		log.WithFields(log.Fields{
+			"user": userID,
+			"role": role,
+		}).Info("revoked role")
+	}
+
+	return granted, revoked, nil
+}
+
+// maxNickname is the longest nickname Discord accepts, in characters.
+const maxNickname = 32
+
+// Tag sets the nickname of userID to tag or, with suffix, appends tag to
+// their current name.
+func (r *Roles) Tag(userID, tag string, suffix bool) error {
+	member, err := r.Session.GuildMember(r.GuildID, userID)
+	if err != nil {
+		return err
+	}
+
+	nick := tag
+	if suffix {
+		name := member.Nick
+		if name == "" {
+			name = member.User.GlobalName
+		}
+		if name == "" {
+			name = member.User.Username
+		}
+		if strings.HasSuffix(name, tag) {
+			return nil
+		}
+
+		sep := " | "
+		for utf8.RuneCountInString(name+sep+tag) > maxNickname && name != "" {
+			_, size := utf8.DecodeLastRuneInString(name)
+			name = name[:len(name)-size]
+		}
+		if name != "" {
+			nick = name + sep + tag
+		}
+	}
+	if nick == member.Nick {
+		return nil
+	}
+
+	err = r.Session.GuildMemberNickname(r.GuildID, userID, nick)
+	if err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"user": userID,
+		"nick": nick,
+	}).Info("tagged nickname")
+	return nil
+}
@@ -0,0 +1,92 @@
+package discord
+
+import "time"
+
+import log "github.com/apex/log"
+import "github.com/bwmarrin/discordgo"
+
+// identifyInterval is how long Discord wants between two shards
+// identifying, unless it allows more concurrency.
+const identifyInterval = 5 * time.Second
+
+// AddHandler adds a gateway event handler, run for the events of every
+// shard. It must be called before Open.
+func (b *Bot) AddHandler(handler interface{}) {
+	b.events = append(b.events, handler)
+	b.Session.AddHandler(handler)
+}
+
+// openShards connects the shards this process runs, the first one through
+// Session.
+func (b *Bot) openShards() error {
+	count := b.ShardCount
+	concurrency := 1
+	if count == 0 || count > 1 {
+		gateway, err := b.Session.GatewayBot()
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			count = gateway.Shards
+		}
+		if gateway.SessionStartLimit.MaxConcurrency > 1 {
+			concurrency = gateway.SessionStartLimit.MaxConcurrency
+		}
+	}
+
+	ids := b.ShardIDs
+	if len(ids) == 0 {
+		for id := 0; id < count; id++ {
+			ids = append(ids, id)
+		}
+	}
+
+	for n, id := range ids {
+		session := b.Session
+		if n > 0 {
+			session = b.shard()
+			if n%concurrency == 0 {
+				time.Sleep(identifyInterval)
+			}
+		}
+
+		session.ShardID = id
+		session.ShardCount = count
+		err := session.Open()
+		if err != nil {
+			b.Close()
+			return err
+		}
+		b.shards = append(b.shards, session)
+
+		log.WithFields(log.Fields{
+			"shard":  id,
+			"shards": count,
+		}).Info("connected to Discord gateway")
+	}
+	return nil
+}
+
+// shard returns a session sharing the settings and handlers of Session.
+func (b *Bot) shard() *discordgo.Session {
+	session, _ := discordgo.New(b.Session.Identify.Token)
+	session.Identify.Intents = b.Session.Identify.Intents
+	session.Client = b.Session.Client
+	session.Dialer = b.Session.Dialer
+	session.UserAgent = b.Session.UserAgent
+	for _, handler := range b.events {
+		session.AddHandler(handler)
+	}
+	return session
+}
+
+// UpdateWatchStatus sets the "Watching" status of the bot on every shard.
+func (b *Bot) UpdateWatchStatus(name string) error {
+	for _, session := range b.shards {
+		err := session.UpdateWatchStatus(0, name)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package discord
+
+import "github.com/bwmarrin/discordgo"
+
+// OpenThread starts a private thread named name in channelID and adds
+// userID to it. Members who can manage threads see it too.
+func OpenThread(s *discordgo.Session, channelID, userID, name string) (*discordgo.Channel, error) {
+	thread, err := s.ThreadStartComplex(channelID, &discordgo.ThreadStart{
+		Name:                name,
+		Type:                discordgo.ChannelTypeGuildPrivateThread,
+		AutoArchiveDuration: 1440,
+		Invitable:           false,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.ThreadMemberAdd(thread.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return thread, nil
+}
+
+// CloseThread archives and locks threadID.
+func CloseThread(s *discordgo.Session, threadID string) error {
+	closed := true
+	_, err := s.ChannelEditComplex(threadID, &discordgo.ChannelEdit{
+		Archived: &closed,
+		Locked:   &closed,
+	})
+	return err
+}
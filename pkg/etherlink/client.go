@@ -0,0 +1,101 @@
+package etherlink
+
+import "bytes"
+import "context"
+import "encoding/json"
+import "fmt"
+import "math/big"
+import "net/http"
+import "strings"
+import "sync/atomic"
+
+import log "github.com/apex/log"
+
+// Client talks to an Etherlink JSON-RPC endpoint, e.g.
+// https://node.mainnet.etherlink.com.
+type Client struct {
+	URL  string
+	HTTP *http.Client
+
+	id int64
+}
+
+// NewClient returns a Client for the node at url.
+func NewClient(url string) *Client {
+	return &Client{
+		URL:  url,
+		HTTP: http.DefaultClient,
+	}
+}
+
+type rpcRequest struct {
+	JSONRPC string        `json:"jsonrpc"`
+	ID      int64         `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+type rpcResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (c *Client) call(ctx context.Context, method string, result interface{}, params ...interface{}) error {
+	body, err := json.Marshal(rpcRequest{
+		JSONRPC: "2.0",
+		ID:      atomic.AddInt64(&c.id, 1),
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.HTTP.Post(c.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		log.FromContext(ctx).WithError(err).WithField("method", method).Error("could not call etherlink")
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("etherlink: %v returned %v", method, resp.Status)
+	}
+
+	var r rpcResponse
+	err = json.NewDecoder(resp.Body).Decode(&r)
+	if err != nil {
+		return err
+	}
+	if r.Error != nil {
+		return fmt.Errorf("etherlink: %v: %v", method, r.Error.Message)
+	}
+	return json.Unmarshal(r.Result, result)
+}
+
+func (c *Client) quantity(ctx context.Context, method, address string) (*big.Int, error) {
+	var hex string
+	err := c.call(ctx, method, &hex, address, "latest")
+	if err != nil {
+		return nil, err
+	}
+
+	n, ok := new(big.Int).SetString(strings.TrimPrefix(hex, "0x"), 16)
+	if !ok {
+		return nil, fmt.Errorf("etherlink: bad quantity %q", hex)
+	}
+	return n, nil
+}
+
+// Balance returns the balance of address in wei, 10^-18 XTZ.
+func (c *Client) Balance(ctx context.Context, address string) (*big.Int, error) {
+	return c.quantity(ctx, "eth_getBalance", address)
+}
+
+// Nonce returns the number of transactions sent by address.
+func (c *Client) Nonce(ctx context.Context, address string) (*big.Int, error) {
+	return c.quantity(ctx, "eth_getTransactionCount", address)
+}
@@ -0,0 +1,4 @@
+// Package etherlink supports Etherlink, the Tezos EVM rollup: it validates
+// EVM addresses, recovers EIP-191 personal_sign signatures and queries
+// accounts through the Etherlink JSON-RPC.
+package etherlink
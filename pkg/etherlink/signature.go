@@ -0,0 +1,71 @@
+package etherlink
+
+import "encoding/hex"
+import "errors"
+import "fmt"
+import "strings"
+
+import "github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+import "golang.org/x/crypto/sha3"
+
+// ErrBadSignature is returned for signatures that are not 65 hex encoded
+// bytes.
+var ErrBadSignature = errors.New("bad EIP-191 signature")
+
+// ValidAddress reports whether address looks like an EVM address, 0x
+// followed by 20 hex encoded bytes.
+func ValidAddress(address string) bool {
+	if len(address) != 42 || !strings.HasPrefix(address, "0x") {
+		return false
+	}
+	_, err := hex.DecodeString(address[2:])
+	return err == nil
+}
+
+// Normalize returns the lower case form of address, under which it is
+// stored.
+func Normalize(address string) string {
+	return strings.ToLower(address)
+}
+
+func keccak256(data ...[]byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	for _, d := range data {
+		h.Write(d)
+	}
+	return h.Sum(nil)
+}
+
+// hashMessage returns the EIP-191 version 0x45 hash of message, the one
+// signed by personal_sign.
+func hashMessage(message string) []byte {
+	prefix := fmt.Sprintf("\x19Ethereum Signed Message:\n%d", len(message))
+	return keccak256([]byte(prefix), []byte(message))
+}
+
+// VerifySignature checks that signature, as returned by personal_sign, was
+// made over message by the key behind address.
+func VerifySignature(address, signature, message string) (bool, error) {
+	sig, err := hex.DecodeString(strings.TrimPrefix(signature, "0x"))
+	if err != nil || len(sig) != 65 {
+		return false, ErrBadSignature
+	}
+
+	v := sig[64]
+	if v >= 27 {
+		v -= 27
+	}
+	if v > 1 {
+		return false, ErrBadSignature
+	}
+
+	// decred expects the recovery code first, offset by 27
+	compact := append([]byte{27 + v}, sig[:64]...)
+	key, _, err := ecdsa.RecoverCompact(compact, hashMessage(message))
+	if err != nil {
+		return false, nil
+	}
+
+	recovered := keccak256(key.SerializeUncompressed()[1:])[12:]
+	return "0x"+hex.EncodeToString(recovered) == Normalize(address), nil
+}
@@ -0,0 +1,103 @@
+// Package i18n translates the messages the bot sends.
+//
+// Catalogs are JSON objects mapping message keys to fmt formats, one file
+// per language named after its code, e.g. fr.json. Formats may use explicit
+// argument indexes, such as %[2]v, to reorder arguments. en.json is the
+// reference: messages missing from other languages fall back to it.
+package i18n
+
+import "embed"
+import "encoding/json"
+import "fmt"
+import "io/fs"
+import "os"
+import "path"
+import "sort"
+import "strings"
+
+// Fallback is the language of the reference catalog.
+const Fallback = "en"
+
+//go:embed locales/*.json
+var locales embed.FS
+
+// Catalog holds the messages of each language.
+type Catalog struct {
+	messages map[string]map[string]string
+}
+
+// New returns a Catalog of the built-in languages.
+func New() (*Catalog, error) {
+	c := &Catalog{messages: make(map[string]map[string]string)}
+	err := c.load(locales, "locales")
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// LoadDir adds the catalogs found in dir, whose messages replace the
+// built-in ones of the same language and key.
+func (c *Catalog) LoadDir(dir string) error {
+	return c.load(os.DirFS(dir), ".")
+}
+
+func (c *Catalog) load(fsys fs.FS, dir string) error {
+	paths, err := fs.Glob(fsys, path.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+
+	for _, p := range paths {
+		b, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		var messages map[string]string
+		err = json.Unmarshal(b, &messages)
+		if err != nil {
+			return fmt.Errorf("%v: %w", p, err)
+		}
+
+		lang := strings.TrimSuffix(path.Base(p), ".json")
+		if c.messages[lang] == nil {
+			c.messages[lang] = make(map[string]string, len(messages))
+		}
+		for key, format := range messages {
+			c.messages[lang][key] = format
+		}
+	}
+	return nil
+}
+
+// Has reports whether the catalog holds messages in lang.
+func (c *Catalog) Has(lang string) bool {
+	return c.messages[lang] != nil
+}
+
+// Languages returns the codes of the languages of the catalog, sorted.
+func (c *Catalog) Languages() []string {
+	langs := make([]string, 0, len(c.messages))
+	for lang := range c.messages {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// T returns the message key in lang, formatted with args as by fmt.Sprintf.
+// It falls back to the reference language, then to key itself.
+func (c *Catalog) T(lang, key string, args ...interface{}) string {
+	format, ok := c.messages[lang][key]
+	if !ok {
+		format, ok = c.messages[Fallback][key]
+	}
+	if !ok {
+		format = key
+	}
+
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
@@ -0,0 +1,141 @@
+// Package kick removes Discord members who didn't verify a wallet, or whose
+// wallet stopped qualifying, once their grace period is over.
+package kick
+
+import "time"
+
+import log "github.com/apex/log"
+import "github.com/bwmarrin/discordgo"
+
+import "github.com/aaronwinter/tezosagora/pkg/store"
+
+// Kicker periodically goes through the tracked members of GuildID and of
+// the guilds returned by Guilds.
+type Kicker struct {
+	Store   *store.Store
+	Session *discordgo.Session
+	GuildID string
+	// Grace is how long members may stay without a qualifying wallet, from
+	// when they joined or from when their wallet got disqualified.
+	Grace time.Duration
+	// RemindAfter, when set, has OnRemind called once that far into the
+	// grace period of members, with when they will be kicked.
+	RemindAfter time.Duration
+	OnRemind    func(userID string, kickAt time.Time) error
+	// Guilds, when set, returns the IDs of the guilds served next to
+	// GuildID, whose members and registrations live in their views of Store.
+	Guilds func() ([]string, error)
+}
+
+// Run kicks every interval until stop is closed.
+func (k *Kicker) Run(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			err := k.Kick()
+			if err != nil {
+				log.WithError(err).Error("kick run failed")
+			}
+		}
+	}
+}
+
+// Kick removes the members whose grace period is over and reminds the ones
+// whose grace period is running out, in every guild.
+func (k *Kicker) Kick() error {
+	err := k.kick(k.GuildID, k.Store)
+	if err != nil || k.Guilds == nil {
+		return err
+	}
+
+	guilds, err := k.Guilds()
+	if err != nil {
+		return err
+	}
+	for _, id := range guilds {
+		err = k.kick(id, k.Store.Guild(id))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// kick goes through the members of guildID tracked in db.
+func (k *Kicker) kick(guildID string, db *store.Store) error {
+	// when each member's grace period started, zero for qualified ones
+	since := make(map[string]time.Time)
+	err := db.Each(func(reg *store.Registration) error {
+		if reg.DiscordID != "" {
+			since[reg.DiscordID] = reg.Disqualified
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var expired []string
+	var remind []*store.Member
+	kickAt := make(map[string]time.Time)
+	now := time.Now()
+	err = db.EachMember(func(m *store.Member) error {
+		start, registered := since[m.UserID]
+		if !registered {
+			start = m.Joined
+		}
+		switch {
+		case start.IsZero():
+		case now.Sub(start) > k.Grace:
+			expired = append(expired, m.UserID)
+		case k.OnRemind != nil && k.RemindAfter > 0 && now.Sub(start) > k.RemindAfter && m.Reminded.Before(start):
+			remind = append(remind, m)
+			kickAt[m.UserID] = start.Add(k.Grace)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, m := range remind {
+		err = k.OnRemind(m.UserID, kickAt[m.UserID])
+		if err != nil {
+			log.WithError(err).WithField("user", m.UserID).Warn("could not remind member")
+			continue
+		}
+
+		m.Reminded = now
+		err = db.PutMember(m)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, userID := range expired {
+		err = k.Session.GuildMemberDeleteWithReason(guildID, userID, "no qualifying wallet verified")
+		if err != nil {
+			log.WithError(err).WithFields(log.Fields{
+				"guild": guildID,
+				"user":  userID,
+			}).Error("could not kick member")
+			continue
+		}
+
+		log.WithFields(log.Fields{
+			"guild": guildID,
+			"user":  userID,
+		}).Warn("kicked member without a qualifying wallet")
+		err = db.DeleteMember(userID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
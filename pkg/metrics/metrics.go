@@ -0,0 +1,84 @@
+// Package metrics holds the Prometheus collectors of the service, which the
+// web server exposes at /metrics.
+package metrics
+
+import "net/http"
+import "time"
+
+import "github.com/prometheus/client_golang/prometheus"
+import "github.com/prometheus/client_golang/prometheus/promauto"
+import "github.com/prometheus/client_golang/prometheus/promhttp"
+
+const namespace = "agora"
+
+var (
+	// Verifications counts verification requests by outcome.
+	Verifications = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "verifications_total",
+		Help:      "Verification requests by outcome.",
+	}, []string{"outcome"})
+
+	// InFlight counts the verifications evaluating the gating rules, when
+	// they are capped.
+	InFlight = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "verifications_in_flight",
+		Help:      "Verifications evaluating the gating rules.",
+	})
+
+	// TezosRequests times the calls to the Tezos APIs by endpoint and
+	// result, either "ok" or "error".
+	TezosRequests = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "tezos_request_duration_seconds",
+		Help:      "Duration of the calls to the Tezos APIs.",
+	}, []string{"endpoint", "result"})
+
+	// InviteCreations times the creation of Discord invites by result.
+	InviteCreations = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "discord_invite_duration_seconds",
+		Help:      "Duration of the creation of Discord invites.",
+	}, []string{"result"})
+
+	// DBOperations times the database operations by kind.
+	DBOperations = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_operation_duration_seconds",
+		Help:      "Duration of the database operations.",
+		Buckets:   prometheus.ExponentialBuckets(0.0001, 4, 8),
+	}, []string{"op"})
+
+	// Panics counts the HTTP handlers that panicked.
+	Panics = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_panics_total",
+		Help:      "HTTP handlers that panicked.",
+	})
+
+	// HTTPRequests times the HTTP requests by route, method and status code.
+	HTTPRequests = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Duration of the HTTP requests.",
+	}, []string{"route", "method", "code"})
+)
+
+// Result returns the result label of a call that returned err.
+func Result(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "ok"
+}
+
+// Since returns the seconds elapsed since start, as observed by histograms.
+func Since(start time.Time) float64 {
+	return time.Since(start).Seconds()
+}
+
+// Handler serves the collected metrics.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
@@ -0,0 +1,4 @@
+// Package payment implements proof of ownership by micro-payment: a wallet
+// is asked to send a unique small amount to a destination address and is
+// considered verified once that transfer is confirmed on chain.
+package payment
@@ -0,0 +1,176 @@
+package payment
+
+import "context"
+import "errors"
+import "math/rand"
+import "sync"
+import "time"
+
+import log "github.com/apex/log"
+
+import "github.com/aaronwinter/tezosagora/pkg/tezos"
+
+// ErrTooManyPending is returned by Challenge while MaxPending challenges are
+// pending.
+var ErrTooManyPending = errors.New("payment: too many pending challenges")
+
+// Challenge asks Wallet to send Amount mutez to Destination.
+type Challenge struct {
+	Wallet      string
+	Destination string
+	Amount      int64
+	Issued      time.Time
+}
+
+// Watcher issues challenges and polls the chain for the matching transfers.
+type Watcher struct {
+	Destination   string
+	Timeout       time.Duration
+	Confirmations int64
+	Indexer       *tezos.TzKT
+	// MaxPending, when set, caps the challenges pending at once, beyond
+	// which Challenge fails for wallets without one.
+	MaxPending int
+
+	// OnConfirmed is called from the polling goroutine once the transfer
+	// answering a challenge is confirmed.
+	OnConfirmed func(wallet string)
+	// OnSeen is called from the polling goroutine when the transfer
+	// answering a challenge is found with too few confirmations yet.
+	OnSeen func(wallet string, confirmations int64)
+
+	mu      sync.Mutex
+	pending map[string]*Challenge
+}
+
+// NewWatcher returns a Watcher asking for transfers to destination.
+func NewWatcher(indexer *tezos.TzKT, destination string, timeout time.Duration, confirmations int64) *Watcher {
+	return &Watcher{
+		Destination:   destination,
+		Timeout:       timeout,
+		Confirmations: confirmations,
+		Indexer:       indexer,
+		pending:       make(map[string]*Challenge),
+	}
+}
+
+// Challenge returns the pending challenge for wallet, issuing a new one if
+// there is none or the previous one timed out. It fails with
+// ErrTooManyPending when MaxPending challenges are pending already.
+func (w *Watcher) Challenge(wallet string) (*Challenge, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	c, ok := w.pending[wallet]
+	if ok && time.Since(c.Issued) < w.Timeout {
+		return c, nil
+	}
+	if !ok && w.MaxPending > 0 && len(w.pending) >= w.MaxPending {
+		w.expire()
+		if len(w.pending) >= w.MaxPending {
+			return nil, ErrTooManyPending
+		}
+	}
+
+	c = &Challenge{
+		Wallet:      wallet,
+		Destination: w.Destination,
+		Amount:      randomAmount(),
+		Issued:      time.Now(),
+	}
+	w.pending[wallet] = c
+
+	log.WithFields(log.Fields{
+		"wallet": wallet,
+		"amount": c.Amount,
+	}).Debug("issued payment challenge")
+
+	return c, nil
+}
+
+// Pending reports whether wallet has a challenge pending.
+func (w *Watcher) Pending(wallet string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	c, ok := w.pending[wallet]
+	return ok && time.Since(c.Issued) < w.Timeout
+}
+
+// randomAmount picks an amount between 0.001 and 0.01 XTZ. Challenges may
+// share amounts, as transfers are matched by their sender too.
+func randomAmount() int64 {
+	return int64(1000 + rand.Intn(9000))
+}
+
+// expire forgets the challenges that timed out. w.mu must be held.
+func (w *Watcher) expire() {
+	for wallet, c := range w.pending {
+		if time.Since(c.Issued) >= w.Timeout {
+			log.WithField("wallet", wallet).Debug("payment challenge expired")
+			delete(w.pending, wallet)
+		}
+	}
+}
+
+// Run polls the indexer every interval until stop is closed.
+func (w *Watcher) Run(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			w.check()
+		}
+	}
+}
+
+func (w *Watcher) check() {
+	w.mu.Lock()
+	w.expire()
+	challenges := make([]*Challenge, 0, len(w.pending))
+	for _, c := range w.pending {
+		challenges = append(challenges, c)
+	}
+	w.mu.Unlock()
+
+	if len(challenges) == 0 {
+		return
+	}
+
+	head, err := w.Indexer.Head(context.Background())
+	if err != nil {
+		log.WithError(err).Error("could not fetch head level")
+		return
+	}
+
+	for _, c := range challenges {
+		level, err := w.Indexer.FindTransfer(context.Background(), c.Wallet, c.Destination, c.Amount, c.Issued)
+		if err != nil {
+			log.WithError(err).WithField("wallet", c.Wallet).Error("could not look for payment")
+			continue
+		}
+
+		if level == 0 {
+			continue
+		}
+		if head-level+1 < w.Confirmations {
+			if w.OnSeen != nil {
+				w.OnSeen(c.Wallet, head-level+1)
+			}
+			continue
+		}
+
+		log.WithField("wallet", c.Wallet).Debug("payment confirmed!")
+
+		w.mu.Lock()
+		delete(w.pending, c.Wallet)
+		w.mu.Unlock()
+
+		if w.OnConfirmed != nil {
+			w.OnConfirmed(c.Wallet)
+		}
+	}
+}
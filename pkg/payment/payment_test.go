@@ -0,0 +1,57 @@
+package payment
+
+import "fmt"
+import "testing"
+import "time"
+
+func TestChallengeBeyondAmounts(t *testing.T) {
+	w := NewWatcher(nil, "tz1destination", time.Hour, 1)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 10000; i++ {
+			c, err := w.Challenge(fmt.Sprintf("tz1%033d", i))
+			if err != nil {
+				t.Errorf("challenge %v: %v", i, err)
+				return
+			}
+			if c.Amount < 1000 || c.Amount >= 10000 {
+				t.Errorf("challenge %v: amount %v out of range", i, c.Amount)
+				return
+			}
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("issuing more challenges than amounts did not complete")
+	}
+}
+
+func TestChallengeMaxPending(t *testing.T) {
+	w := NewWatcher(nil, "tz1destination", time.Hour, 1)
+	w.MaxPending = 2
+	for i := 0; i < 2; i++ {
+		_, err := w.Challenge(fmt.Sprintf("tz1%033d", i))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, err := w.Challenge(fmt.Sprintf("tz1%033d", 2))
+	if err != ErrTooManyPending {
+		t.Fatalf("got %v, want ErrTooManyPending", err)
+	}
+	// pending wallets still get theirs
+	_, err = w.Challenge(fmt.Sprintf("tz1%033d", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w.Timeout = 0
+	_, err = w.Challenge(fmt.Sprintf("tz1%033d", 2))
+	if err != nil {
+		t.Fatalf("expired challenges still count: %v", err)
+	}
+}
@@ -0,0 +1,123 @@
+// Package pow issues and checks hashcash-like proof of work challenges, a
+// privacy friendly alternative to CAPTCHAs.
+package pow
+
+import "crypto/hmac"
+import "crypto/rand"
+import "crypto/sha256"
+import "encoding/base64"
+import "encoding/hex"
+import "errors"
+import "fmt"
+import "math/bits"
+import "strconv"
+import "strings"
+import "sync"
+import "time"
+
+// Errors returned by Check.
+var (
+	ErrBadChallenge = errors.New("pow: invalid or expired challenge")
+	ErrSpent        = errors.New("pow: challenge already used")
+	ErrBadSolution  = errors.New("pow: solution does not meet the target")
+)
+
+// Challenge is handed to clients, who have to find a Nonce such that the
+// SHA-256 digest of Challenge, ":" and Nonce starts with Difficulty zero bits.
+type Challenge struct {
+	Challenge  string `json:"challenge"`
+	Difficulty int    `json:"difficulty"`
+}
+
+// Issuer hands out challenges valid for TTL and checks their solutions, each
+// challenge being usable once. Challenges are only valid for the lifetime of
+// the process. It is safe for concurrent use.
+type Issuer struct {
+	Difficulty int
+	TTL        time.Duration
+
+	key   []byte
+	mu    sync.Mutex
+	spent map[string]time.Time
+}
+
+// New returns an Issuer of challenges of the given difficulty, in bits.
+func New(difficulty int, ttl time.Duration) *Issuer {
+	key := make([]byte, 32)
+	rand.Read(key)
+	return &Issuer{
+		Difficulty: difficulty,
+		TTL:        ttl,
+		key:        key,
+		spent:      make(map[string]time.Time),
+	}
+}
+
+func (i *Issuer) sign(payload string) string {
+	mac := hmac.New(sha256.New, i.key)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Issue returns a new challenge.
+func (i *Issuer) Issue() Challenge {
+	salt := make([]byte, 16)
+	rand.Read(salt)
+	payload := fmt.Sprintf("%v.%v.%v", hex.EncodeToString(salt), i.Difficulty, time.Now().Add(i.TTL).Unix())
+	return Challenge{
+		Challenge:  payload + "." + i.sign(payload),
+		Difficulty: i.Difficulty,
+	}
+}
+
+// Check verifies that nonce solves challenge, which must have been issued
+// by i, not be expired and not have been used yet.
+func (i *Issuer) Check(challenge, nonce string) error {
+	dot := strings.LastIndex(challenge, ".")
+	if dot < 0 || !hmac.Equal([]byte(challenge[dot+1:]), []byte(i.sign(challenge[:dot]))) {
+		return ErrBadChallenge
+	}
+
+	parts := strings.Split(challenge[:dot], ".")
+	if len(parts) != 3 {
+		return ErrBadChallenge
+	}
+	difficulty, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return ErrBadChallenge
+	}
+	expires, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return ErrBadChallenge
+	}
+
+	if zeroBits(sha256.Sum256([]byte(challenge+":"+nonce))) < difficulty {
+		return ErrBadSolution
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	now := time.Now()
+	for c, expiry := range i.spent {
+		if now.After(expiry) {
+			delete(i.spent, c)
+		}
+	}
+	if _, ok := i.spent[challenge]; ok {
+		return ErrSpent
+	}
+	i.spent[challenge] = time.Unix(expires, 0)
+	return nil
+}
+
+// zeroBits counts the leading zero bits of digest.
+func zeroBits(digest [sha256.Size]byte) int {
+	n := 0
+	for _, b := range digest {
+		n += bits.LeadingZeros8(b)
+		if b != 0 {
+			break
+		}
+	}
+	return n
+}
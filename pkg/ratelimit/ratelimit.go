@@ -0,0 +1,83 @@
+// Package ratelimit provides token buckets keyed by client, such as an IP
+// address or a wallet.
+package ratelimit
+
+import "sync"
+import "time"
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter lets each key through Burst times at once, then once every
+// Interval. It is safe for concurrent use.
+type Limiter struct {
+	Interval time.Duration
+	Burst    int
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	purged  time.Time
+}
+
+// New returns a Limiter refilling one token every interval, up to burst.
+func New(interval time.Duration, burst int) *Limiter {
+	return &Limiter{
+		Interval: interval,
+		Burst:    burst,
+		buckets:  make(map[string]*bucket),
+		purged:   time.Now(),
+	}
+}
+
+// Allow takes a token from the bucket of key. When there is none left, it
+// returns false and how long until the next one.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b := l.refill(key)
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) * float64(l.Interval))
+	}
+	b.tokens--
+	return true, 0
+}
+
+// Exhausted reports whether the bucket of key is empty, without taking a
+// token from it.
+func (l *Limiter) Exhausted(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.refill(key).tokens < 1
+}
+
+// refill returns the bucket of key topped up with the tokens earned since it
+// was last used. The caller must hold mu.
+func (l *Limiter) refill(key string) *bucket {
+	now := time.Now()
+	full := l.Interval * time.Duration(l.Burst)
+	if now.Sub(l.purged) > full {
+		for k, b := range l.buckets {
+			if now.Sub(b.last) > full {
+				delete(l.buckets, k)
+			}
+		}
+		l.purged = now
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.Burst), last: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens += float64(now.Sub(b.last)) / float64(l.Interval)
+	if b.tokens > float64(l.Burst) {
+		b.tokens = float64(l.Burst)
+	}
+	b.last = now
+	return b
+}
@@ -0,0 +1,683 @@
+// Wallet verification for internal services, see package rpc.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: agora.proto
+
+package agorapb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Outcome int32
+
+const (
+	Outcome_OUTCOME_UNSPECIFIED        Outcome = 0
+	Outcome_OUTCOME_REGISTERED         Outcome = 1
+	Outcome_OUTCOME_ALREADY_REGISTERED Outcome = 2
+	Outcome_OUTCOME_BAD_INPUT          Outcome = 3
+	Outcome_OUTCOME_INVALID_SIGNATURE  Outcome = 4
+	Outcome_OUTCOME_NOT_ELIGIBLE       Outcome = 5
+	Outcome_OUTCOME_UNAVAILABLE        Outcome = 6
+	Outcome_OUTCOME_BLOCKED            Outcome = 7
+	Outcome_OUTCOME_TOO_MANY_ATTEMPTS  Outcome = 8
+)
+
+// Enum value maps for Outcome.
+var (
+	Outcome_name = map[int32]string{
+		0: "OUTCOME_UNSPECIFIED",
+		1: "OUTCOME_REGISTERED",
+		2: "OUTCOME_ALREADY_REGISTERED",
+		3: "OUTCOME_BAD_INPUT",
+		4: "OUTCOME_INVALID_SIGNATURE",
+		5: "OUTCOME_NOT_ELIGIBLE",
+		6: "OUTCOME_UNAVAILABLE",
+		7: "OUTCOME_BLOCKED",
+		8: "OUTCOME_TOO_MANY_ATTEMPTS",
+	}
+	Outcome_value = map[string]int32{
+		"OUTCOME_UNSPECIFIED":        0,
+		"OUTCOME_REGISTERED":         1,
+		"OUTCOME_ALREADY_REGISTERED": 2,
+		"OUTCOME_BAD_INPUT":          3,
+		"OUTCOME_INVALID_SIGNATURE":  4,
+		"OUTCOME_NOT_ELIGIBLE":       5,
+		"OUTCOME_UNAVAILABLE":        6,
+		"OUTCOME_BLOCKED":            7,
+		"OUTCOME_TOO_MANY_ATTEMPTS":  8,
+	}
+)
+
+func (x Outcome) Enum() *Outcome {
+	p := new(Outcome)
+	*p = x
+	return p
+}
+
+func (x Outcome) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Outcome) Descriptor() protoreflect.EnumDescriptor {
+	return file_agora_proto_enumTypes[0].Descriptor()
+}
+
+func (Outcome) Type() protoreflect.EnumType {
+	return &file_agora_proto_enumTypes[0]
+}
+
+func (x Outcome) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Outcome.Descriptor instead.
+func (Outcome) EnumDescriptor() ([]byte, []int) {
+	return file_agora_proto_rawDescGZIP(), []int{0}
+}
+
+// Report is the outcome of a gating rule and of its sub-rules.
+type Report struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Rule          string                 `protobuf:"bytes,1,opt,name=rule,proto3" json:"rule,omitempty"`
+	Passed        bool                   `protobuf:"varint,2,opt,name=passed,proto3" json:"passed,omitempty"`
+	Checks        []*Report              `protobuf:"bytes,3,rep,name=checks,proto3" json:"checks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Report) Reset() {
+	*x = Report{}
+	mi := &file_agora_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Report) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Report) ProtoMessage() {}
+
+func (x *Report) ProtoReflect() protoreflect.Message {
+	mi := &file_agora_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Report.ProtoReflect.Descriptor instead.
+func (*Report) Descriptor() ([]byte, []int) {
+	return file_agora_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Report) GetRule() string {
+	if x != nil {
+		return x.Rule
+	}
+	return ""
+}
+
+func (x *Report) GetPassed() bool {
+	if x != nil {
+		return x.Passed
+	}
+	return false
+}
+
+func (x *Report) GetChecks() []*Report {
+	if x != nil {
+		return x.Checks
+	}
+	return nil
+}
+
+type CheckRequest struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Address string                 `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	// guild names another guild than the default one.
+	Guild         string `protobuf:"bytes,2,opt,name=guild,proto3" json:"guild,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckRequest) Reset() {
+	*x = CheckRequest{}
+	mi := &file_agora_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckRequest) ProtoMessage() {}
+
+func (x *CheckRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_agora_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckRequest.ProtoReflect.Descriptor instead.
+func (*CheckRequest) Descriptor() ([]byte, []int) {
+	return file_agora_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CheckRequest) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *CheckRequest) GetGuild() string {
+	if x != nil {
+		return x.Guild
+	}
+	return ""
+}
+
+type CheckResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Address       string                 `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	Registered    bool                   `protobuf:"varint,2,opt,name=registered,proto3" json:"registered,omitempty"`
+	Report        *Report                `protobuf:"bytes,3,opt,name=report,proto3" json:"report,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckResponse) Reset() {
+	*x = CheckResponse{}
+	mi := &file_agora_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckResponse) ProtoMessage() {}
+
+func (x *CheckResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_agora_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckResponse.ProtoReflect.Descriptor instead.
+func (*CheckResponse) Descriptor() ([]byte, []int) {
+	return file_agora_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CheckResponse) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *CheckResponse) GetRegistered() bool {
+	if x != nil {
+		return x.Registered
+	}
+	return false
+}
+
+func (x *CheckResponse) GetReport() *Report {
+	if x != nil {
+		return x.Report
+	}
+	return nil
+}
+
+// VerifyRequest carries a registration attempt. public_key, signature and
+// message are needed when signatures are required.
+type VerifyRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Address       string                 `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	PublicKey     string                 `protobuf:"bytes,2,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+	Signature     string                 `protobuf:"bytes,3,opt,name=signature,proto3" json:"signature,omitempty"`
+	Message       string                 `protobuf:"bytes,4,opt,name=message,proto3" json:"message,omitempty"`
+	Guild         string                 `protobuf:"bytes,5,opt,name=guild,proto3" json:"guild,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VerifyRequest) Reset() {
+	*x = VerifyRequest{}
+	mi := &file_agora_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VerifyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyRequest) ProtoMessage() {}
+
+func (x *VerifyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_agora_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyRequest.ProtoReflect.Descriptor instead.
+func (*VerifyRequest) Descriptor() ([]byte, []int) {
+	return file_agora_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *VerifyRequest) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *VerifyRequest) GetPublicKey() string {
+	if x != nil {
+		return x.PublicKey
+	}
+	return ""
+}
+
+func (x *VerifyRequest) GetSignature() string {
+	if x != nil {
+		return x.Signature
+	}
+	return ""
+}
+
+func (x *VerifyRequest) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *VerifyRequest) GetGuild() string {
+	if x != nil {
+		return x.Guild
+	}
+	return ""
+}
+
+type VerifyResponse struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Outcome Outcome                `protobuf:"varint,1,opt,name=outcome,proto3,enum=tezosagora.v1.Outcome" json:"outcome,omitempty"`
+	Wallet  string                 `protobuf:"bytes,2,opt,name=wallet,proto3" json:"wallet,omitempty"`
+	Invite  string                 `protobuf:"bytes,3,opt,name=invite,proto3" json:"invite,omitempty"`
+	Report  *Report                `protobuf:"bytes,4,opt,name=report,proto3" json:"report,omitempty"`
+	// retry_after_seconds hints when to come back after OUTCOME_UNAVAILABLE
+	// or OUTCOME_TOO_MANY_ATTEMPTS.
+	RetryAfterSeconds int32 `protobuf:"varint,5,opt,name=retry_after_seconds,json=retryAfterSeconds,proto3" json:"retry_after_seconds,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *VerifyResponse) Reset() {
+	*x = VerifyResponse{}
+	mi := &file_agora_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VerifyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyResponse) ProtoMessage() {}
+
+func (x *VerifyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_agora_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyResponse.ProtoReflect.Descriptor instead.
+func (*VerifyResponse) Descriptor() ([]byte, []int) {
+	return file_agora_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *VerifyResponse) GetOutcome() Outcome {
+	if x != nil {
+		return x.Outcome
+	}
+	return Outcome_OUTCOME_UNSPECIFIED
+}
+
+func (x *VerifyResponse) GetWallet() string {
+	if x != nil {
+		return x.Wallet
+	}
+	return ""
+}
+
+func (x *VerifyResponse) GetInvite() string {
+	if x != nil {
+		return x.Invite
+	}
+	return ""
+}
+
+func (x *VerifyResponse) GetReport() *Report {
+	if x != nil {
+		return x.Report
+	}
+	return nil
+}
+
+func (x *VerifyResponse) GetRetryAfterSeconds() int32 {
+	if x != nil {
+		return x.RetryAfterSeconds
+	}
+	return 0
+}
+
+type StatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Address       string                 `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	Guild         string                 `protobuf:"bytes,2,opt,name=guild,proto3" json:"guild,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatusRequest) Reset() {
+	*x = StatusRequest{}
+	mi := &file_agora_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatusRequest) ProtoMessage() {}
+
+func (x *StatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_agora_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatusRequest.ProtoReflect.Descriptor instead.
+func (*StatusRequest) Descriptor() ([]byte, []int) {
+	return file_agora_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *StatusRequest) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *StatusRequest) GetGuild() string {
+	if x != nil {
+		return x.Guild
+	}
+	return ""
+}
+
+type StatusResponse struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Address    string                 `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	Registered bool                   `protobuf:"varint,2,opt,name=registered,proto3" json:"registered,omitempty"`
+	// owner is the registered wallet address is linked to, if not itself.
+	Owner         string `protobuf:"bytes,3,opt,name=owner,proto3" json:"owner,omitempty"`
+	Redeemed      bool   `protobuf:"varint,4,opt,name=redeemed,proto3" json:"redeemed,omitempty"`
+	Claimed       bool   `protobuf:"varint,5,opt,name=claimed,proto3" json:"claimed,omitempty"`
+	Disqualified  bool   `protobuf:"varint,6,opt,name=disqualified,proto3" json:"disqualified,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatusResponse) Reset() {
+	*x = StatusResponse{}
+	mi := &file_agora_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatusResponse) ProtoMessage() {}
+
+func (x *StatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_agora_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatusResponse.ProtoReflect.Descriptor instead.
+func (*StatusResponse) Descriptor() ([]byte, []int) {
+	return file_agora_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *StatusResponse) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *StatusResponse) GetRegistered() bool {
+	if x != nil {
+		return x.Registered
+	}
+	return false
+}
+
+func (x *StatusResponse) GetOwner() string {
+	if x != nil {
+		return x.Owner
+	}
+	return ""
+}
+
+func (x *StatusResponse) GetRedeemed() bool {
+	if x != nil {
+		return x.Redeemed
+	}
+	return false
+}
+
+func (x *StatusResponse) GetClaimed() bool {
+	if x != nil {
+		return x.Claimed
+	}
+	return false
+}
+
+func (x *StatusResponse) GetDisqualified() bool {
+	if x != nil {
+		return x.Disqualified
+	}
+	return false
+}
+
+var File_agora_proto protoreflect.FileDescriptor
+
+const file_agora_proto_rawDesc = "" +
+	"\n" +
+	"\vagora.proto\x12\rtezosagora.v1\"c\n" +
+	"\x06Report\x12\x12\n" +
+	"\x04rule\x18\x01 \x01(\tR\x04rule\x12\x16\n" +
+	"\x06passed\x18\x02 \x01(\bR\x06passed\x12-\n" +
+	"\x06checks\x18\x03 \x03(\v2\x15.tezosagora.v1.ReportR\x06checks\">\n" +
+	"\fCheckRequest\x12\x18\n" +
+	"\aaddress\x18\x01 \x01(\tR\aaddress\x12\x14\n" +
+	"\x05guild\x18\x02 \x01(\tR\x05guild\"x\n" +
+	"\rCheckResponse\x12\x18\n" +
+	"\aaddress\x18\x01 \x01(\tR\aaddress\x12\x1e\n" +
+	"\n" +
+	"registered\x18\x02 \x01(\bR\n" +
+	"registered\x12-\n" +
+	"\x06report\x18\x03 \x01(\v2\x15.tezosagora.v1.ReportR\x06report\"\x96\x01\n" +
+	"\rVerifyRequest\x12\x18\n" +
+	"\aaddress\x18\x01 \x01(\tR\aaddress\x12\x1d\n" +
+	"\n" +
+	"public_key\x18\x02 \x01(\tR\tpublicKey\x12\x1c\n" +
+	"\tsignature\x18\x03 \x01(\tR\tsignature\x12\x18\n" +
+	"\amessage\x18\x04 \x01(\tR\amessage\x12\x14\n" +
+	"\x05guild\x18\x05 \x01(\tR\x05guild\"\xd1\x01\n" +
+	"\x0eVerifyResponse\x120\n" +
+	"\aoutcome\x18\x01 \x01(\x0e2\x16.tezosagora.v1.OutcomeR\aoutcome\x12\x16\n" +
+	"\x06wallet\x18\x02 \x01(\tR\x06wallet\x12\x16\n" +
+	"\x06invite\x18\x03 \x01(\tR\x06invite\x12-\n" +
+	"\x06report\x18\x04 \x01(\v2\x15.tezosagora.v1.ReportR\x06report\x12.\n" +
+	"\x13retry_after_seconds\x18\x05 \x01(\x05R\x11retryAfterSeconds\"?\n" +
+	"\rStatusRequest\x12\x18\n" +
+	"\aaddress\x18\x01 \x01(\tR\aaddress\x12\x14\n" +
+	"\x05guild\x18\x02 \x01(\tR\x05guild\"\xba\x01\n" +
+	"\x0eStatusResponse\x12\x18\n" +
+	"\aaddress\x18\x01 \x01(\tR\aaddress\x12\x1e\n" +
+	"\n" +
+	"registered\x18\x02 \x01(\bR\n" +
+	"registered\x12\x14\n" +
+	"\x05owner\x18\x03 \x01(\tR\x05owner\x12\x1a\n" +
+	"\bredeemed\x18\x04 \x01(\bR\bredeemed\x12\x18\n" +
+	"\aclaimed\x18\x05 \x01(\bR\aclaimed\x12\"\n" +
+	"\fdisqualified\x18\x06 \x01(\bR\fdisqualified*\xf7\x01\n" +
+	"\aOutcome\x12\x17\n" +
+	"\x13OUTCOME_UNSPECIFIED\x10\x00\x12\x16\n" +
+	"\x12OUTCOME_REGISTERED\x10\x01\x12\x1e\n" +
+	"\x1aOUTCOME_ALREADY_REGISTERED\x10\x02\x12\x15\n" +
+	"\x11OUTCOME_BAD_INPUT\x10\x03\x12\x1d\n" +
+	"\x19OUTCOME_INVALID_SIGNATURE\x10\x04\x12\x18\n" +
+	"\x14OUTCOME_NOT_ELIGIBLE\x10\x05\x12\x17\n" +
+	"\x13OUTCOME_UNAVAILABLE\x10\x06\x12\x13\n" +
+	"\x0fOUTCOME_BLOCKED\x10\a\x12\x1d\n" +
+	"\x19OUTCOME_TOO_MANY_ATTEMPTS\x10\b2\xd9\x01\n" +
+	"\x05Agora\x12B\n" +
+	"\x05Check\x12\x1b.tezosagora.v1.CheckRequest\x1a\x1c.tezosagora.v1.CheckResponse\x12E\n" +
+	"\x06Verify\x12\x1c.tezosagora.v1.VerifyRequest\x1a\x1d.tezosagora.v1.VerifyResponse\x12E\n" +
+	"\x06Status\x12\x1c.tezosagora.v1.StatusRequest\x1a\x1d.tezosagora.v1.StatusResponseB3Z1github.com/aaronwinter/tezosagora/pkg/rpc/agorapbb\x06proto3"
+
+var (
+	file_agora_proto_rawDescOnce sync.Once
+	file_agora_proto_rawDescData []byte
+)
+
+func file_agora_proto_rawDescGZIP() []byte {
+	file_agora_proto_rawDescOnce.Do(func() {
+		file_agora_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_agora_proto_rawDesc), len(file_agora_proto_rawDesc)))
+	})
+	return file_agora_proto_rawDescData
+}
+
+var file_agora_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_agora_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_agora_proto_goTypes = []any{
+	(Outcome)(0),           // 0: tezosagora.v1.Outcome
+	(*Report)(nil),         // 1: tezosagora.v1.Report
+	(*CheckRequest)(nil),   // 2: tezosagora.v1.CheckRequest
+	(*CheckResponse)(nil),  // 3: tezosagora.v1.CheckResponse
+	(*VerifyRequest)(nil),  // 4: tezosagora.v1.VerifyRequest
+	(*VerifyResponse)(nil), // 5: tezosagora.v1.VerifyResponse
+	(*StatusRequest)(nil),  // 6: tezosagora.v1.StatusRequest
+	(*StatusResponse)(nil), // 7: tezosagora.v1.StatusResponse
+}
+var file_agora_proto_depIdxs = []int32{
+	1, // 0: tezosagora.v1.Report.checks:type_name -> tezosagora.v1.Report
+	1, // 1: tezosagora.v1.CheckResponse.report:type_name -> tezosagora.v1.Report
+	0, // 2: tezosagora.v1.VerifyResponse.outcome:type_name -> tezosagora.v1.Outcome
+	1, // 3: tezosagora.v1.VerifyResponse.report:type_name -> tezosagora.v1.Report
+	2, // 4: tezosagora.v1.Agora.Check:input_type -> tezosagora.v1.CheckRequest
+	4, // 5: tezosagora.v1.Agora.Verify:input_type -> tezosagora.v1.VerifyRequest
+	6, // 6: tezosagora.v1.Agora.Status:input_type -> tezosagora.v1.StatusRequest
+	3, // 7: tezosagora.v1.Agora.Check:output_type -> tezosagora.v1.CheckResponse
+	5, // 8: tezosagora.v1.Agora.Verify:output_type -> tezosagora.v1.VerifyResponse
+	7, // 9: tezosagora.v1.Agora.Status:output_type -> tezosagora.v1.StatusResponse
+	7, // [7:10] is the sub-list for method output_type
+	4, // [4:7] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_agora_proto_init() }
+func file_agora_proto_init() {
+	if File_agora_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_agora_proto_rawDesc), len(file_agora_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   7,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_agora_proto_goTypes,
+		DependencyIndexes: file_agora_proto_depIdxs,
+		EnumInfos:         file_agora_proto_enumTypes,
+		MessageInfos:      file_agora_proto_msgTypes,
+	}.Build()
+	File_agora_proto = out.File
+	file_agora_proto_goTypes = nil
+	file_agora_proto_depIdxs = nil
+}
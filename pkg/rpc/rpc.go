@@ -0,0 +1,228 @@
+// Package rpc exposes the verification pipeline over gRPC, for internal
+// services that check or register wallets programmatically. The service is
+// defined in agora.proto, from which the agorapb package is generated.
+package rpc
+
+//go:generate protoc --go_out=agorapb --go_opt=paths=source_relative --go-grpc_out=agorapb --go-grpc_opt=paths=source_relative agora.proto
+
+import "context"
+import "crypto/subtle"
+import "net"
+import "strings"
+import "sync"
+
+import log "github.com/apex/log"
+import "google.golang.org/grpc"
+import "google.golang.org/grpc/codes"
+import "google.golang.org/grpc/metadata"
+import "google.golang.org/grpc/status"
+
+import "github.com/aaronwinter/tezosagora/pkg/breaker"
+import "github.com/aaronwinter/tezosagora/pkg/rpc/agorapb"
+import "github.com/aaronwinter/tezosagora/pkg/rules"
+import "github.com/aaronwinter/tezosagora/pkg/verify"
+
+var outcomes = map[verify.Outcome]agorapb.Outcome{
+	verify.Registered:        agorapb.Outcome_OUTCOME_REGISTERED,
+	verify.AlreadyRegistered: agorapb.Outcome_OUTCOME_ALREADY_REGISTERED,
+	verify.BadInput:          agorapb.Outcome_OUTCOME_BAD_INPUT,
+	verify.InvalidSignature:  agorapb.Outcome_OUTCOME_INVALID_SIGNATURE,
+	verify.NotEligible:       agorapb.Outcome_OUTCOME_NOT_ELIGIBLE,
+	verify.Unavailable:       agorapb.Outcome_OUTCOME_UNAVAILABLE,
+	verify.Blocked:           agorapb.Outcome_OUTCOME_BLOCKED,
+	verify.TooManyAttempts:   agorapb.Outcome_OUTCOME_TOO_MANY_ATTEMPTS,
+}
+
+// Server implements the Agora gRPC service on top of a Verifier.
+type Server struct {
+	agorapb.UnimplementedAgoraServer
+
+	Verifier *verify.Verifier
+	// Token must be sent by clients as a bearer token in the authorization
+	// metadata. Every call is refused while it is empty.
+	Token string
+	// Guild, when set, returns the Verifier of the guild named in requests,
+	// or nil if it is unknown.
+	Guild func(id string) (*verify.Verifier, error)
+
+	mu     sync.Mutex
+	server *grpc.Server
+}
+
+// NewServer returns a Server running requests through verifier.
+func NewServer(verifier *verify.Verifier) *Server {
+	return &Server{Verifier: verifier}
+}
+
+// ListenAndServe serves the Agora service on the TCP address addr.
+func (s *Server) ListenAndServe(addr string) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	server := grpc.NewServer(grpc.UnaryInterceptor(s.authorize))
+	agorapb.RegisterAgoraServer(server, s)
+	s.mu.Lock()
+	s.server = server
+	s.mu.Unlock()
+	return server.Serve(lis)
+}
+
+// Stop stops accepting calls and waits for the pending ones to finish, after
+// which ListenAndServe returns nil.
+func (s *Server) Stop() {
+	s.mu.Lock()
+	server := s.server
+	s.mu.Unlock()
+	if server != nil {
+		server.GracefulStop()
+	}
+}
+
+// authorize only lets calls carrying Token through, and none when it is
+// empty.
+func (s *Server) authorize(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	var token string
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get("authorization"); len(values) > 0 {
+		token = strings.TrimPrefix(values[0], "Bearer ")
+	}
+	if s.Token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(s.Token)) != 1 {
+		log.WithField("method", info.FullMethod).Warn("unauthorized grpc call")
+		return nil, status.Error(codes.Unauthenticated, "missing or wrong token")
+	}
+	return handler(ctx, req)
+}
+
+// verifier returns the Verifier of guild id, or the default one when id is
+// empty.
+func (s *Server) verifier(id string) (*verify.Verifier, error) {
+	if id == "" || s.Guild == nil {
+		return s.Verifier, nil
+	}
+
+	verifier, err := s.Guild(id)
+	if err != nil {
+		log.WithError(err).WithField("guild", id).Error("could not load guild")
+		return nil, status.Error(codes.Internal, "could not load guild")
+	}
+	if verifier == nil {
+		return nil, status.Error(codes.NotFound, "unknown guild")
+	}
+	return verifier, nil
+}
+
+// Check implements agorapb.AgoraServer.
+func (s *Server) Check(ctx context.Context, req *agorapb.CheckRequest) (*agorapb.CheckResponse, error) {
+	verifier, err := s.verifier(req.Guild)
+	if err != nil {
+		return nil, err
+	}
+
+	address := strings.TrimSpace(req.Address)
+	if !verifier.ValidAddress(address) {
+		return nil, status.Error(codes.InvalidArgument, "bad address")
+	}
+
+	if verifier.Blocklist != nil {
+		reason, blocked, err := verifier.Blocklist.Blocked(address)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		if blocked {
+			return nil, status.Errorf(codes.PermissionDenied, "blocked: %v", reason)
+		}
+	}
+
+	registered, err := verifier.Store.IsRegistered(address)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	r, err := verifier.Evaluate(ctx, address)
+	if err == breaker.ErrOpen {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &agorapb.CheckResponse{
+		Address:    address,
+		Registered: registered,
+		Report:     report(&r),
+	}, nil
+}
+
+// Verify implements agorapb.AgoraServer.
+func (s *Server) Verify(ctx context.Context, req *agorapb.VerifyRequest) (*agorapb.VerifyResponse, error) {
+	verifier, err := s.verifier(req.Guild)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := verifier.Verify(ctx, verify.Request{
+		Address:   strings.TrimSpace(req.Address),
+		PublicKey: req.PublicKey,
+		Signature: req.Signature,
+		Message:   req.Message,
+	})
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	response := &agorapb.VerifyResponse{
+		Outcome: outcomes[result.Outcome],
+		Wallet:  result.Wallet,
+		Invite:  result.Invite,
+		Report:  report(result.Report),
+	}
+	if result.Outcome == verify.Unavailable || result.Outcome == verify.TooManyAttempts {
+		response.RetryAfterSeconds = int32(result.RetryAfter.Seconds()) + 1
+	}
+	return response, nil
+}
+
+// Status implements agorapb.AgoraServer.
+func (s *Server) Status(ctx context.Context, req *agorapb.StatusRequest) (*agorapb.StatusResponse, error) {
+	verifier, err := s.verifier(req.Guild)
+	if err != nil {
+		return nil, err
+	}
+
+	address := strings.TrimSpace(req.Address)
+	if !verifier.ValidAddress(address) {
+		return nil, status.Error(codes.InvalidArgument, "bad address")
+	}
+
+	reg, err := verifier.Store.Owner(address)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	response := &agorapb.StatusResponse{Address: address}
+	if reg != nil {
+		response.Registered = true
+		if reg.Wallet != address {
+			response.Owner = reg.Wallet
+		}
+		response.Redeemed = !reg.Redeemed.IsZero()
+		response.Claimed = reg.DiscordID != ""
+		response.Disqualified = !reg.Disqualified.IsZero()
+	}
+	return response, nil
+}
+
+// report converts a gating report to its protobuf message.
+func report(r *rules.Report) *agorapb.Report {
+	if r == nil {
+		return nil
+	}
+
+	converted := &agorapb.Report{Rule: r.Rule, Passed: r.Passed}
+	for i := range r.Checks {
+		converted.Checks = append(converted.Checks, report(&r.Checks[i]))
+	}
+	return converted
+}
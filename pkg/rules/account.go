@@ -0,0 +1,130 @@
+package rules
+
+import "context"
+import "fmt"
+import "math/big"
+import "time"
+
+import "github.com/aaronwinter/tezosagora/pkg/tezos"
+
+// atLevel describes a snapshot level for reports.
+func atLevel(level int64) string {
+	if level == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" at level %v", level)
+}
+
+// Balance passes for wallets holding at least Min mutez, at Level if set.
+type Balance struct {
+	TzKT  *tezos.TzKT
+	Min   int64
+	Level int64
+}
+
+func (r Balance) describe() string {
+	return fmt.Sprintf("balance of at least %v XTZ%v", float64(r.Min)/1e6, atLevel(r.Level))
+}
+
+// Evaluate implements Rule.
+func (r Balance) Evaluate(ctx context.Context, wallet string) (Report, error) {
+	report := Report{Rule: r.describe()}
+	balance, err := r.TzKT.BalanceAt(ctx, wallet, r.Level)
+	if err != nil {
+		return report, err
+	}
+	report.Passed = balance >= r.Min
+	return report, nil
+}
+
+// Token passes for wallets holding at least Min tokens of Contract. An empty
+// TokenID accepts any token of the contract, which suits NFT collections.
+type Token struct {
+	TzKT     *tezos.TzKT
+	Contract string
+	TokenID  string
+	Min      *big.Int
+	Level    int64
+}
+
+func (r Token) describe() string {
+	token := r.Contract
+	if r.TokenID != "" {
+		token = fmt.Sprintf("%v:%v", r.Contract, r.TokenID)
+	}
+	return fmt.Sprintf("holds at least %v of %v%v", r.Min, token, atLevel(r.Level))
+}
+
+// Evaluate implements Rule.
+func (r Token) Evaluate(ctx context.Context, wallet string) (Report, error) {
+	report := Report{Rule: r.describe()}
+
+	balance, err := r.TzKT.TokenBalance(ctx, wallet, r.Contract, r.TokenID, r.Level)
+	if err != nil {
+		return report, err
+	}
+	report.Passed = balance.Cmp(r.Min) >= 0
+	return report, nil
+}
+
+// Baker passes for wallets registered as delegates.
+type Baker struct {
+	TzKT *tezos.TzKT
+}
+
+// Evaluate implements Rule.
+func (r Baker) Evaluate(ctx context.Context, wallet string) (Report, error) {
+	report := Report{Rule: "is a baker"}
+	account, err := r.TzKT.Account(ctx, wallet)
+	if err != nil {
+		return report, err
+	}
+	report.Passed = account.IsBaker()
+	return report, nil
+}
+
+// Delegation passes for wallets delegating to one of Bakers, or to anyone
+// if Bakers is empty.
+type Delegation struct {
+	TzKT   *tezos.TzKT
+	Bakers []string
+}
+
+// Evaluate implements Rule.
+func (r Delegation) Evaluate(ctx context.Context, wallet string) (Report, error) {
+	report := Report{Rule: "delegates"}
+	if len(r.Bakers) > 0 {
+		report.Rule = fmt.Sprintf("delegates to one of %v", r.Bakers)
+	}
+
+	account, err := r.TzKT.Account(ctx, wallet)
+	if err != nil || account.Delegate == nil {
+		return report, err
+	}
+
+	report.Passed = len(r.Bakers) == 0
+	for _, b := range r.Bakers {
+		if account.Delegate.Address == b {
+			report.Passed = true
+		}
+	}
+	return report, nil
+}
+
+// Age passes for wallets whose first on-chain activity is at least Min old.
+type Age struct {
+	TzKT *tezos.TzKT
+	Min  time.Duration
+}
+
+// Evaluate implements Rule.
+func (r Age) Evaluate(ctx context.Context, wallet string) (Report, error) {
+	report := Report{Rule: fmt.Sprintf("active for at least %v", r.Min)}
+	account, err := r.TzKT.Account(ctx, wallet)
+	if err != nil {
+		return report, err
+	}
+	first := account.FirstActivityTime
+	report.Passed = !first.IsZero() && time.Since(first) >= r.Min
+	return report, nil
+}
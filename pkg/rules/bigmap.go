@@ -0,0 +1,36 @@
+package rules
+
+import "context"
+import "fmt"
+
+import "github.com/aaronwinter/tezosagora/pkg/tezos"
+
+// BigMapMember passes for wallets present as a key of a contract big map,
+// such as a DAO member registry or a whitelist.
+type BigMapMember struct {
+	RPC      *tezos.RPC
+	BigMapID int64
+	// KeyHash is set when the big map is keyed by key_hash rather than by
+	// address.
+	KeyHash bool
+	// Level evaluates membership at a past block level instead of head.
+	Level int64
+}
+
+// Evaluate implements Rule.
+func (r BigMapMember) Evaluate(ctx context.Context, wallet string) (Report, error) {
+	report := Report{Rule: fmt.Sprintf("member of big map %v%v", r.BigMapID, atLevel(r.Level))}
+
+	pack := tezos.PackAddress
+	if r.KeyHash {
+		pack = tezos.PackKeyHash
+	}
+
+	packed, err := pack(wallet)
+	if err != nil {
+		return report, err
+	}
+
+	report.Passed, err = r.RPC.BigMapHas(ctx, r.BigMapID, packed, r.Level)
+	return report, err
+}
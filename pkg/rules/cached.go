@@ -0,0 +1,25 @@
+package rules
+
+import "context"
+
+import "github.com/aaronwinter/tezosagora/pkg/cache"
+
+// Cached remembers the reports of Rule so that repeated submissions of the
+// same wallet don't hit the upstream APIs. Failed evaluations are not cached.
+type Cached struct {
+	Rule  Rule
+	Cache *cache.Cache
+}
+
+// Evaluate implements Rule.
+func (r Cached) Evaluate(ctx context.Context, wallet string) (Report, error) {
+	if v, ok := r.Cache.Get(wallet); ok {
+		return v.(Report), nil
+	}
+
+	report, err := r.Rule.Evaluate(ctx, wallet)
+	if err == nil {
+		r.Cache.Set(wallet, report)
+	}
+	return report, err
+}
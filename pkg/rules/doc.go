@@ -0,0 +1,3 @@
+// Package rules holds the gating criteria a wallet has to meet before it is
+// handed an invite.
+package rules
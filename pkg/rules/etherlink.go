@@ -0,0 +1,59 @@
+package rules
+
+import "context"
+import "fmt"
+import "math/big"
+
+import "github.com/aaronwinter/tezosagora/pkg/etherlink"
+
+// Networks sends Etherlink addresses to Etherlink and everything else to
+// Tezos.
+type Networks struct {
+	Tezos     Rule
+	Etherlink Rule
+}
+
+// Evaluate implements Rule.
+func (r Networks) Evaluate(ctx context.Context, wallet string) (Report, error) {
+	if etherlink.ValidAddress(wallet) {
+		if r.Etherlink == nil {
+			return Report{Rule: "Etherlink addresses are accepted"}, nil
+		}
+		return r.Etherlink.Evaluate(ctx, wallet)
+	}
+	return r.Tezos.Evaluate(ctx, wallet)
+}
+
+// EVMBalance passes for Etherlink accounts holding at least Min wei. With
+// a zero Min it passes for any account that holds funds or ever sent a
+// transaction.
+type EVMBalance struct {
+	Client *etherlink.Client
+	Min    *big.Int
+}
+
+// Evaluate implements Rule.
+func (r EVMBalance) Evaluate(ctx context.Context, wallet string) (Report, error) {
+	if r.Min.Sign() == 0 {
+		report := Report{Rule: "Etherlink account exists"}
+		balance, err := r.Client.Balance(ctx, wallet)
+		if err != nil {
+			return report, err
+		}
+		nonce, err := r.Client.Nonce(ctx, wallet)
+		if err != nil {
+			return report, err
+		}
+		report.Passed = balance.Sign() > 0 || nonce.Sign() > 0
+		return report, nil
+	}
+
+	xtz := new(big.Float).Quo(new(big.Float).SetInt(r.Min), big.NewFloat(1e18))
+	report := Report{Rule: fmt.Sprintf("Etherlink balance of at least %v XTZ", xtz.Text('f', -1))}
+	balance, err := r.Client.Balance(ctx, wallet)
+	if err != nil {
+		return report, err
+	}
+	report.Passed = balance.Cmp(r.Min) >= 0
+	return report, nil
+}
@@ -0,0 +1,172 @@
+package rules
+
+import "context"
+import "math/big"
+
+import "github.com/aaronwinter/tezosagora/pkg/etherlink"
+
+// A GroupRule can also judge the wallets of a single holder as a whole,
+// e.g. by summing their balances.
+type GroupRule interface {
+	Rule
+	EvaluateGroup(ctx context.Context, wallets []string) (Report, error)
+}
+
+// EvaluateGroup evaluates rule over the combined holdings of wallets. Rules
+// that can't combine wallets pass when any of the wallets passes them.
+func EvaluateGroup(ctx context.Context, rule Rule, wallets []string) (Report, error) {
+	if len(wallets) == 1 {
+		return rule.Evaluate(ctx, wallets[0])
+	}
+
+	if g, ok := rule.(GroupRule); ok {
+		return g.EvaluateGroup(ctx, wallets)
+	}
+
+	var report Report
+	for i, wallet := range wallets {
+		r, err := rule.Evaluate(ctx, wallet)
+		if err != nil {
+			return Report{}, err
+		}
+		if i == 0 || r.Passed && !report.Passed {
+			report = r
+		}
+	}
+	return report, nil
+}
+
+func evaluateGroupAll(ctx context.Context, rs []Rule, wallets []string) ([]Report, error) {
+	reports := make([]Report, 0, len(rs))
+	for _, r := range rs {
+		report, err := EvaluateGroup(ctx, r, wallets)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+// EvaluateGroup implements GroupRule.
+func (rs All) EvaluateGroup(ctx context.Context, wallets []string) (Report, error) {
+	if len(rs) == 1 {
+		return EvaluateGroup(ctx, rs[0], wallets)
+	}
+
+	checks, err := evaluateGroupAll(ctx, rs, wallets)
+	if err != nil {
+		return Report{}, err
+	}
+
+	passed := true
+	for _, c := range checks {
+		passed = passed && c.Passed
+	}
+	return Report{Rule: "all of", Passed: passed, Checks: checks}, nil
+}
+
+// EvaluateGroup implements GroupRule.
+func (rs Any) EvaluateGroup(ctx context.Context, wallets []string) (Report, error) {
+	if len(rs) == 1 {
+		return EvaluateGroup(ctx, rs[0], wallets)
+	}
+
+	checks, err := evaluateGroupAll(ctx, rs, wallets)
+	if err != nil {
+		return Report{}, err
+	}
+
+	passed := false
+	for _, c := range checks {
+		passed = passed || c.Passed
+	}
+	return Report{Rule: "any of", Passed: passed, Checks: checks}, nil
+}
+
+// EvaluateGroup implements GroupRule.
+func (r Not) EvaluateGroup(ctx context.Context, wallets []string) (Report, error) {
+	report, err := EvaluateGroup(ctx, r.Rule, wallets)
+	if err != nil {
+		return Report{}, err
+	}
+	return Report{Rule: "not", Passed: !report.Passed, Checks: []Report{report}}, nil
+}
+
+// EvaluateGroup implements GroupRule.
+func (r Balance) EvaluateGroup(ctx context.Context, wallets []string) (Report, error) {
+	report := Report{Rule: r.describe() + " combined"}
+	var total int64
+	for _, wallet := range wallets {
+		balance, err := r.TzKT.BalanceAt(ctx, wallet, r.Level)
+		if err != nil {
+			return report, err
+		}
+		total += balance
+	}
+	report.Passed = total >= r.Min
+	return report, nil
+}
+
+// EvaluateGroup implements GroupRule.
+func (r Token) EvaluateGroup(ctx context.Context, wallets []string) (Report, error) {
+	report := Report{Rule: r.describe() + " combined"}
+	total := new(big.Int)
+	for _, wallet := range wallets {
+		balance, err := r.TzKT.TokenBalance(ctx, wallet, r.Contract, r.TokenID, r.Level)
+		if err != nil {
+			return report, err
+		}
+		total.Add(total, balance)
+	}
+	report.Passed = total.Cmp(r.Min) >= 0
+	return report, nil
+}
+
+// EvaluateGroup implements GroupRule. Holdings on different networks can't
+// be summed, so the group passes if either network's wallets do.
+func (r Networks) EvaluateGroup(ctx context.Context, wallets []string) (Report, error) {
+	var tezos, evm []string
+	for _, wallet := range wallets {
+		if etherlink.ValidAddress(wallet) {
+			evm = append(evm, wallet)
+		} else {
+			tezos = append(tezos, wallet)
+		}
+	}
+
+	if len(evm) == 0 {
+		return EvaluateGroup(ctx, r.Tezos, tezos)
+	}
+	if len(tezos) == 0 {
+		return r.evaluateEtherlink(ctx, evm)
+	}
+
+	var checks []Report
+	report, err := EvaluateGroup(ctx, r.Tezos, tezos)
+	if err != nil {
+		return Report{}, err
+	}
+	checks = append(checks, report)
+
+	report, err = r.evaluateEtherlink(ctx, evm)
+	if err != nil {
+		return Report{}, err
+	}
+	checks = append(checks, report)
+
+	return Report{Rule: "any of", Passed: checks[0].Passed || checks[1].Passed, Checks: checks}, nil
+}
+
+func (r Networks) evaluateEtherlink(ctx context.Context, wallets []string) (Report, error) {
+	if r.Etherlink == nil {
+		return Report{Rule: "Etherlink addresses are accepted"}, nil
+	}
+	return EvaluateGroup(ctx, r.Etherlink, wallets)
+}
+
+// EvaluateGroup implements GroupRule. Groups are not cached, as a change
+// to any of their wallets would have to invalidate them.
+func (r Cached) EvaluateGroup(ctx context.Context, wallets []string) (Report, error) {
+	return EvaluateGroup(ctx, r.Rule, wallets)
+}
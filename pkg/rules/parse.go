@@ -0,0 +1,283 @@
+package rules
+
+import "fmt"
+import "math"
+import "math/big"
+import "strconv"
+import "strings"
+import "time"
+import "unicode"
+
+import "github.com/aaronwinter/tezosagora/pkg/tezos"
+
+// Backends are the Tezos clients handed to the rules built by Parse. A
+// non-zero Level evaluates balance, token, big map and view checks at that
+// block level instead of head.
+type Backends struct {
+	Client *tezos.Client
+	TzKT   *tezos.TzKT
+	RPC    *tezos.RPC
+	Level  int64
+}
+
+// Parse builds a Rule from a boolean expression combining checks with AND,
+// OR, NOT and parentheses, e.g.
+//
+//	exists AND (balance(100) OR nft(KT1...) OR baker) AND age(30d)
+//
+// The available checks are:
+//
+//	exists                       the wallet is known to the Tezos API
+//	balance(xtz)                 holds at least xtz tez
+//	token(contract, [id], [min]) holds at least min (default 1) tokens
+//	nft(contract)                holds any token of contract
+//	baker                        is a registered delegate
+//	delegates([baker...])        delegates, optionally to one of bakers
+//	age(duration)                first activity is older than duration
+//	bigmap(id, [key_hash])       is a key of the big map
+//	view(contract, name)         the on-chain view name approves the wallet
+func Parse(expr string, b Backends) (Rule, error) {
+	p := &parser{tokens: tokenize(expr), backends: b}
+	rule, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(p.tokens) {
+		return nil, fmt.Errorf("rules: unexpected %q", p.tokens[p.pos])
+	}
+	return rule, nil
+}
+
+func tokenize(expr string) []string {
+	var tokens []string
+	var word strings.Builder
+	flush := func() {
+		if word.Len() > 0 {
+			tokens = append(tokens, word.String())
+			word.Reset()
+		}
+	}
+
+	for _, c := range expr {
+		switch {
+		case unicode.IsSpace(c):
+			flush()
+		case c == '(' || c == ')' || c == ',':
+			flush()
+			tokens = append(tokens, string(c))
+		default:
+			word.WriteRune(c)
+		}
+	}
+	flush()
+
+	return tokens
+}
+
+type parser struct {
+	tokens   []string
+	pos      int
+	backends Backends
+}
+
+func (p *parser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *parser) next() string {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+func (p *parser) isOp(names ...string) bool {
+	t := p.peek()
+	for _, n := range names {
+		if strings.EqualFold(t, n) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) or() (Rule, error) {
+	rule, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+
+	any := Any{rule}
+	for p.isOp("or", "||") {
+		p.next()
+		rule, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		any = append(any, rule)
+	}
+
+	if len(any) == 1 {
+		return rule, nil
+	}
+	return any, nil
+}
+
+func (p *parser) and() (Rule, error) {
+	rule, err := p.not()
+	if err != nil {
+		return nil, err
+	}
+
+	all := All{rule}
+	for p.isOp("and", "&&") {
+		p.next()
+		rule, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, rule)
+	}
+
+	if len(all) == 1 {
+		return rule, nil
+	}
+	return all, nil
+}
+
+func (p *parser) not() (Rule, error) {
+	if p.isOp("not", "!") {
+		p.next()
+		rule, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		return Not{Rule: rule}, nil
+	}
+
+	if p.peek() == "(" {
+		p.next()
+		rule, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if p.next() != ")" {
+			return nil, fmt.Errorf("rules: missing )")
+		}
+		return rule, nil
+	}
+
+	return p.check()
+}
+
+func (p *parser) check() (Rule, error) {
+	name := strings.ToLower(p.next())
+	if name == "" || name == ")" || name == "," {
+		return nil, fmt.Errorf("rules: expected a check, got %q", name)
+	}
+
+	var args []string
+	if p.peek() == "(" {
+		p.next()
+		for p.peek() != ")" {
+			arg := p.next()
+			if arg == "" || arg == "(" || arg == "," {
+				return nil, fmt.Errorf("rules: bad arguments to %v", name)
+			}
+			args = append(args, arg)
+			if p.peek() == "," {
+				p.next()
+			}
+		}
+		p.next()
+	}
+
+	return p.build(name, args)
+}
+
+func (p *parser) build(name string, args []string) (Rule, error) {
+	arity := func(min, max int) error {
+		if len(args) < min || len(args) > max {
+			return fmt.Errorf("rules: %v takes %v to %v arguments, got %v", name, min, max, len(args))
+		}
+		return nil
+	}
+
+	b := p.backends
+	switch name {
+	case "exists":
+		return Exists{Client: b.Client}, arity(0, 0)
+	case "baker":
+		return Baker{TzKT: b.TzKT}, arity(0, 0)
+	case "delegates":
+		return Delegation{TzKT: b.TzKT, Bakers: args}, nil
+	case "balance":
+		if err := arity(1, 1); err != nil {
+			return nil, err
+		}
+		xtz, err := strconv.ParseFloat(args[0], 64)
+		if err != nil {
+			return nil, fmt.Errorf("rules: bad balance %q", args[0])
+		}
+		return Balance{TzKT: b.TzKT, Min: int64(math.Round(xtz * 1e6)), Level: b.Level}, nil
+	case "nft", "token":
+		max := 3
+		if name == "nft" {
+			max = 1
+		}
+		if err := arity(1, max); err != nil {
+			return nil, err
+		}
+		rule := Token{TzKT: b.TzKT, Contract: args[0], Min: big.NewInt(1), Level: b.Level}
+		if len(args) > 1 {
+			rule.TokenID = args[1]
+		}
+		if len(args) > 2 {
+			_, ok := rule.Min.SetString(args[2], 10)
+			if !ok {
+				return nil, fmt.Errorf("rules: bad token amount %q", args[2])
+			}
+		}
+		return rule, nil
+	case "age":
+		if err := arity(1, 1); err != nil {
+			return nil, err
+		}
+		d, err := parseDuration(args[0])
+		if err != nil {
+			return nil, fmt.Errorf("rules: bad age %q", args[0])
+		}
+		return Age{TzKT: b.TzKT, Min: d}, nil
+	case "bigmap":
+		if err := arity(1, 2); err != nil {
+			return nil, err
+		}
+		id, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("rules: bad big map id %q", args[0])
+		}
+		keyHash := len(args) > 1
+		if keyHash && args[1] != "key_hash" {
+			return nil, fmt.Errorf("rules: bad big map key %q", args[1])
+		}
+		return BigMapMember{RPC: b.RPC, BigMapID: id, KeyHash: keyHash, Level: b.Level}, nil
+	case "view":
+		if err := arity(2, 2); err != nil {
+			return nil, err
+		}
+		return View{RPC: b.RPC, Contract: args[0], View: args[1], Level: b.Level}, nil
+	}
+
+	return nil, fmt.Errorf("rules: unknown check %q", name)
+}
+
+// parseDuration extends time.ParseDuration with a d suffix for days.
+func parseDuration(s string) (time.Duration, error) {
+	if strings.HasSuffix(s, "d") {
+		days, err := strconv.ParseFloat(strings.TrimSuffix(s, "d"), 64)
+		return time.Duration(days * float64(24*time.Hour)), err
+	}
+	return time.ParseDuration(s)
+}
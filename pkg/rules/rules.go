@@ -0,0 +1,99 @@
+package rules
+
+import "context"
+
+import "github.com/aaronwinter/tezosagora/pkg/tezos"
+
+// A Rule decides whether a wallet may be let in, and explains why. The
+// lookups it makes log through the logger of ctx, see log.FromContext.
+type Rule interface {
+	Evaluate(ctx context.Context, wallet string) (Report, error)
+}
+
+// Report is the outcome of a rule for one wallet. Composite rules report the
+// outcome of each of their children in Checks.
+type Report struct {
+	Rule   string   `json:"rule"`
+	Passed bool     `json:"passed"`
+	Checks []Report `json:"checks,omitempty"`
+}
+
+// Exists passes for any wallet known to the Tezos API.
+type Exists struct {
+	Client *tezos.Client
+}
+
+// Evaluate implements Rule.
+func (r Exists) Evaluate(ctx context.Context, wallet string) (Report, error) {
+	ok, err := r.Client.WalletExists(ctx, wallet)
+	return Report{Rule: "wallet exists", Passed: ok}, err
+}
+
+func evaluateAll(ctx context.Context, rs []Rule, wallet string) ([]Report, error) {
+	reports := make([]Report, 0, len(rs))
+	for _, r := range rs {
+		report, err := r.Evaluate(ctx, wallet)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+// All passes when every one of its rules passes. Every rule is evaluated so
+// the report lists all of them.
+type All []Rule
+
+// Evaluate implements Rule.
+func (rs All) Evaluate(ctx context.Context, wallet string) (Report, error) {
+	if len(rs) == 1 {
+		return rs[0].Evaluate(ctx, wallet)
+	}
+
+	checks, err := evaluateAll(ctx, rs, wallet)
+	if err != nil {
+		return Report{}, err
+	}
+
+	passed := true
+	for _, c := range checks {
+		passed = passed && c.Passed
+	}
+	return Report{Rule: "all of", Passed: passed, Checks: checks}, nil
+}
+
+// Any passes when at least one of its rules passes.
+type Any []Rule
+
+// Evaluate implements Rule.
+func (rs Any) Evaluate(ctx context.Context, wallet string) (Report, error) {
+	if len(rs) == 1 {
+		return rs[0].Evaluate(ctx, wallet)
+	}
+
+	checks, err := evaluateAll(ctx, rs, wallet)
+	if err != nil {
+		return Report{}, err
+	}
+
+	passed := false
+	for _, c := range checks {
+		passed = passed || c.Passed
+	}
+	return Report{Rule: "any of", Passed: passed, Checks: checks}, nil
+}
+
+// Not passes when its rule fails.
+type Not struct {
+	Rule Rule
+}
+
+// Evaluate implements Rule.
+func (r Not) Evaluate(ctx context.Context, wallet string) (Report, error) {
+	report, err := r.Rule.Evaluate(ctx, wallet)
+	if err != nil {
+		return Report{}, err
+	}
+	return Report{Rule: "not", Passed: !report.Passed, Checks: []Report{report}}, nil
+}
@@ -0,0 +1,56 @@
+package rules
+
+import "fmt"
+import "strings"
+
+// A Tier maps holders passing Rule to a Discord role or channel.
+type Tier struct {
+	Name string
+	// ID is the Discord role or channel of the tier.
+	ID   string
+	Rule Rule
+}
+
+// ParseTiers reads a tier table of semicolon separated entries of the form
+// [name:]id=expression, where id is a Discord role, channel or invite code,
+// e.g.
+//
+//	Dolphin:1234=balance(100); Whale:5678=balance(10000); Collector:9012=nft(KT1...)
+//
+// See Parse for the expression syntax.
+func ParseTiers(spec string, b Backends) ([]Tier, error) {
+	var tiers []Tier
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		i := strings.Index(entry, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("rules: tier %q lacks a rule", entry)
+		}
+
+		var tier Tier
+		id := strings.TrimSpace(entry[:i])
+		if j := strings.Index(id, ":"); j >= 0 {
+			tier.Name, id = id[:j], id[j+1:]
+		}
+		if id == "" {
+			return nil, fmt.Errorf("rules: tier %q lacks an id", entry)
+		}
+		tier.ID = id
+		if tier.Name == "" {
+			tier.Name = id
+		}
+
+		rule, err := Parse(entry[i+1:], b)
+		if err != nil {
+			return nil, fmt.Errorf("rules: tier %v: %v", tier.Name, err)
+		}
+		tier.Rule = rule
+
+		tiers = append(tiers, tier)
+	}
+	return tiers, nil
+}
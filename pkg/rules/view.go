@@ -0,0 +1,27 @@
+package rules
+
+import "context"
+import "fmt"
+
+import "github.com/aaronwinter/tezosagora/pkg/tezos"
+
+// View passes for wallets for which the on-chain view View of Contract,
+// called with the wallet address, returns True, Some or a non-zero number.
+// This lets communities keep arbitrary eligibility logic on chain.
+type View struct {
+	RPC      *tezos.RPC
+	Contract string
+	View     string
+	Level    int64
+}
+
+// Evaluate implements Rule.
+func (r View) Evaluate(ctx context.Context, wallet string) (Report, error) {
+	report := Report{Rule: fmt.Sprintf("approved by %v%%%v%v", r.Contract, r.View, atLevel(r.Level))}
+	result, err := r.RPC.RunView(ctx, r.Contract, r.View, tezos.Micheline{String: wallet}, r.Level)
+	if err != nil {
+		return report, err
+	}
+	report.Passed = result.Truthy()
+	return report, nil
+}
@@ -0,0 +1,4 @@
+// Package siwt implements Sign-In with Tezos messages: an EIP-4361 style
+// plain text message binding a signature to a domain, a server issued nonce
+// and a validity window.
+package siwt
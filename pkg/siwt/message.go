@@ -0,0 +1,111 @@
+package siwt
+
+import "errors"
+import "fmt"
+import "strings"
+import "time"
+
+// Prefix is prepended by wallets to messages signed as MICHELINE payloads.
+const Prefix = "Tezos Signed Message: "
+
+const header = " wants you to sign in with your Tezos account:"
+
+// Errors returned when parsing or checking a message.
+var (
+	ErrMalformed       = errors.New("malformed SIWT message")
+	ErrDomainMismatch  = errors.New("SIWT domain mismatch")
+	ErrAddressMismatch = errors.New("SIWT address mismatch")
+	ErrUnknownNonce    = errors.New("unknown or already used SIWT nonce")
+	ErrExpired         = errors.New("SIWT message expired")
+	ErrNotYetValid     = errors.New("SIWT message not yet valid")
+)
+
+// Message is a Sign-In with Tezos message.
+type Message struct {
+	Domain    string
+	Address   string
+	Statement string
+
+	URI            string
+	Version        string
+	ChainID        string
+	Nonce          string
+	IssuedAt       time.Time
+	ExpirationTime time.Time
+	NotBefore      time.Time
+}
+
+// String formats m the way SIWT frontend libraries do, without the wallet
+// prefix.
+func (m *Message) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%v%v\n%v\n\n", m.Domain, header, m.Address)
+	if m.Statement != "" {
+		fmt.Fprintf(&b, "%v\n\n", m.Statement)
+	}
+	fmt.Fprintf(&b, "URI: %v\nVersion: %v\nChain ID: %v\nNonce: %v\nIssued At: %v",
+		m.URI, m.Version, m.ChainID, m.Nonce, m.IssuedAt.UTC().Format(time.RFC3339))
+	if !m.ExpirationTime.IsZero() {
+		fmt.Fprintf(&b, "\nExpiration Time: %v", m.ExpirationTime.UTC().Format(time.RFC3339))
+	}
+	if !m.NotBefore.IsZero() {
+		fmt.Fprintf(&b, "\nNot Before: %v", m.NotBefore.UTC().Format(time.RFC3339))
+	}
+	return b.String()
+}
+
+// Parse reads a SIWT message, with or without the wallet prefix.
+func Parse(s string) (*Message, error) {
+	s = strings.TrimPrefix(strings.ReplaceAll(s, "\r\n", "\n"), Prefix)
+	lines := strings.Split(s, "\n")
+	if len(lines) < 3 || !strings.HasSuffix(lines[0], header) || lines[2] != "" {
+		return nil, ErrMalformed
+	}
+
+	m := &Message{
+		Domain:  strings.TrimSuffix(lines[0], header),
+		Address: lines[1],
+	}
+
+	rest := lines[3:]
+	if len(rest) > 1 && !strings.Contains(rest[0], ": ") && rest[1] == "" {
+		m.Statement = rest[0]
+		rest = rest[2:]
+	}
+
+	for _, line := range rest {
+		kv := strings.SplitN(line, ": ", 2)
+		if len(kv) != 2 {
+			return nil, ErrMalformed
+		}
+
+		var err error
+		switch kv[0] {
+		case "URI":
+			m.URI = kv[1]
+		case "Version":
+			m.Version = kv[1]
+		case "Chain ID":
+			m.ChainID = kv[1]
+		case "Nonce":
+			m.Nonce = kv[1]
+		case "Issued At":
+			m.IssuedAt, err = time.Parse(time.RFC3339, kv[1])
+		case "Expiration Time":
+			m.ExpirationTime, err = time.Parse(time.RFC3339, kv[1])
+		case "Not Before":
+			m.NotBefore, err = time.Parse(time.RFC3339, kv[1])
+		default:
+			return nil, ErrMalformed
+		}
+		if err != nil {
+			return nil, ErrMalformed
+		}
+	}
+
+	if m.Domain == "" || m.Address == "" || m.Nonce == "" || m.IssuedAt.IsZero() {
+		return nil, ErrMalformed
+	}
+
+	return m, nil
+}
@@ -0,0 +1,138 @@
+package siwt
+
+import "crypto/hmac"
+import "crypto/rand"
+import "crypto/sha256"
+import "encoding/binary"
+import "encoding/hex"
+import "sync"
+import "time"
+
+import log "github.com/apex/log"
+
+// Sizes of the parts of a nonce: a random salt, its expiry and the
+// truncated MAC of both.
+const (
+	saltSize   = 12
+	expirySize = 8
+	macSize    = 16
+)
+
+// Verifier checks SIWT messages against the expected domain and the nonces
+// it issued. Nonces are signed rather than recorded, only the used ones are
+// kept until they expire. They are only valid for the lifetime of the
+// process.
+type Verifier struct {
+	Domain string
+	// MaxAge bounds how long after issuance a message without an explicit
+	// expiration time is accepted, and how long issued nonces stay valid.
+	MaxAge time.Duration
+
+	key  []byte
+	mu   sync.Mutex
+	used map[string]time.Time
+}
+
+// NewVerifier returns a Verifier accepting messages for domain.
+func NewVerifier(domain string, maxAge time.Duration) *Verifier {
+	key := make([]byte, 32)
+	rand.Read(key)
+	return &Verifier{
+		Domain: domain,
+		MaxAge: maxAge,
+		key:    key,
+		used:   make(map[string]time.Time),
+	}
+}
+
+func (v *Verifier) sign(b []byte) []byte {
+	mac := hmac.New(sha256.New, v.key)
+	mac.Write(b)
+	return mac.Sum(nil)[:macSize]
+}
+
+// Nonce issues a fresh single use nonce, valid for MaxAge. Nonces are hex
+// encoded, as SIWT requires them to be alphanumeric.
+func (v *Verifier) Nonce() (string, error) {
+	b := make([]byte, saltSize+expirySize, saltSize+expirySize+macSize)
+	_, err := rand.Read(b[:saltSize])
+	if err != nil {
+		return "", err
+	}
+	binary.BigEndian.PutUint64(b[saltSize:], uint64(time.Now().Add(v.MaxAge).Unix()))
+
+	return hex.EncodeToString(append(b, v.sign(b)...)), nil
+}
+
+// consume reports whether nonce was issued by v, isn't expired and wasn't
+// used before, and records it as used.
+func (v *Verifier) consume(nonce string) bool {
+	b, err := hex.DecodeString(nonce)
+	if err != nil || len(b) != saltSize+expirySize+macSize {
+		return false
+	}
+	payload := b[:saltSize+expirySize]
+	if !hmac.Equal(b[saltSize+expirySize:], v.sign(payload)) {
+		return false
+	}
+	expires := time.Unix(int64(binary.BigEndian.Uint64(payload[saltSize:])), 0)
+	// hex decoding ignores case, which must not tell uses apart
+	nonce = hex.EncodeToString(b)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	now := time.Now()
+	if now.After(expires) {
+		return false
+	}
+	for n, expiry := range v.used {
+		if now.After(expiry) {
+			delete(v.used, n)
+		}
+	}
+	if _, ok := v.used[nonce]; ok {
+		return false
+	}
+	v.used[nonce] = expires
+	return true
+}
+
+// Check parses message and makes sure it was issued for address on the
+// expected domain, that it is within its validity window and that its nonce
+// was issued by v and not used before. The signature itself is not checked.
+func (v *Verifier) Check(address, message string) (*Message, error) {
+	m, err := Parse(message)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.Domain != v.Domain {
+		log.WithFields(log.Fields{
+			"expected": v.Domain,
+			"domain":   m.Domain,
+		}).Warn("SIWT domain mismatch")
+		return nil, ErrDomainMismatch
+	}
+
+	if m.Address != address {
+		return nil, ErrAddressMismatch
+	}
+
+	now := time.Now()
+	expiration := m.ExpirationTime
+	if expiration.IsZero() {
+		expiration = m.IssuedAt.Add(v.MaxAge)
+	}
+	if now.After(expiration) {
+		return nil, ErrExpired
+	}
+	if now.Before(m.NotBefore) || now.Before(m.IssuedAt.Add(-time.Minute)) {
+		return nil, ErrNotYetValid
+	}
+
+	if !v.consume(m.Nonce) {
+		return nil, ErrUnknownNonce
+	}
+
+	return m, nil
+}
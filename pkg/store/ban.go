@@ -0,0 +1,67 @@
+package store
+
+import "encoding/json"
+import "time"
+
+// banKind records block the wallets of banned Discord members.
+const banKind = "ban"
+
+// Ban blocks a wallet whose Discord account got banned, or that an admin
+// blocked, in which case UserID is empty.
+type Ban struct {
+	Wallet string    `json:"wallet"`
+	UserID string    `json:"user_id"`
+	Reason string    `json:"reason,omitempty"`
+	Banned time.Time `json:"banned"`
+}
+
+// PutBan creates or replaces a ban.
+func (s *Store) PutBan(b *Ban) error {
+	val, err := json.Marshal(b)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Set(s.key(banKind, b.Wallet), val)
+}
+
+// DeleteBan lifts the ban of wallet.
+func (s *Store) DeleteBan(wallet string) error {
+	return s.db.Delete(s.key(banKind, wallet))
+}
+
+// EachBan calls f for every ban, stopping at the first error.
+func (s *Store) EachBan(f func(*Ban) error) error {
+	return s.scan(s.prefix+banKind+kindSeparator, func(_ string, val []byte) error {
+		b := &Ban{}
+		err := json.Unmarshal(val, b)
+		if err != nil {
+			return err
+		}
+		return f(b)
+	})
+}
+
+// Blocked reports whether address is banned, which makes the store a
+// blocklist.Checker.
+func (s *Store) Blocked(address string) (string, bool, error) {
+	val, err := s.db.Get(nil, s.key(banKind, address))
+	if err != nil || val == nil {
+		return "", false, err
+	}
+
+	var b Ban
+	err = json.Unmarshal(val, &b)
+	if err != nil {
+		return "", false, err
+	}
+
+	reason := "Discord account banned"
+	if b.UserID == "" {
+		reason = "blocked by an admin"
+	}
+	if b.Reason != "" {
+		reason += ": " + b.Reason
+	}
+	return reason, true, nil
+}
@@ -0,0 +1,3 @@
+// Package store persists wallet registrations in a kv database, mapping each
+// registered wallet to the invite that was generated for it.
+package store
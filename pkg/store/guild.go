@@ -0,0 +1,72 @@
+package store
+
+import "encoding/json"
+import "strings"
+
+// Guild holds the settings of a Discord guild served next to the default
+// one. Empty fields fall back to the service configuration.
+type Guild struct {
+	ID        string `json:"id"`
+	ChannelID string `json:"channel_id"`
+	// Name and Listed offer the guild as a destination on the web form.
+	Name   string `json:"name,omitempty"`
+	Listed bool   `json:"listed,omitempty"`
+	// RoleID is granted to members verifying with the Verify button of
+	// the guild.
+	RoleID string `json:"role_id,omitempty"`
+	// Language is the code of the language the bot speaks in the guild.
+	Language string `json:"language,omitempty"`
+	// Rules is the gating expression for the guild.
+	Rules string `json:"rules,omitempty"`
+	// InviteMaxAge, a fixed lifetime in seconds, and InviteMaxUses override
+	// the invite settings when set.
+	InviteMaxAge  int `json:"invite_max_age,omitempty"`
+	InviteMaxUses int `json:"invite_max_uses,omitempty"`
+}
+
+// PutGuild creates or replaces the settings of a guild.
+func (s *Store) PutGuild(g *Guild) error {
+	val, err := json.Marshal(g)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Set(s.key(guildKind, g.ID), val)
+}
+
+// GetGuild returns the settings of guild id, or nil if it has none.
+func (s *Store) GetGuild(id string) (*Guild, error) {
+	val, err := s.db.Get(nil, s.key(guildKind, id))
+	if err != nil || val == nil {
+		return nil, err
+	}
+
+	g := &Guild{}
+	err = json.Unmarshal(val, g)
+	return g, err
+}
+
+// DeleteGuild removes the settings of guild id. Its registrations are kept.
+func (s *Store) DeleteGuild(id string) error {
+	return s.db.Delete(s.key(guildKind, id))
+}
+
+// Guilds returns the settings of every guild.
+func (s *Store) Guilds() ([]*Guild, error) {
+	var guilds []*Guild
+	err := s.scan(s.prefix+guildKind+kindSeparator, func(id string, val []byte) error {
+		// skips the registrations of the guilds
+		if strings.Contains(id, kindSeparator) {
+			return nil
+		}
+
+		g := &Guild{}
+		err := json.Unmarshal(val, g)
+		if err != nil {
+			return err
+		}
+		guilds = append(guilds, g)
+		return nil
+	})
+	return guilds, err
+}
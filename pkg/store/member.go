@@ -0,0 +1,43 @@
+package store
+
+import "encoding/json"
+import "time"
+
+// memberKind records track the Discord members who joined the guild.
+const memberKind = "member"
+
+// Member is a Discord member who joined the guild while the bot was
+// watching.
+type Member struct {
+	UserID string    `json:"user_id"`
+	Joined time.Time `json:"joined"`
+	// Reminded is when the member was last reminded to verify.
+	Reminded time.Time `json:"reminded,omitempty"`
+}
+
+// PutMember creates or replaces a member record.
+func (s *Store) PutMember(m *Member) error {
+	val, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Set(s.key(memberKind, m.UserID), val)
+}
+
+// DeleteMember forgets the member userID.
+func (s *Store) DeleteMember(userID string) error {
+	return s.db.Delete(s.key(memberKind, userID))
+}
+
+// EachMember calls f for every member record, stopping at the first error.
+func (s *Store) EachMember(f func(*Member) error) error {
+	return s.scan(s.prefix+memberKind+kindSeparator, func(_ string, val []byte) error {
+		m := &Member{}
+		err := json.Unmarshal(val, m)
+		if err != nil {
+			return err
+		}
+		return f(m)
+	})
+}
@@ -0,0 +1,593 @@
+package store
+
+import "encoding/json"
+import "errors"
+import "io"
+import "strings"
+import "sync"
+import "time"
+
+import log "github.com/apex/log"
+import "github.com/cznic/kv"
+
+// Registrations are keyed by wallet address. Other records live under keys
+// of the form "<kind>:<id>", which never collide with an address.
+const kindSeparator = ":"
+
+// linkKind records map a linked wallet to the wallet it was linked to.
+const linkKind = "link"
+
+// inviteKind records map an invite code to the wallet it was handed to.
+const inviteKind = "invite"
+
+// ticketKind records map a one-time verification token to a Discord user.
+const ticketKind = "ticket"
+
+// spentKind records hold the IDs of the signed tickets already used, with
+// their expiry.
+const spentKind = "spent"
+
+// discordKind records map a Discord user to the wallet of the registration
+// tied to them. The one with an empty ID marks the index as built.
+const discordKind = "discord"
+
+// guildKind records hold the settings of a Discord guild, whose own
+// registrations live under keys prefixed with "guild:<id>:".
+const guildKind = "guild"
+
+// Errors returned by Claim, as a wallet backs at most one Discord account
+// and a Discord account is backed by at most one registration.
+var (
+	ErrWalletClaimed  = errors.New("wallet is tied to another Discord account")
+	ErrAccountClaimed = errors.New("Discord account is tied to another wallet")
+)
+
+// key returns the key of the record of kind for id.
+func (s *Store) key(kind, id string) []byte {
+	return []byte(s.prefix + kind + kindSeparator + id)
+}
+
+// walletKey returns the key of the registration of wallet.
+func (s *Store) walletKey(wallet string) []byte {
+	return []byte(s.prefix + wallet)
+}
+
+// Registration records that a wallet obtained an invite.
+type Registration struct {
+	Wallet     string    `json:"wallet"`
+	Invite     string    `json:"invite"`
+	Registered time.Time `json:"registered,omitempty"`
+
+	// Checked is the last time a sweep evaluated the wallet against the
+	// gating rules, Disqualified the time it first failed them, if it still
+	// does.
+	Checked      time.Time `json:"checked,omitempty"`
+	Disqualified time.Time `json:"disqualified,omitempty"`
+
+	// Linked are further wallets of the same holder, whose holdings count
+	// towards the gating rules.
+	Linked []string `json:"linked,omitempty"`
+
+	// DiscordID is the Discord user the registration is tied to, if any.
+	DiscordID string `json:"discord_id,omitempty"`
+	// Redeemed is when the invite was seen being used.
+	Redeemed time.Time `json:"redeemed,omitempty"`
+	// Campaign is the campaign of the ticket the wallet was verified with,
+	// if any.
+	Campaign string `json:"campaign,omitempty"`
+}
+
+// inviteCode returns the code at the end of an invite URL.
+func inviteCode(inviteURL string) string {
+	return inviteURL[strings.LastIndex(inviteURL, "/")+1:]
+}
+
+// Wallets returns the registered wallet followed by its linked wallets.
+func (r *Registration) Wallets() []string {
+	return append([]string{r.Wallet}, r.Linked...)
+}
+
+// Store keeps track of registered wallets.
+type Store struct {
+	db     timed
+	prefix string
+	// mu serializes the updates which read a registration before writing
+	// it back, across the views of the guilds.
+	mu *sync.Mutex
+}
+
+// Open opens the database at name, creating it if it does not exist yet.
+func Open(name string) (*Store, error) {
+	db, err := kv.Open(name, &kv.Options{})
+	if err != nil {
+		log.WithError(err).Error("failed to open DB")
+		log.Debug("trying to create DB")
+		db, err = kv.Create(name, &kv.Options{})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &Store{db: timed{db}, mu: new(sync.Mutex)}, nil
+}
+
+// Guild returns the view of the store holding the registrations of the
+// Discord guild id, isolated from those of the other guilds. Closing it
+// closes the whole database.
+func (s *Store) Guild(id string) *Store {
+	return &Store{db: s.db, prefix: guildKind + kindSeparator + id + kindSeparator, mu: s.mu}
+}
+
+// Ping reports whether the database can be read.
+func (s *Store) Ping() error {
+	_, err := s.db.Get(nil, s.key("ping", ""))
+	return err
+}
+
+// Close flushes and closes the underlying database.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// decode reads a registration, older databases storing the bare invite URL.
+func decode(wallet string, val []byte) (*Registration, error) {
+	reg := &Registration{Wallet: wallet}
+	if len(val) == 0 || val[0] != '{' {
+		reg.Invite = string(val)
+		return reg, nil
+	}
+
+	err := json.Unmarshal(val, reg)
+	return reg, err
+}
+
+// IsRegistered reports whether wallet already obtained an invite, either
+// itself or as a linked wallet.
+func (s *Store) IsRegistered(wallet string) (bool, error) {
+	reg, err := s.Owner(wallet)
+	return reg != nil, err
+}
+
+// Get returns the registration of wallet, or nil if it is not registered.
+func (s *Store) Get(wallet string) (*Registration, error) {
+	val, err := s.db.Get(nil, s.walletKey(wallet))
+	if err != nil || val == nil {
+		return nil, err
+	}
+
+	return decode(wallet, val)
+}
+
+// Owner returns the registration wallet belongs to, be it its own or the
+// one it is linked to, or nil if there is none.
+func (s *Store) Owner(wallet string) (*Registration, error) {
+	reg, err := s.Get(wallet)
+	if err != nil || reg != nil {
+		return reg, err
+	}
+
+	val, err := s.db.Get(nil, s.key(linkKind, wallet))
+	if err != nil || val == nil {
+		return nil, err
+	}
+
+	return s.Get(string(val))
+}
+
+// Link adds wallet to the linked wallets of reg.
+func (s *Store) Link(reg *Registration, wallet string) error {
+	err := s.db.Set(s.key(linkKind, wallet), []byte(reg.Wallet))
+	if err != nil {
+		return err
+	}
+
+	reg.Linked = append(reg.Linked, wallet)
+	return s.Put(reg)
+}
+
+// Unlink removes wallet from the linked wallets of reg.
+func (s *Store) Unlink(reg *Registration, wallet string) error {
+	linked := reg.Linked[:0]
+	for _, w := range reg.Linked {
+		if w != wallet {
+			linked = append(linked, w)
+		}
+	}
+	reg.Linked = linked
+
+	err := s.Put(reg)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Delete(s.key(linkKind, wallet))
+}
+
+// Claim ties the registration owning wallet to the Discord user userID and
+// returns it, or nil if wallet is not registered. It fails with
+// ErrWalletClaimed or ErrAccountClaimed if either is tied to another
+// already, see Release.
+func (s *Store) Claim(wallet, userID string) (*Registration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reg, err := s.Owner(wallet)
+	if err != nil || reg == nil {
+		return nil, err
+	}
+	if reg.DiscordID == userID {
+		return reg, nil
+	}
+	if reg.DiscordID != "" {
+		return nil, ErrWalletClaimed
+	}
+
+	other, err := s.ByDiscord(userID)
+	if err != nil {
+		return nil, err
+	}
+	if other != nil {
+		return nil, ErrAccountClaimed
+	}
+
+	reg.DiscordID = userID
+	return reg, s.Put(reg)
+}
+
+// Attribute records campaign on the registration owning wallet, unless it
+// has one already, and returns it, or nil if wallet is not registered.
+func (s *Store) Attribute(wallet, campaign string) (*Registration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reg, err := s.Owner(wallet)
+	if err != nil || reg == nil || reg.Campaign != "" {
+		return reg, err
+	}
+	reg.Campaign = campaign
+	return reg, s.Put(reg)
+}
+
+// Release unties the registration owning wallet from its Discord account,
+// so that another may claim it, and returns it as it was, or nil if wallet
+// is not registered.
+func (s *Store) Release(wallet string) (*Registration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reg, err := s.Owner(wallet)
+	if err != nil || reg == nil || reg.DiscordID == "" {
+		return reg, err
+	}
+
+	released := *reg
+	released.DiscordID = ""
+	return reg, s.Put(&released)
+}
+
+// Update reads the registration owning wallet afresh and stores it once
+// changed by f, under the lock Claim takes, so that the updates made since
+// it was last read aren't lost. It returns the registration, or nil without
+// calling f if wallet is not registered anymore.
+func (s *Store) Update(wallet string, f func(reg *Registration)) (*Registration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reg, err := s.Owner(wallet)
+	if err != nil || reg == nil {
+		return nil, err
+	}
+	f(reg)
+	return reg, s.Put(reg)
+}
+
+// ByDiscord returns the registration tied to the Discord user userID, or
+// nil if there is none.
+func (s *Store) ByDiscord(userID string) (*Registration, error) {
+	err := s.indexDiscord()
+	if err != nil {
+		return nil, err
+	}
+
+	wallet, err := s.db.Get(nil, s.key(discordKind, userID))
+	if err != nil || wallet == nil {
+		return nil, err
+	}
+	reg, err := s.Get(string(wallet))
+	if err != nil || reg == nil || reg.DiscordID != userID {
+		return nil, err
+	}
+	return reg, nil
+}
+
+// indexDiscord builds the index of the registrations by Discord user once,
+// as databases written by older versions lack it.
+func (s *Store) indexDiscord() error {
+	built, err := s.db.Get(nil, s.key(discordKind, ""))
+	if err != nil || built != nil {
+		return err
+	}
+
+	wallets := make(map[string]string)
+	err = s.Each(func(reg *Registration) error {
+		if reg.DiscordID != "" {
+			wallets[reg.DiscordID] = reg.Wallet
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for userID, wallet := range wallets {
+		err = s.db.Set(s.key(discordKind, userID), []byte(wallet))
+		if err != nil {
+			return err
+		}
+	}
+	log.WithField("count", len(wallets)).Info("indexed registrations by Discord user")
+	return s.db.Set(s.key(discordKind, ""), []byte(time.Now().UTC().Format(time.RFC3339)))
+}
+
+// Invite returns the invite URL stored for wallet, or an empty string if the
+// wallet is not registered.
+func (s *Store) Invite(wallet string) (string, error) {
+	reg, err := s.Owner(wallet)
+	if err != nil || reg == nil {
+		return "", err
+	}
+
+	return reg.Invite, nil
+}
+
+// Register records that wallet obtained inviteURL. Shared invites, handed
+// out to other wallets too such as vanity URLs, are left out of the index of
+// InviteOwner.
+func (s *Store) Register(wallet, inviteURL string, shared bool) error {
+	if inviteURL != "" && !shared {
+		err := s.db.Set(s.key(inviteKind, inviteCode(inviteURL)), []byte(wallet))
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.Put(&Registration{
+		Wallet:     wallet,
+		Invite:     inviteURL,
+		Registered: time.Now().UTC(),
+	})
+}
+
+// Reissue replaces the invite of reg with inviteURL, see Register for
+// shared.
+func (s *Store) Reissue(reg *Registration, inviteURL string, shared bool) error {
+	err := s.db.Delete(s.key(inviteKind, inviteCode(reg.Invite)))
+	if err != nil {
+		return err
+	}
+
+	if !shared {
+		err = s.db.Set(s.key(inviteKind, inviteCode(inviteURL)), []byte(reg.Wallet))
+		if err != nil {
+			return err
+		}
+	}
+
+	reg.Invite = inviteURL
+	return s.Put(reg)
+}
+
+// InviteOwner returns the registration the invite code was handed to, or
+// nil if there is none.
+func (s *Store) InviteOwner(code string) (*Registration, error) {
+	val, err := s.db.Get(nil, s.key(inviteKind, code))
+	if err != nil || val == nil {
+		return nil, err
+	}
+
+	return s.Owner(string(val))
+}
+
+// Put creates or replaces a registration, keeping its Discord user indexed.
+func (s *Store) Put(reg *Registration) error {
+	val, err := json.Marshal(reg)
+	if err != nil {
+		return err
+	}
+
+	old, err := s.Get(reg.Wallet)
+	if err != nil {
+		return err
+	}
+	if old != nil && old.DiscordID != "" && old.DiscordID != reg.DiscordID {
+		err = s.db.Delete(s.key(discordKind, old.DiscordID))
+		if err != nil {
+			return err
+		}
+	}
+	if reg.DiscordID != "" {
+		err = s.db.Set(s.key(discordKind, reg.DiscordID), []byte(reg.Wallet))
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.db.Set(s.walletKey(reg.Wallet), val)
+}
+
+// Delete removes the registration of wallet, releasing its linked wallets.
+func (s *Store) Delete(wallet string) error {
+	reg, err := s.Get(wallet)
+	if err != nil || reg == nil {
+		return err
+	}
+
+	for _, linked := range reg.Linked {
+		err = s.db.Delete(s.key(linkKind, linked))
+		if err != nil {
+			return err
+		}
+	}
+
+	err = s.db.Delete(s.key(inviteKind, inviteCode(reg.Invite)))
+	if err != nil {
+		return err
+	}
+
+	if reg.DiscordID != "" {
+		err = s.db.Delete(s.key(discordKind, reg.DiscordID))
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.db.Delete(s.walletKey(wallet))
+}
+
+// Each calls f for every registration, in address order, stopping at the
+// first error.
+func (s *Store) Each(f func(*Registration) error) error {
+	return s.EachFrom("", f)
+}
+
+// EachFrom is like Each, starting at the first wallet not sorting before
+// from.
+func (s *Store) EachFrom(from string, f func(*Registration) error) error {
+	return s.scanFrom(s.prefix, from, func(id string, val []byte) error {
+		if strings.Contains(id, kindSeparator) {
+			return nil
+		}
+
+		reg, err := decode(id, val)
+		if err != nil {
+			log.WithError(err).WithField("key", s.prefix+id).Error("could not decode registration")
+			return nil
+		}
+
+		return f(reg)
+	})
+}
+
+// scan calls f for every record whose key starts with prefix, with the rest
+// of the key, stopping at the first error.
+func (s *Store) scan(prefix string, f func(id string, val []byte) error) error {
+	return s.scanFrom(prefix, "", f)
+}
+
+// scanFrom is like scan, starting at the first key not sorting before
+// prefix followed by from.
+func (s *Store) scanFrom(prefix, from string, f func(id string, val []byte) error) error {
+	defer observe("scan", time.Now())
+
+	var enum *kv.Enumerator
+	var err error
+	if prefix+from == "" {
+		enum, err = s.db.SeekFirst()
+	} else {
+		enum, _, err = s.db.Seek([]byte(prefix + from))
+	}
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for {
+		key, val, err := enum.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if !strings.HasPrefix(string(key), prefix) {
+			return nil
+		}
+
+		err = f(strings.TrimPrefix(string(key), prefix), val)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+type ticket struct {
+	UserID  string    `json:"user_id"`
+	Expires time.Time `json:"expires"`
+}
+
+// PutTicket records that token may be used once, until expires, to tie a
+// registration to the Discord user userID.
+func (s *Store) PutTicket(token, userID string, expires time.Time) error {
+	val, err := json.Marshal(ticket{UserID: userID, Expires: expires})
+	if err != nil {
+		return err
+	}
+
+	return s.db.Set(s.key(ticketKind, token), val)
+}
+
+// TakeTicket consumes token and returns its Discord user, or an empty string
+// if the token is unknown, used or expired.
+func (s *Store) TakeTicket(token string) (string, error) {
+	val, err := s.db.Get(nil, s.key(ticketKind, token))
+	if err != nil || val == nil {
+		return "", err
+	}
+
+	err = s.db.Delete(s.key(ticketKind, token))
+	if err != nil {
+		return "", err
+	}
+
+	var t ticket
+	err = json.Unmarshal(val, &t)
+	if err != nil || time.Now().After(t.Expires) {
+		return "", err
+	}
+	return t.UserID, nil
+}
+
+// TicketSpent reports whether the signed ticket id was used already.
+func (s *Store) TicketSpent(id string) (bool, error) {
+	val, err := s.db.Get(nil, s.key(spentKind, id))
+	return val != nil, err
+}
+
+// SpendTicket records that the signed ticket id, valid until expires, got
+// used, and reports whether it wasn't already.
+func (s *Store) SpendTicket(id string, expires time.Time) (bool, error) {
+	_, written, err := s.db.Put(nil, s.key(spentKind, id), func(key, old []byte) ([]byte, bool, error) {
+		if old != nil {
+			return nil, false, nil
+		}
+		return []byte(expires.UTC().Format(time.RFC3339)), true, nil
+	})
+	return written, err
+}
+
+// PruneTickets deletes the records of the signed tickets which expired, as
+// they can't be used again anyway, and returns how many it deleted.
+func (s *Store) PruneTickets() (int, error) {
+	now := time.Now()
+	var expired []string
+	err := s.scan(s.prefix+spentKind+kindSeparator, func(id string, val []byte) error {
+		expires, err := time.Parse(time.RFC3339, string(val))
+		if err != nil || now.After(expires) {
+			expired = append(expired, id)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for i, id := range expired {
+		err = s.db.Delete(s.key(spentKind, id))
+		if err != nil {
+			return i, err
+		}
+	}
+	return len(expired), nil
+}
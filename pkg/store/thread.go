@@ -0,0 +1,20 @@
+package store
+
+// threadKind records map a Discord user to their open verification thread.
+const threadKind = "thread"
+
+// PutThread records threadID as the verification thread of userID.
+func (s *Store) PutThread(userID, threadID string) error {
+	return s.db.Set(s.key(threadKind, userID), []byte(threadID))
+}
+
+// TakeThread forgets and returns the verification thread of userID, or an
+// empty string if they have none.
+func (s *Store) TakeThread(userID string) (string, error) {
+	val, err := s.db.Get(nil, s.key(threadKind, userID))
+	if err != nil || val == nil {
+		return "", err
+	}
+
+	return string(val), s.db.Delete(s.key(threadKind, userID))
+}
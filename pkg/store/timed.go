@@ -0,0 +1,31 @@
+package store
+
+import "time"
+
+import "github.com/cznic/kv"
+
+import "github.com/aaronwinter/tezosagora/pkg/metrics"
+
+// timed is the database, recording how long its operations take.
+type timed struct {
+	*kv.DB
+}
+
+func (db timed) Get(buf, key []byte) ([]byte, error) {
+	defer observe("get", time.Now())
+	return db.DB.Get(buf, key)
+}
+
+func (db timed) Set(key, value []byte) error {
+	defer observe("set", time.Now())
+	return db.DB.Set(key, value)
+}
+
+func (db timed) Delete(key []byte) error {
+	defer observe("delete", time.Now())
+	return db.DB.Delete(key)
+}
+
+func observe(op string, start time.Time) {
+	metrics.DBOperations.WithLabelValues(op).Observe(metrics.Since(start))
+}
@@ -0,0 +1,196 @@
+// Package sweep periodically re-evaluates registered wallets against the
+// gating rules, flagging the ones that no longer qualify, e.g. because they
+// sold the NFT they were let in for.
+package sweep
+
+import "context"
+import "time"
+
+import log "github.com/apex/log"
+
+import "github.com/aaronwinter/tezosagora/pkg/rules"
+import "github.com/aaronwinter/tezosagora/pkg/store"
+import "github.com/aaronwinter/tezosagora/pkg/verify"
+
+// Sweeper re-checks every registration.
+type Sweeper struct {
+	Store    *store.Store
+	Verifier *verify.Verifier
+	// Pause between two wallets, to spare the upstream APIs.
+	Pause time.Duration
+	// OnChange, when set, is called after the stored registration was
+	// updated for a wallet that stopped or started qualifying again.
+	OnChange func(reg *store.Registration, report rules.Report)
+	// OnChecked, when set, is called after every check, e.g. to follow
+	// changes that don't affect qualification.
+	OnChecked func(reg *store.Registration, report rules.Report)
+	// Guilds, when set, returns the pipelines of the guilds served next to
+	// the default one by guild ID, whose registrations are checked too.
+	// OnChange and OnChecked only follow the default guild.
+	Guilds func() (map[string]*verify.Verifier, error)
+}
+
+// Run sweeps every interval until stop is closed.
+func (s *Sweeper) Run(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			err := s.Sweep()
+			if err != nil {
+				log.WithError(err).Error("sweep failed")
+			}
+		}
+	}
+}
+
+// Sweep checks every registration once.
+func (s *Sweeper) Sweep() error {
+	err := s.sweep("", s.Verifier)
+	if err != nil || s.Guilds == nil {
+		return err
+	}
+
+	guilds, err := s.Guilds()
+	if err != nil {
+		return err
+	}
+	for id, verifier := range guilds {
+		err = s.sweep(id, verifier)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sweep checks every registration of the guild id, empty for the default
+// one, against verifier.
+func (s *Sweeper) sweep(id string, verifier *verify.Verifier) error {
+	var regs []*store.Registration
+	err := s.store(verifier).Each(func(reg *store.Registration) error {
+		regs = append(regs, reg)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"guild": id,
+		"count": len(regs),
+	}).Info("sweeping registrations")
+
+	disqualified := 0
+	for _, reg := range regs {
+		passed, err := s.check(verifier, reg)
+		if err != nil {
+			// with the upstream down there is no point in carrying on
+			return err
+		}
+		if !passed {
+			disqualified++
+		}
+
+		time.Sleep(s.Pause)
+	}
+
+	log.WithFields(log.Fields{
+		"guild":        id,
+		"count":        len(regs),
+		"disqualified": disqualified,
+	}).Info("sweep done")
+
+	return nil
+}
+
+// Recheck evaluates the registrations owning wallet right away, in every
+// guild, e.g. because its holdings changed. Unregistered wallets are
+// ignored.
+func (s *Sweeper) Recheck(wallet string) error {
+	err := s.recheck(s.Verifier, wallet)
+	if err != nil || s.Guilds == nil {
+		return err
+	}
+
+	guilds, err := s.Guilds()
+	if err != nil {
+		return err
+	}
+	for _, verifier := range guilds {
+		err = s.recheck(verifier, wallet)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Sweeper) recheck(verifier *verify.Verifier, wallet string) error {
+	reg, err := s.store(verifier).Owner(wallet)
+	if err != nil || reg == nil {
+		return err
+	}
+
+	_, err = s.check(verifier, reg)
+	return err
+}
+
+// store returns the store holding the registrations checked by verifier.
+func (s *Sweeper) store(verifier *verify.Verifier) *store.Store {
+	if verifier == s.Verifier {
+		return s.Store
+	}
+	return verifier.Store
+}
+
+func (s *Sweeper) check(verifier *verify.Verifier, reg *store.Registration) (bool, error) {
+	report, err := verifier.Evaluate(context.Background(), reg.Wallets()...)
+	if err != nil {
+		return false, err
+	}
+
+	// the registration may have changed, or be gone, since it was read:
+	// only the outcome of the check is written to a fresh copy
+	now := time.Now().UTC()
+	changed := false
+	fresh, err := s.store(verifier).Update(reg.Wallet, func(fresh *store.Registration) {
+		changed = report.Passed != fresh.Disqualified.IsZero()
+		fresh.Checked = now
+		if !report.Passed {
+			if fresh.Disqualified.IsZero() {
+				fresh.Disqualified = now
+			}
+		} else {
+			fresh.Disqualified = time.Time{}
+		}
+	})
+	if err != nil {
+		return false, err
+	}
+	if fresh == nil {
+		log.WithField("wallet", reg.Wallet).Debug("wallet unregistered while being checked")
+		return true, nil
+	}
+	reg = fresh
+
+	if changed {
+		log.WithFields(log.Fields{
+			"wallet": reg.Wallet,
+			"passed": report.Passed,
+		}).Warn("wallet qualification changed")
+		if s.OnChange != nil && verifier == s.Verifier {
+			s.OnChange(reg, report)
+		}
+	}
+
+	if s.OnChecked != nil && verifier == s.Verifier {
+		s.OnChecked(reg, report)
+	}
+
+	return report.Passed, nil
+}
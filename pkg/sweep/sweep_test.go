@@ -0,0 +1,132 @@
+package sweep
+
+import "context"
+import "path/filepath"
+import "testing"
+import "time"
+
+import "github.com/aaronwinter/tezosagora/pkg/rules"
+import "github.com/aaronwinter/tezosagora/pkg/store"
+import "github.com/aaronwinter/tezosagora/pkg/verify"
+
+const (
+	claimed = "tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx"
+	revoked = "tz29EVstU72wBE6ELK2CEk2BGhH9fjNP3LxR"
+)
+
+// ruleFunc passes every wallet after calling itself on it.
+type ruleFunc func(wallet string)
+
+func (f ruleFunc) Evaluate(_ context.Context, wallet string) (rules.Report, error) {
+	f(wallet)
+	return rules.Report{Rule: "test", Passed: true}, nil
+}
+
+func TestSweepKeepsConcurrentChanges(t *testing.T) {
+	db, err := store.Open(filepath.Join(t.TempDir(), "db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, wallet := range []string{claimed, revoked} {
+		err = db.Register(wallet, "https://discord.gg/"+wallet[:8], false)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the registrations change once the sweep read them, while the
+	// wallets are being checked
+	rule := ruleFunc(func(wallet string) {
+		var err error
+		switch wallet {
+		case claimed:
+			_, err = db.Claim(claimed, "1234")
+		case revoked:
+			err = db.Delete(revoked)
+		}
+		if err != nil {
+			t.Error(err)
+		}
+	})
+	s := &Sweeper{Store: db, Verifier: &verify.Verifier{Store: db, Rule: rule}}
+	err = s.Sweep()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reg, err := db.Get(claimed)
+	if err != nil || reg == nil {
+		t.Fatalf("got %v, %v, want the registration of %v", reg, err, claimed)
+	}
+	if reg.DiscordID != "1234" || reg.Checked.IsZero() {
+		t.Errorf("got Discord user %q checked at %v, want the claim kept and the check recorded", reg.DiscordID, reg.Checked)
+	}
+	owner, err := db.ByDiscord("1234")
+	if err != nil || owner == nil || owner.Wallet != claimed {
+		t.Errorf("got %v, %v for the Discord user, want the registration of %v", owner, err, claimed)
+	}
+
+	reg, err = db.Get(revoked)
+	if err != nil || reg != nil {
+		t.Errorf("got %v, %v, want the revoked registration to stay deleted", reg, err)
+	}
+}
+
+func TestSweepGuilds(t *testing.T) {
+	db, err := store.Open(filepath.Join(t.TempDir(), "db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	guild := db.Guild("42")
+	err = guild.Register(revoked, "https://discord.gg/guild", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var checked []string
+	s := &Sweeper{
+		Store:    db,
+		Verifier: &verify.Verifier{Store: db, Rule: ruleFunc(func(string) {})},
+		Guilds: func() (map[string]*verify.Verifier, error) {
+			return map[string]*verify.Verifier{"42": {Store: guild, Rule: failing{}}}, nil
+		},
+		OnChecked: func(reg *store.Registration, _ rules.Report) {
+			checked = append(checked, reg.Wallet)
+		},
+	}
+	err = s.Sweep()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reg, err := guild.Get(revoked)
+	if err != nil || reg == nil || reg.Disqualified.IsZero() {
+		t.Fatalf("got %v, %v, want the registration of the guild disqualified by its rule", reg, err)
+	}
+	if len(checked) != 0 {
+		t.Errorf("got OnChecked called for %v, want it to only follow the default guild", checked)
+	}
+
+	reg.Disqualified = time.Time{}
+	err = guild.Put(reg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.Recheck(revoked)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reg, err = guild.Get(revoked)
+	if err != nil || reg == nil || reg.Disqualified.IsZero() {
+		t.Errorf("got %v, %v, want the registration of the guild rechecked", reg, err)
+	}
+}
+
+// failing fails every wallet.
+type failing struct{}
+
+func (failing) Evaluate(_ context.Context, wallet string) (rules.Report, error) {
+	return rules.Report{Rule: "test"}, nil
+}
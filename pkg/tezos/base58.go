@@ -0,0 +1,93 @@
+package tezos
+
+import "bytes"
+import "crypto/sha256"
+import "math/big"
+import "strings"
+
+const b58alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+func b58decode(s string) ([]byte, error) {
+	n := new(big.Int)
+	radix := big.NewInt(58)
+	for _, c := range s {
+		i := strings.IndexRune(b58alphabet, c)
+		if i < 0 {
+			return nil, ErrBadEncoding
+		}
+		n.Mul(n, radix)
+		n.Add(n, big.NewInt(int64(i)))
+	}
+
+	zeros := 0
+	for zeros < len(s) && s[zeros] == b58alphabet[0] {
+		zeros++
+	}
+
+	return append(make([]byte, zeros), n.Bytes()...), nil
+}
+
+func b58encode(b []byte) string {
+	n := new(big.Int).SetBytes(b)
+	radix := big.NewInt(58)
+	mod := new(big.Int)
+
+	var out []byte
+	for n.Sign() > 0 {
+		n.DivMod(n, radix, mod)
+		out = append(out, b58alphabet[mod.Int64()])
+	}
+	for i := 0; i < len(b) && b[i] == 0; i++ {
+		out = append(out, b58alphabet[0])
+	}
+
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
+
+func checksum(b []byte) []byte {
+	first := sha256.Sum256(b)
+	second := sha256.Sum256(first[:])
+	return second[:4]
+}
+
+func b58checkEncode(prefix, payload []byte) string {
+	b := append(append([]byte{}, prefix...), payload...)
+	return b58encode(append(b, checksum(b)...))
+}
+
+// b58checkDecode finds the prefix matching s and returns the decoded payload
+// along with the prefix description.
+func b58checkDecode(s string, prefixes map[string]b58prefix) ([]byte, b58prefix, error) {
+	var p b58prefix
+	var name string
+	for k, v := range prefixes {
+		// prefer the longest human prefix, "spsig1" must win over "sig"
+		if strings.HasPrefix(s, k) && len(k) > len(name) {
+			name, p = k, v
+		}
+	}
+	if name == "" {
+		return nil, p, ErrUnknownPrefix
+	}
+
+	b, err := b58decode(s)
+	if err != nil {
+		return nil, p, err
+	}
+	if len(b) != len(p.prefix)+p.length+4 {
+		return nil, p, ErrBadEncoding
+	}
+
+	body, sum := b[:len(b)-4], b[len(b)-4:]
+	if !bytes.Equal(checksum(body), sum) {
+		return nil, p, ErrBadChecksum
+	}
+	if !bytes.Equal(body[:len(p.prefix)], p.prefix) {
+		return nil, p, ErrUnknownPrefix
+	}
+
+	return body[len(p.prefix):], p, nil
+}
@@ -0,0 +1,61 @@
+package tezos
+
+import "context"
+import "fmt"
+import "net/http"
+import "time"
+
+import log "github.com/apex/log"
+
+// DefaultTimeout bounds the calls of the clients of this package, unless
+// they are given another http.Client.
+const DefaultTimeout = 10 * time.Second
+
+// Client looks wallets up through a Tezos API serving one JSON document per
+// wallet, such as https://check.tezos.com.
+type Client struct {
+	Endpoints *Endpoints
+	HTTP      *http.Client
+}
+
+// NewClient returns a Client failing over between the APIs at urls.
+func NewClient(urls ...string) *Client {
+	return &Client{
+		Endpoints: NewEndpoints(urls...),
+		HTTP:      &http.Client{Timeout: DefaultTimeout},
+	}
+}
+
+// WalletExists reports whether the API knows about wallet.
+func (c *Client) WalletExists(ctx context.Context, wallet string) (bool, error) {
+	var exists bool
+	err := c.Endpoints.Do(ctx, func(base string) error {
+		url := fmt.Sprintf("%v/%v.json", base, wallet)
+		log.FromContext(ctx).WithFields(log.Fields{
+			"wallet": wallet,
+			"url":    url,
+		}).Debug("fetching wallet")
+		resp, err := c.HTTP.Get(url)
+		if err != nil {
+			log.FromContext(ctx).WithError(err).WithField("url", url).Error("could not fetch wallet")
+			return err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode == http.StatusNotFound {
+			log.FromContext(ctx).Warn("wallet not found!")
+			exists = false
+			return nil
+		}
+
+		// 5xx and 429 are retried, any other status is a final error
+		if resp.StatusCode != http.StatusOK {
+			return statusError(url, resp)
+		}
+
+		log.FromContext(ctx).WithField("wallet", wallet).Debug("wallet fetched!")
+		exists = true
+		return nil
+	})
+	return exists, err
+}
@@ -0,0 +1,41 @@
+package tezos
+
+import "context"
+import "net/http"
+import "net/http/httptest"
+import "testing"
+
+func TestWalletExistsStatus(t *testing.T) {
+	var code int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(code)
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL)
+	c.Endpoints.Backoff = Backoff{MaxAttempts: 1}
+
+	for _, test := range []struct {
+		code      int
+		exists    bool
+		err       bool
+		temporary bool
+	}{
+		{code: http.StatusOK, exists: true},
+		{code: http.StatusNotFound},
+		{code: http.StatusTooManyRequests, err: true, temporary: true},
+		{code: http.StatusBadGateway, err: true, temporary: true},
+		{code: http.StatusForbidden, err: true},
+		{code: http.StatusNoContent, err: true},
+	} {
+		code = test.code
+		exists, err := c.WalletExists(context.Background(), vectors[0].address)
+		if exists != test.exists || (err != nil) != test.err {
+			t.Errorf("%v: got %v, %v, want %v and an error: %v", test.code, exists, err, test.exists, test.err)
+			continue
+		}
+		if err != nil && Temporary(err) != test.temporary {
+			t.Errorf("%v: got a temporary error: %v, want %v", test.code, Temporary(err), test.temporary)
+		}
+	}
+}
@@ -0,0 +1,4 @@
+// Package tezos talks to the Tezos side of the world: it decodes base58check
+// addresses, keys and signatures, verifies wallet signatures over Michelson
+// packed payloads and looks wallets up through a Tezos API.
+package tezos
@@ -0,0 +1,149 @@
+package tezos
+
+import "context"
+import "errors"
+import "sync"
+import "time"
+
+import log "github.com/apex/log"
+
+import "github.com/aaronwinter/tezosagora/pkg/metrics"
+
+// ErrNoEndpoints is returned by Endpoints.Do when no URL is configured.
+var ErrNoEndpoints = errors.New("no endpoints configured")
+
+// DefaultCooldown is how long a failing endpoint is skipped by default.
+const DefaultCooldown = 30 * time.Second
+
+// Endpoints is a list of interchangeable API base URLs. Calls go to the
+// first healthy endpoint and fail over to the next one on errors, the failing
+// endpoint being skipped for Cooldown. When every endpoint failed with a
+// temporary error, the whole round is retried according to Backoff.
+type Endpoints struct {
+	Cooldown time.Duration
+	Backoff  Backoff
+
+	mu        sync.Mutex
+	endpoints []*endpoint
+}
+
+type endpoint struct {
+	url       string
+	failures  int
+	downUntil time.Time
+}
+
+// EndpointStatus describes the health of one endpoint.
+type EndpointStatus struct {
+	URL      string `json:"url"`
+	Healthy  bool   `json:"healthy"`
+	Failures int    `json:"failures"`
+}
+
+// NewEndpoints returns Endpoints over urls, tried in order.
+func NewEndpoints(urls ...string) *Endpoints {
+	e := &Endpoints{Cooldown: DefaultCooldown, Backoff: DefaultBackoff}
+	for _, u := range urls {
+		e.endpoints = append(e.endpoints, &endpoint{url: u})
+	}
+	return e
+}
+
+// candidates returns healthy endpoints first, then the ones cooling down so
+// that a call is still attempted when everything looks down.
+func (e *Endpoints) candidates() []*endpoint {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := time.Now()
+	var healthy, down []*endpoint
+	for _, ep := range e.endpoints {
+		if now.Before(ep.downUntil) {
+			down = append(down, ep)
+		} else {
+			healthy = append(healthy, ep)
+		}
+	}
+	return append(healthy, down...)
+}
+
+func (e *Endpoints) report(ctx context.Context, ep *endpoint, err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err == nil {
+		if ep.failures > 0 {
+			log.FromContext(ctx).WithField("url", ep.url).Info("endpoint recovered")
+		}
+		ep.failures = 0
+		ep.downUntil = time.Time{}
+		return
+	}
+
+	ep.failures++
+	ep.downUntil = time.Now().Add(e.Cooldown)
+	log.FromContext(ctx).WithError(err).WithFields(log.Fields{
+		"url":      ep.url,
+		"failures": ep.failures,
+	}).Warn("endpoint failed, failing over")
+}
+
+// Do calls f with the base URL of each endpoint in turn until one succeeds,
+// and returns the last error if none does after all attempts.
+func (e *Endpoints) Do(ctx context.Context, f func(url string) error) error {
+	err := ErrNoEndpoints
+	for attempt := 1; ; attempt++ {
+		for _, ep := range e.candidates() {
+			start := time.Now()
+			err = f(ep.url)
+			metrics.TezosRequests.WithLabelValues(ep.url, metrics.Result(err)).Observe(metrics.Since(start))
+			if err != nil && !Temporary(err) {
+				// the endpoint answered, just not what we hoped for
+				e.report(ctx, ep, nil)
+				return err
+			}
+			e.report(ctx, ep, err)
+			if err == nil {
+				return nil
+			}
+		}
+
+		if attempt >= e.Backoff.MaxAttempts {
+			return err
+		}
+
+		delay := e.Backoff.Delay(attempt)
+		log.FromContext(ctx).WithError(err).WithFields(log.Fields{
+			"attempt": attempt,
+			"delay":   delay,
+		}).Warn("all endpoints failed, retrying")
+		time.Sleep(delay)
+	}
+}
+
+// Healthy reports whether any endpoint is healthy.
+func (e *Endpoints) Healthy() bool {
+	for _, status := range e.Status() {
+		if status.Healthy {
+			return true
+		}
+	}
+	return false
+}
+
+// Status returns the health of every endpoint.
+func (e *Endpoints) Status() []EndpointStatus {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := time.Now()
+	status := make([]EndpointStatus, len(e.endpoints))
+	for i, ep := range e.endpoints {
+		status[i] = EndpointStatus{
+			URL:      ep.url,
+			Healthy:  !now.Before(ep.downUntil),
+			Failures: ep.failures,
+		}
+	}
+	return status
+}
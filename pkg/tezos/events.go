@@ -0,0 +1,262 @@
+package tezos
+
+import "bytes"
+import "encoding/json"
+import "sync"
+import "time"
+
+import log "github.com/apex/log"
+import "github.com/gorilla/websocket"
+
+// SignalR messages are JSON documents terminated by a record separator.
+const recordSeparator = 0x1e
+
+const (
+	signalrInvocation = 1
+	signalrPing       = 6
+	signalrClose      = 7
+)
+
+// TzKT subscription messages carry state (0), data (1) or reorg (2).
+const tzktData = 1
+
+// stableConnection is how long a connection has to stay up for the
+// reconnection backoff to start over once it drops.
+const stableConnection = time.Minute
+
+type signalrMessage struct {
+	Type       int               `json:"type"`
+	Target     string            `json:"target,omitempty"`
+	Arguments  []json.RawMessage `json:"arguments,omitempty"`
+	Error      string            `json:"error,omitempty"`
+	Invocation string            `json:"invocationId,omitempty"`
+}
+
+type tzktEvent struct {
+	Type int `json:"type"`
+	Data []struct {
+		Address string `json:"address"`
+		Account *struct {
+			Address string `json:"address"`
+		} `json:"account"`
+	} `json:"data"`
+}
+
+// Events streams balance and token holding changes of a set of accounts
+// from the TzKT WebSocket API, e.g. wss://api.tzkt.io/v1/ws.
+type Events struct {
+	URL    string
+	Dialer *websocket.Dialer
+	// OnChange is called with the address of every account whose balance
+	// or token holdings changed.
+	OnChange func(address string)
+
+	mu   sync.Mutex
+	conn *websocket.Conn
+}
+
+// NewEvents returns Events connecting to url.
+func NewEvents(url string) *Events {
+	return &Events{
+		URL:    url,
+		Dialer: websocket.DefaultDialer,
+	}
+}
+
+func (e *Events) send(conn *websocket.Conn, msg interface{}) error {
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return conn.WriteMessage(websocket.TextMessage, append(b, recordSeparator))
+}
+
+func (e *Events) subscribe(conn *websocket.Conn, addresses ...string) error {
+	if len(addresses) == 0 {
+		return nil
+	}
+
+	err := e.send(conn, signalrMessage{
+		Type:      signalrInvocation,
+		Target:    "SubscribeToAccounts",
+		Arguments: []json.RawMessage{mustJSON(map[string][]string{"addresses": addresses})},
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, a := range addresses {
+		err = e.send(conn, signalrMessage{
+			Type:      signalrInvocation,
+			Target:    "SubscribeToTokenBalances",
+			Arguments: []json.RawMessage{mustJSON(map[string]string{"account": a})},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func mustJSON(v interface{}) json.RawMessage {
+	b, _ := json.Marshal(v)
+	return b
+}
+
+// Watch adds address to the live subscriptions. Addresses registered
+// while disconnected are picked up on reconnection through Run's addresses.
+func (e *Events) Watch(address string) {
+	e.mu.Lock()
+	conn := e.conn
+	e.mu.Unlock()
+
+	if conn == nil {
+		return
+	}
+
+	err := e.subscribe(conn, address)
+	if err != nil {
+		log.WithError(err).WithField("address", address).Error("could not subscribe to account")
+	}
+}
+
+// Run keeps a connection open until stop is closed, subscribing to the
+// accounts returned by addresses on every (re)connection.
+func (e *Events) Run(addresses func() ([]string, error), stop <-chan struct{}) {
+	backoff := DefaultBackoff
+	backoff.Max = time.Minute
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+		err := e.run(addresses, stop)
+
+		select {
+		case <-stop:
+			return
+		default:
+		}
+
+		if err == nil {
+			attempt = 0
+			continue
+		}
+
+		// a connection that held for a while starts the backoff over
+		if time.Since(start) >= stableConnection {
+			attempt = 1
+		}
+
+		delay := backoff.Delay(attempt)
+		log.WithError(err).WithField("delay", delay).Warn("tzkt events disconnected, reconnecting")
+		select {
+		case <-stop:
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+func (e *Events) run(addresses func() ([]string, error), stop <-chan struct{}) error {
+	conn, _, err := e.Dialer.Dial(e.URL, nil)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	err = e.send(conn, map[string]interface{}{"protocol": "json", "version": 1})
+	if err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-stop:
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	list, err := addresses()
+	if err != nil {
+		return err
+	}
+	err = e.subscribe(conn, list...)
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	e.conn = conn
+	e.mu.Unlock()
+	defer func() {
+		e.mu.Lock()
+		e.conn = nil
+		e.mu.Unlock()
+	}()
+
+	log.WithField("accounts", len(list)).Info("subscribed to tzkt events")
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+
+		for _, record := range bytes.Split(data, []byte{recordSeparator}) {
+			if len(record) == 0 {
+				continue
+			}
+			err = e.handle(record)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (e *Events) handle(record []byte) error {
+	var msg signalrMessage
+	err := json.Unmarshal(record, &msg)
+	if err != nil {
+		log.WithError(err).Warn("could not decode tzkt message")
+		return nil
+	}
+
+	switch msg.Type {
+	case signalrPing:
+		return nil
+	case signalrClose:
+		log.WithField("error", msg.Error).Warn("tzkt closed the connection")
+		return websocket.ErrCloseSent
+	case signalrInvocation:
+	default:
+		return nil
+	}
+
+	if msg.Target != "accounts" && msg.Target != "token_balances" {
+		return nil
+	}
+
+	for _, arg := range msg.Arguments {
+		var event tzktEvent
+		err := json.Unmarshal(arg, &event)
+		if err != nil || event.Type != tzktData {
+			continue
+		}
+
+		for _, d := range event.Data {
+			address := d.Address
+			if d.Account != nil {
+				address = d.Account.Address
+			}
+			if address != "" && e.OnChange != nil {
+				e.OnChange(address)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package tezos
+
+import "errors"
+import "fmt"
+import "math/rand"
+import "net/http"
+import "time"
+
+// StatusError is returned when an API answers with an unexpected status.
+type StatusError struct {
+	URL    string
+	Status string
+	Code   int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("tezos: %v returned %v", e.URL, e.Status)
+}
+
+func statusError(url string, resp *http.Response) error {
+	return &StatusError{URL: url, Status: resp.Status, Code: resp.StatusCode}
+}
+
+// Temporary reports whether err is worth retrying: transport errors, 5xx
+// and 429 are, other statuses are a final answer. It tells whether the
+// upstream is unavailable, as opposed to refusing the request.
+func Temporary(err error) bool {
+	var e *StatusError
+	if errors.As(err, &e) {
+		return e.Code >= http.StatusInternalServerError || e.Code == http.StatusTooManyRequests
+	}
+	return true
+}
+
+// Backoff is a retry policy with jittered exponential delays.
+type Backoff struct {
+	MaxAttempts int
+	Base        time.Duration
+	Max         time.Duration
+}
+
+// DefaultBackoff is the retry policy of new Endpoints.
+var DefaultBackoff = Backoff{
+	MaxAttempts: 3,
+	Base:        200 * time.Millisecond,
+	Max:         5 * time.Second,
+}
+
+// Delay returns how long to wait before retry number attempt, starting at 1.
+// It picks a random duration up to Base*2^(attempt-1), capped at Max.
+func (b Backoff) Delay(attempt int) time.Duration {
+	d := b.Base << uint(attempt-1)
+	if d <= 0 || d > b.Max {
+		d = b.Max
+	}
+	if d <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(d)))
+}
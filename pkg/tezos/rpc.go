@@ -0,0 +1,99 @@
+package tezos
+
+import "context"
+import "encoding/binary"
+import "fmt"
+import "net/http"
+
+import log "github.com/apex/log"
+import "golang.org/x/crypto/blake2b"
+
+var exprPrefix = []byte{13, 44, 64, 27}
+
+// RPC queries a Tezos node RPC, e.g. https://mainnet.api.tez.ie.
+type RPC struct {
+	Endpoints *Endpoints
+	HTTP      *http.Client
+}
+
+// NewRPC returns an RPC client failing over between the nodes at urls.
+func NewRPC(urls ...string) *RPC {
+	return &RPC{
+		Endpoints: NewEndpoints(urls...),
+		HTTP:      &http.Client{Timeout: DefaultTimeout},
+	}
+}
+
+func packBytes(b []byte) []byte {
+	packed := []byte{packPrefix, packTagBytes, 0, 0, 0, 0}
+	binary.BigEndian.PutUint32(packed[2:], uint32(len(b)))
+	return append(packed, b...)
+}
+
+// PackKeyHash returns the Michelson PACK encoding of a tz1, tz2 or tz3
+// address typed as key_hash.
+func PackKeyHash(address string) ([]byte, error) {
+	hash, p, err := b58checkDecode(address, addressPrefixes)
+	if err != nil {
+		return nil, err
+	}
+
+	return packBytes(append([]byte{byte(p.curve)}, hash...)), nil
+}
+
+// PackAddress returns the Michelson PACK encoding of an implicit or
+// originated address typed as address.
+func PackAddress(address string) ([]byte, error) {
+	hash, p, err := b58checkDecode(address, addressPrefixes)
+	if err == nil {
+		return packBytes(append([]byte{0, byte(p.curve)}, hash...)), nil
+	}
+
+	hash, _, err = b58checkDecode(address, contractPrefixes)
+	if err != nil {
+		return nil, err
+	}
+
+	b := append([]byte{1}, hash...)
+	return packBytes(append(b, 0)), nil
+}
+
+// ScriptExprHash returns the expr... hash under which a packed value is
+// indexed in big maps.
+func ScriptExprHash(packed []byte) string {
+	sum := blake2b.Sum256(packed)
+	return b58checkEncode(exprPrefix, sum[:])
+}
+
+// BigMapHas reports whether the big map with the given id holds packed as
+// a key at level, or at head when level is 0.
+func (r *RPC) BigMapHas(ctx context.Context, id int64, packed []byte, level int64) (bool, error) {
+	block := "head"
+	if level > 0 {
+		block = fmt.Sprint(level)
+	}
+
+	var found bool
+	err := r.Endpoints.Do(ctx, func(base string) error {
+		url := fmt.Sprintf("%v/chains/main/blocks/%v/context/big_maps/%v/%v", base, block, id, ScriptExprHash(packed))
+		log.FromContext(ctx).WithField("url", url).Debug("fetching big map value")
+		resp, err := r.HTTP.Get(url)
+		if err != nil {
+			log.FromContext(ctx).WithError(err).WithField("url", url).Error("could not fetch big map value")
+			return err
+		}
+		defer resp.Body.Close()
+
+		switch resp.StatusCode {
+		case http.StatusOK:
+			found = true
+			return nil
+		case http.StatusNotFound:
+			found = false
+			return nil
+		}
+
+		return statusError(url, resp)
+	})
+	return found, err
+}
@@ -0,0 +1,164 @@
+package tezos
+
+import "crypto/ecdsa"
+import "crypto/ed25519"
+import "crypto/elliptic"
+import "encoding/binary"
+import "encoding/hex"
+import "errors"
+import "math/big"
+import "strings"
+
+import "github.com/decred/dcrd/dcrec/secp256k1/v4"
+import secp256k1ecdsa "github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+import "golang.org/x/crypto/blake2b"
+
+// Michelson PACK tags, see TZIP-17 and the Micheline binary encoding.
+const (
+	packPrefix    = 0x05
+	packTagString = 0x01
+	packTagBytes  = 0x0a
+)
+
+type curve int
+
+const (
+	curveEd25519 curve = iota
+	curveSecp256k1
+	curveP256
+)
+
+type b58prefix struct {
+	prefix []byte
+	length int
+	curve  curve
+}
+
+var (
+	addressPrefixes = map[string]b58prefix{
+		"tz1": {[]byte{6, 161, 159}, 20, curveEd25519},
+		"tz2": {[]byte{6, 161, 161}, 20, curveSecp256k1},
+		"tz3": {[]byte{6, 161, 164}, 20, curveP256},
+	}
+
+	contractPrefixes = map[string]b58prefix{
+		"KT1": {[]byte{2, 90, 121}, 20, -1},
+	}
+
+	publicKeyPrefixes = map[string]b58prefix{
+		"edpk": {[]byte{13, 15, 37, 217}, 32, curveEd25519},
+		"sppk": {[]byte{3, 254, 226, 86}, 33, curveSecp256k1},
+		"p2pk": {[]byte{3, 178, 139, 127}, 33, curveP256},
+	}
+
+	signaturePrefixes = map[string]b58prefix{
+		"edsig":  {[]byte{9, 245, 205, 134, 18}, 64, curveEd25519},
+		"spsig1": {[]byte{13, 115, 101, 19, 63}, 64, curveSecp256k1},
+		"p2sig":  {[]byte{54, 240, 44, 52}, 64, curveP256},
+		"sig":    {[]byte{4, 130, 43}, 64, -1},
+	}
+)
+
+// Errors returned when decoding or verifying keys, signatures and payloads.
+var (
+	ErrBadChecksum     = errors.New("bad base58 checksum")
+	ErrBadEncoding     = errors.New("bad base58 encoding")
+	ErrUnknownPrefix   = errors.New("unknown prefix")
+	ErrCurveMismatch   = errors.New("public key and signature curves differ")
+	ErrAddressMismatch = errors.New("public key does not match address")
+	ErrBadPayload      = errors.New("payload is not a packed michelson string or bytes")
+)
+
+// PackString returns the Michelson PACK encoding of a string literal, which
+// is what wallets sign when asked for a MICHELINE signing type.
+func PackString(s string) []byte {
+	b := []byte{packPrefix, packTagString, 0, 0, 0, 0}
+	binary.BigEndian.PutUint32(b[2:], uint32(len(s)))
+	return append(b, s...)
+}
+
+// UnpackPayload accepts either a hex encoded packed payload as produced by
+// wallets or a plain text message, and returns the bytes that were signed
+// along with the human readable message.
+func UnpackPayload(payload string) ([]byte, string, error) {
+	raw, err := hex.DecodeString(strings.TrimPrefix(payload, "0x"))
+	if err != nil || len(raw) == 0 || raw[0] != packPrefix {
+		return PackString(payload), payload, nil
+	}
+
+	if len(raw) < 6 || (raw[1] != packTagString && raw[1] != packTagBytes) {
+		return nil, "", ErrBadPayload
+	}
+	length := binary.BigEndian.Uint32(raw[2:6])
+	if int(length) != len(raw)-6 {
+		return nil, "", ErrBadPayload
+	}
+
+	return raw, string(raw[6:]), nil
+}
+
+// publicKeyHash returns the tz1, tz2 or tz3 address of a raw public key.
+func publicKeyHash(pk []byte, c curve) string {
+	h, _ := blake2b.New(20, nil)
+	h.Write(pk)
+	for _, p := range addressPrefixes {
+		if p.curve == c {
+			return b58checkEncode(p.prefix, h.Sum(nil))
+		}
+	}
+	return ""
+}
+
+// VerifySignature checks that signature is a valid signature of the packed
+// payload by publicKey, and that publicKey hashes to address. The payload is
+// either the hex encoded bytes handed to the wallet or the plain message.
+func VerifySignature(address, publicKey, signature, payload string) (bool, error) {
+	pk, pkp, err := b58checkDecode(publicKey, publicKeyPrefixes)
+	if err != nil {
+		return false, err
+	}
+
+	if publicKeyHash(pk, pkp.curve) != address {
+		return false, ErrAddressMismatch
+	}
+
+	sig, sigp, err := b58checkDecode(signature, signaturePrefixes)
+	if err != nil {
+		return false, err
+	}
+	if sigp.curve >= 0 && sigp.curve != pkp.curve {
+		return false, ErrCurveMismatch
+	}
+
+	signed, _, err := UnpackPayload(payload)
+	if err != nil {
+		return false, err
+	}
+	digest := blake2b.Sum256(signed)
+
+	switch pkp.curve {
+	case curveEd25519:
+		return ed25519.Verify(ed25519.PublicKey(pk), digest[:], sig), nil
+	case curveSecp256k1:
+		key, err := secp256k1.ParsePubKey(pk)
+		if err != nil {
+			return false, err
+		}
+		var r, s secp256k1.ModNScalar
+		if r.SetByteSlice(sig[:32]) || s.SetByteSlice(sig[32:]) {
+			return false, nil
+		}
+		return secp256k1ecdsa.NewSignature(&r, &s).Verify(digest[:], key), nil
+	case curveP256:
+		x, y := elliptic.UnmarshalCompressed(elliptic.P256(), pk)
+		if x == nil {
+			return false, ErrBadEncoding
+		}
+		key := ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
+		r := new(big.Int).SetBytes(sig[:32])
+		s := new(big.Int).SetBytes(sig[32:])
+		return ecdsa.Verify(&key, digest[:], r, s), nil
+	}
+
+	return false, ErrUnknownPrefix
+}
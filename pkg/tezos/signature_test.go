@@ -0,0 +1,78 @@
+package tezos
+
+import "testing"
+
+// payload is the packed "Tezos Signed Message: agora.example wants you to
+// sign in with your Tezos account", as wallets hand it out.
+const payload = "05010000005054657a6f73205369676e6564204d6573736167653a2061676f72612e6578616d706c652077616e747320796f7520746f207369676e20696e207769746820796f75722054657a6f73206163636f756e74"
+
+// vectors are signatures of payload. The ed25519 key is the first bootstrap
+// account of the Tezos sandbox.
+var vectors = []struct {
+	name      string
+	address   string
+	publicKey string
+	signature string
+}{
+	{
+		name:      "ed25519",
+		address:   "tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx",
+		publicKey: "edpkuBknW28nW72KG6RoHtYW7p12T6GKc7nAbwYX5m8Wd9sDVC9yav",
+		signature: "edsigtckN6BGGvTHquXDxDgA8jPUpn588mk7kdMj26568ht8LZC8c3tKqLUyH2HxcvYrQ3je9PoT32EzM2mGhPd8JCdtLBPQyhe",
+	},
+	{
+		name:      "secp256k1",
+		address:   "tz29EVstU72wBE6ELK2CEk2BGhH9fjNP3LxR",
+		publicKey: "sppk7aED8APwqptofXbCwATuaoQNC9m1oAJf9fbYXiGL5uKm2PQLD1J",
+		signature: "spsig1RRyvJqKdQk9c1mnfGgsRpwvaME21zGBoUqv6LrPxgmEYPaXJJBp45ZzxJoWMto4Pq3XfxnBbF6SMEJypLduDqgZk6nA2u",
+	},
+	{
+		name:      "p256",
+		address:   "tz3QFxdvQcMvL4MwZ4WZwYQkzjnoFoDALpdC",
+		publicKey: "p2pk67GSfLZUxjfCaUyD1hJYJAKYVsVWBRAQ1MnmZPyq4XgcQAqcovr",
+		signature: "p2sigoLPNUZ6BgjZfAYnCdGZJ77Z64K5VDitJHfdusSV8vWQodRtHmpB3JB8XVyDBWQWQDKoZjwaogp1BTaTba1gFXPcxJThZ8",
+	},
+}
+
+func TestVerifySignature(t *testing.T) {
+	for _, v := range vectors {
+		valid, err := VerifySignature(v.address, v.publicKey, v.signature, payload)
+		if err != nil || !valid {
+			t.Errorf("%v: got %v, %v, want a valid signature", v.name, valid, err)
+		}
+
+		// the same message, unpacked
+		_, message, _ := UnpackPayload(payload)
+		valid, err = VerifySignature(v.address, v.publicKey, v.signature, message)
+		if err != nil || !valid {
+			t.Errorf("%v: got %v, %v for the plain message, want a valid signature", v.name, valid, err)
+		}
+
+		valid, err = VerifySignature(v.address, v.publicKey, v.signature, message+".")
+		if err != nil || valid {
+			t.Errorf("%v: got %v, %v for another message, want an invalid signature", v.name, valid, err)
+		}
+	}
+}
+
+func TestVerifySignatureMismatch(t *testing.T) {
+	ed, sp := vectors[0], vectors[1]
+
+	_, err := VerifySignature(sp.address, ed.publicKey, ed.signature, payload)
+	if err != ErrAddressMismatch {
+		t.Errorf("got %v for the key of another address, want %v", err, ErrAddressMismatch)
+	}
+
+	_, err = VerifySignature(ed.address, ed.publicKey, sp.signature, payload)
+	if err != ErrCurveMismatch {
+		t.Errorf("got %v for a signature on another curve, want %v", err, ErrCurveMismatch)
+	}
+
+	// a flipped character breaks the checksum
+	signature := []byte(ed.signature)
+	signature[20] ^= 1
+	_, err = VerifySignature(ed.address, ed.publicKey, string(signature), payload)
+	if err == nil {
+		t.Error("got no error for a corrupted signature")
+	}
+}
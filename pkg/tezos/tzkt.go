@@ -0,0 +1,188 @@
+package tezos
+
+import "context"
+import "encoding/json"
+import "fmt"
+import "math/big"
+import "net/http"
+import "net/url"
+import "time"
+
+import log "github.com/apex/log"
+
+import "github.com/aaronwinter/tezosagora/pkg/cache"
+
+// TzKT queries a TzKT indexer API, e.g. https://api.tzkt.io.
+type TzKT struct {
+	Endpoints *Endpoints
+	HTTP      *http.Client
+	// Cache, when set, keeps accounts around so that rules looking at the
+	// same wallet share a single lookup.
+	Cache *cache.Cache
+}
+
+// NewTzKT returns a TzKT client failing over between the APIs at urls.
+func NewTzKT(urls ...string) *TzKT {
+	return &TzKT{
+		Endpoints: NewEndpoints(urls...),
+		HTTP:      &http.Client{Timeout: DefaultTimeout},
+	}
+}
+
+func (t *TzKT) get(ctx context.Context, path string, query url.Values, v interface{}) error {
+	return t.Endpoints.Do(ctx, func(base string) error {
+		u := fmt.Sprintf("%v%v", base, path)
+		if len(query) > 0 {
+			u = fmt.Sprintf("%v?%v", u, query.Encode())
+		}
+
+		resp, err := t.HTTP.Get(u)
+		if err != nil {
+			log.FromContext(ctx).WithError(err).WithField("url", u).Error("could not query tzkt")
+			return err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return statusError(u, resp)
+		}
+
+		return json.NewDecoder(resp.Body).Decode(v)
+	})
+}
+
+// Account is the subset of a TzKT account used by the gating rules.
+type Account struct {
+	Address           string    `json:"address"`
+	Type              string    `json:"type"`
+	Balance           int64     `json:"balance"`
+	FirstActivityTime time.Time `json:"firstActivityTime"`
+	Delegate          *struct {
+		Address string `json:"address"`
+	} `json:"delegate"`
+}
+
+// IsBaker reports whether the account is registered as a delegate.
+func (a *Account) IsBaker() bool {
+	return a.Type == "delegate"
+}
+
+// Account returns the account at address. Unknown addresses come back as
+// accounts of type "empty".
+func (t *TzKT) Account(ctx context.Context, address string) (*Account, error) {
+	if t.Cache != nil {
+		if v, ok := t.Cache.Get(address); ok {
+			return v.(*Account), nil
+		}
+	}
+
+	var account Account
+	err := t.get(ctx, fmt.Sprintf("/v1/accounts/%v", address), nil, &account)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.Cache != nil {
+		t.Cache.Set(address, &account)
+	}
+	return &account, nil
+}
+
+// BalanceAt returns the balance of address in mutez at level, or at head
+// when level is 0.
+func (t *TzKT) BalanceAt(ctx context.Context, address string, level int64) (int64, error) {
+	if level == 0 {
+		account, err := t.Account(ctx, address)
+		if err != nil {
+			return 0, err
+		}
+		return account.Balance, nil
+	}
+
+	var balance int64
+	err := t.get(ctx, fmt.Sprintf("/v1/accounts/%v/balance_history/%v", address, level), nil, &balance)
+	return balance, err
+}
+
+// TokenBalance returns how many tokens of contract owner holds at level, or
+// at head when level is 0. An empty tokenID sums the balances of every token
+// of the contract.
+func (t *TzKT) TokenBalance(ctx context.Context, owner, contract, tokenID string, level int64) (*big.Int, error) {
+	path := "/v1/tokens/balances"
+	if level > 0 {
+		path = fmt.Sprintf("/v1/tokens/historical_balances/%v", level)
+	}
+
+	query := url.Values{}
+	query.Set("account", owner)
+	query.Set("token.contract", contract)
+	if tokenID != "" {
+		query.Set("token.tokenId", tokenID)
+	}
+	query.Set("balance.gt", "0")
+	query.Set("select", "balance")
+
+	var balances []string
+	err := t.get(ctx, path, query, &balances)
+	if err != nil {
+		return nil, err
+	}
+
+	total := new(big.Int)
+	for _, b := range balances {
+		n, ok := new(big.Int).SetString(b, 10)
+		if !ok {
+			return nil, fmt.Errorf("tzkt: bad token balance %q", b)
+		}
+		total.Add(total, n)
+	}
+	return total, nil
+}
+
+// Head returns the level of the current head block.
+func (t *TzKT) Head(ctx context.Context) (int64, error) {
+	var head struct {
+		Level int64 `json:"level"`
+	}
+	err := t.get(ctx, "/v1/head", nil, &head)
+	return head.Level, err
+}
+
+// Domain returns the Tezos domain, such as alice.tez, that address reverse
+// resolves to, or an empty string if it has none.
+func (t *TzKT) Domain(ctx context.Context, address string) (string, error) {
+	query := url.Values{}
+	query.Set("address", address)
+	query.Set("reverse", "true")
+	query.Set("select", "name")
+	query.Set("limit", "1")
+
+	var names []string
+	err := t.get(ctx, "/v1/domains", query, &names)
+	if err != nil || len(names) == 0 {
+		return "", err
+	}
+
+	return names[0], nil
+}
+
+// FindTransfer returns the level of the first applied transaction of amount
+// mutez from sender to target made after since, or 0 if there is none.
+func (t *TzKT) FindTransfer(ctx context.Context, sender, target string, amount int64, since time.Time) (int64, error) {
+	query := url.Values{}
+	query.Set("sender", sender)
+	query.Set("target", target)
+	query.Set("amount", fmt.Sprint(amount))
+	query.Set("status", "applied")
+	query.Set("timestamp.ge", since.UTC().Format(time.RFC3339))
+	query.Set("select", "level")
+	query.Set("limit", "1")
+
+	var levels []int64
+	err := t.get(ctx, "/v1/operations/transactions", query, &levels)
+	if err != nil || len(levels) == 0 {
+		return 0, err
+	}
+
+	return levels[0], nil
+}
@@ -0,0 +1,114 @@
+package tezos
+
+import "bytes"
+import "context"
+import "encoding/json"
+import "fmt"
+import "net/http"
+import "sync"
+
+import log "github.com/apex/log"
+
+// Micheline is a Michelson value in its JSON representation. Sequences are
+// not supported.
+type Micheline struct {
+	Prim   string      `json:"prim,omitempty"`
+	Int    string      `json:"int,omitempty"`
+	String string      `json:"string,omitempty"`
+	Bytes  string      `json:"bytes,omitempty"`
+	Args   []Micheline `json:"args,omitempty"`
+}
+
+// Truthy reports whether m reads as a yes: True, Some, or a non-zero int.
+func (m Micheline) Truthy() bool {
+	switch {
+	case m.Prim == "True" || m.Prim == "Some":
+		return true
+	case m.Int != "":
+		return m.Int != "0"
+	}
+	return false
+}
+
+var chainIDs sync.Map
+
+// chainID returns the chain id of the node at base, which view calls need.
+func (r *RPC) chainID(ctx context.Context, base string) (string, error) {
+	if id, ok := chainIDs.Load(base); ok {
+		return id.(string), nil
+	}
+
+	url := base + "/chains/main/chain_id"
+	resp, err := r.HTTP.Get(url)
+	if err != nil {
+		log.FromContext(ctx).WithError(err).WithField("url", url).Error("could not fetch chain id")
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", statusError(url, resp)
+	}
+
+	var id string
+	err = json.NewDecoder(resp.Body).Decode(&id)
+	if err != nil {
+		return "", err
+	}
+
+	chainIDs.Store(base, id)
+	return id, nil
+}
+
+// RunView calls the on-chain view of contract with input at level, or at
+// head when level is 0, and returns its result.
+func (r *RPC) RunView(ctx context.Context, contract, view string, input Micheline, level int64) (Micheline, error) {
+	block := "head"
+	if level > 0 {
+		block = fmt.Sprint(level)
+	}
+
+	var result Micheline
+	err := r.Endpoints.Do(ctx, func(base string) error {
+		chainID, err := r.chainID(ctx, base)
+		if err != nil {
+			return err
+		}
+
+		body, err := json.Marshal(map[string]interface{}{
+			"contract":       contract,
+			"view":           view,
+			"input":          input,
+			"chain_id":       chainID,
+			"unlimited_gas":  true,
+			"unparsing_mode": "Readable",
+		})
+		if err != nil {
+			return err
+		}
+
+		url := fmt.Sprintf("%v/chains/main/blocks/%v/helpers/scripts/run_script_view", base, block)
+		log.FromContext(ctx).WithFields(log.Fields{
+			"url":  url,
+			"view": view,
+		}).Debug("running view")
+		resp, err := r.HTTP.Post(url, "application/json", bytes.NewReader(body))
+		if err != nil {
+			log.FromContext(ctx).WithError(err).WithField("url", url).Error("could not run view")
+			return err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return statusError(url, resp)
+		}
+
+		var data struct {
+			Data Micheline `json:"data"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&data)
+		result = data.Data
+		return err
+	})
+	return result, err
+}
@@ -0,0 +1,96 @@
+// Package ticket signs and checks one-time verification tickets, which tie
+// the wallet verified on the web to a Discord user or a campaign without
+// being recorded until used.
+package ticket
+
+import "crypto/hmac"
+import "crypto/rand"
+import "crypto/sha256"
+import "encoding/base64"
+import "encoding/hex"
+import "encoding/json"
+import "errors"
+import "strings"
+import "time"
+
+// ErrInvalid is returned by Check for tickets that weren't signed with the
+// key of the Signer, or expired.
+var ErrInvalid = errors.New("ticket: invalid or expired ticket")
+
+// Claims are what a ticket binds the verification to.
+type Claims struct {
+	// ID tells tickets apart, for each to be used once.
+	ID string `json:"id"`
+	// UserID, if set, is the Discord user the registration gets tied to.
+	UserID string `json:"user,omitempty"`
+	// Campaign, if set, is recorded with the registration.
+	Campaign string    `json:"campaign,omitempty"`
+	Expires  time.Time `json:"expires"`
+}
+
+// Signer issues tickets valid for TTL and checks them. Whether a ticket was
+// used already is left to the caller, who has to record the ID of the ones
+// used until they expire.
+type Signer struct {
+	TTL time.Duration
+
+	key []byte
+}
+
+// New returns a Signer of tickets with key, which has to be kept secret and
+// shared by the instances checking the tickets.
+func New(key []byte, ttl time.Duration) *Signer {
+	return &Signer{TTL: ttl, key: key}
+}
+
+func (s *Signer) sign(payload string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Issue returns a ticket for userID and campaign, either of which may be
+// empty, valid for ttl, or TTL when it is 0.
+func (s *Signer) Issue(userID, campaign string, ttl time.Duration) (string, Claims) {
+	if ttl == 0 {
+		ttl = s.TTL
+	}
+	id := make([]byte, 16)
+	rand.Read(id)
+	claims := Claims{
+		ID:       hex.EncodeToString(id),
+		UserID:   userID,
+		Campaign: campaign,
+		Expires:  time.Now().Add(ttl).UTC().Truncate(time.Second),
+	}
+
+	b, _ := json.Marshal(claims)
+	payload := base64.RawURLEncoding.EncodeToString(b)
+	return payload + "." + s.sign(payload), claims
+}
+
+// Check returns the claims of token, or ErrInvalid if it wasn't issued by a
+// Signer with the same key or expired.
+func (s *Signer) Check(token string) (Claims, error) {
+	var claims Claims
+	dot := strings.LastIndex(token, ".")
+	if dot < 0 || !hmac.Equal([]byte(token[dot+1:]), []byte(s.sign(token[:dot]))) {
+		return claims, ErrInvalid
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(token[:dot])
+	if err != nil {
+		return claims, ErrInvalid
+	}
+	err = json.Unmarshal(b, &claims)
+	if err != nil || claims.ID == "" || time.Now().After(claims.Expires) {
+		return claims, ErrInvalid
+	}
+	return claims, nil
+}
+
+// Signed reports whether token looks like a ticket issued by a Signer rather
+// than a random one.
+func Signed(token string) bool {
+	return strings.Contains(token, ".")
+}
@@ -0,0 +1,4 @@
+// Package verify implements the registration pipeline: it checks a submitted
+// wallet against the store, its signature and the gating rules, then hands
+// out and records a Discord invite.
+package verify
@@ -0,0 +1,595 @@
+package verify
+
+import "context"
+import "fmt"
+import "sync/atomic"
+import "time"
+
+import log "github.com/apex/log"
+
+import "github.com/aaronwinter/tezosagora/pkg/blocklist"
+import "github.com/aaronwinter/tezosagora/pkg/breaker"
+import "github.com/aaronwinter/tezosagora/pkg/discord"
+import "github.com/aaronwinter/tezosagora/pkg/etherlink"
+import "github.com/aaronwinter/tezosagora/pkg/metrics"
+import "github.com/aaronwinter/tezosagora/pkg/ratelimit"
+import "github.com/aaronwinter/tezosagora/pkg/rules"
+import "github.com/aaronwinter/tezosagora/pkg/siwt"
+import "github.com/aaronwinter/tezosagora/pkg/store"
+import "github.com/aaronwinter/tezosagora/pkg/tezos"
+
+// Outcome is the result of a registration attempt that did not fail
+// internally.
+type Outcome int
+
+// Possible outcomes of Verifier.Verify.
+const (
+	Registered Outcome = iota
+	AlreadyRegistered
+	BadInput
+	InvalidSignature
+	NotEligible
+	Unavailable
+	Blocked
+	NotRegistered
+	Linked
+	Unlinked
+	TooManyAttempts
+)
+
+var outcomeNames = [...]string{
+	Registered:        "registered",
+	AlreadyRegistered: "already_registered",
+	BadInput:          "bad_input",
+	InvalidSignature:  "invalid_signature",
+	NotEligible:       "not_eligible",
+	Unavailable:       "unavailable",
+	Blocked:           "blocked",
+	NotRegistered:     "not_registered",
+	Linked:            "linked",
+	Unlinked:          "unlinked",
+	TooManyAttempts:   "too_many_attempts",
+}
+
+// String returns the snake case name of o, which the versioned web API and
+// the metrics expose, so names must stay stable.
+func (o Outcome) String() string {
+	if o < 0 || int(o) >= len(outcomeNames) {
+		return fmt.Sprintf("outcome(%d)", int(o))
+	}
+	return outcomeNames[o]
+}
+
+// Request is a registration attempt for Address. PublicKey, Signature and
+// Message are only checked when the Verifier requires signatures, in which
+// case Message is the signed payload.
+type Request struct {
+	Address   string
+	PublicKey string
+	Signature string
+	Message   string
+}
+
+// Result carries the outcome of a registration, the registered wallet, the
+// invite URL and the gating report, if any.
+type Result struct {
+	Outcome Outcome
+	Wallet  string
+	Invite  string
+	Report  *rules.Report
+	// RetryAfter hints when to come back after an Unavailable or
+	// TooManyAttempts outcome.
+	RetryAfter time.Duration
+}
+
+// PausedRetryAfter is the RetryAfter of the Unavailable outcomes while the
+// Verifier is paused.
+const PausedRetryAfter = 5 * time.Minute
+
+// BusyRetryAfter is the RetryAfter of the Unavailable outcomes while all
+// the Slots are taken.
+const BusyRetryAfter = 10 * time.Second
+
+// Verifier runs registration requests through the pipeline.
+type Verifier struct {
+	Store   *store.Store
+	Rule    rules.Rule
+	Inviter *discord.Inviter
+
+	// RequireSignature is only honoured along with SIWT: signatures of
+	// messages which aren't bound to this service could be replayed, so
+	// they are all rejected without it.
+	RequireSignature bool
+	// Etherlink accepts Etherlink (EVM) addresses alongside Tezos ones,
+	// signed with EIP-191 personal_sign.
+	Etherlink bool
+	// SIWT, when set, requires the signed payload to be a Sign-In with Tezos
+	// message for this service. It implies RequireSignature.
+	SIWT *siwt.Verifier
+	// Blocklist, when set, rejects the addresses it blocks.
+	Blocklist blocklist.Checker
+	// Channels, when set, sends wallets to the channel of the first tier
+	// they qualify for rather than to the default channel of Inviter.
+	Channels []rules.Tier
+	// Invites, when set, hands out the invite code of the first tier
+	// wallets qualify for, such as a vanity URL, rather than creating one.
+	// It takes precedence over Channels.
+	Invites []rules.Tier
+	// ReissueInvites hands out a fresh invite to registered wallets whose
+	// invite died unused, as far as known from Registration.Redeemed.
+	ReissueInvites bool
+	// Attempts, when set, caps how often the gating rules may be evaluated
+	// for an unregistered address, beyond which Register yields
+	// TooManyAttempts.
+	Attempts *ratelimit.Limiter
+	// Breaker, when set, guards the gating rules so that an unavailable
+	// upstream yields Unavailable right away instead of slow failures.
+	Breaker *breaker.Breaker
+	// Paused, when set and true, holds off invites for maintenance:
+	// Register yields Unavailable for wallets not registered yet. It is
+	// shared with the copies of the Verifier.
+	Paused *atomic.Bool
+	// Slots, when set, caps the registrations evaluating the gating rules
+	// at once to its capacity: beyond it, Register yields Unavailable
+	// rather than piling up against a slow upstream. It is shared with the
+	// copies of the Verifier.
+	Slots chan struct{}
+
+	// OnRegistered, when set, is called after a wallet got registered.
+	OnRegistered func(wallet, inviteURL string)
+	// OnRejected, when set, is called when wallet failed verification with
+	// outcome InvalidSignature, Blocked or NotEligible.
+	OnRejected func(wallet string, outcome Outcome)
+	// OnLinked, when set, is called after linked got linked to wallet.
+	OnLinked func(wallet, linked string)
+}
+
+// ValidAddress reports whether address has the shape of an address the
+// Verifier accepts.
+func (v *Verifier) ValidAddress(address string) bool {
+	return len(address) == 36 || (v.Etherlink && etherlink.ValidAddress(address))
+}
+
+// SignatureRequired reports whether requests must carry a signature.
+func (v *Verifier) SignatureRequired() bool {
+	return v.RequireSignature || v.SIWT != nil
+}
+
+// Verify processes req. The returned error is only set when something went
+// wrong internally, rejections are reported through Result.Outcome.
+// Everything logged on the way goes through the logger of ctx, which carries
+// the ID of the web request for instance.
+func (v *Verifier) Verify(ctx context.Context, req Request) (Result, error) {
+	result, err := v.verify(ctx, req)
+	outcome := result.Outcome.String()
+	if err != nil {
+		outcome = "error"
+	}
+	metrics.Verifications.WithLabelValues(outcome).Inc()
+	return result, err
+}
+
+func (v *Verifier) verify(ctx context.Context, req Request) (Result, error) {
+	address, outcome, ok := v.authenticate(ctx, req)
+	if !ok {
+		if outcome == InvalidSignature {
+			v.rejected(req.Address, outcome)
+		}
+		return Result{Outcome: outcome}, nil
+	}
+
+	return v.Register(ctx, address)
+}
+
+func (v *Verifier) rejected(wallet string, outcome Outcome) {
+	if v.OnRejected != nil {
+		v.OnRejected(wallet, outcome)
+	}
+}
+
+// authenticate checks that the sender of req controls its address, as far
+// as the Verifier requires, and returns the normalized address.
+func (v *Verifier) authenticate(ctx context.Context, req Request) (string, Outcome, bool) {
+	address := req.Address
+	if !v.ValidAddress(address) {
+		return "", BadInput, false
+	}
+
+	log.FromContext(ctx).Debug("valid address length")
+
+	evm := etherlink.ValidAddress(address)
+	if evm {
+		address = etherlink.Normalize(address)
+	}
+
+	if v.SignatureRequired() && v.SIWT == nil {
+		log.FromContext(ctx).WithField("wallet", address).Error("rejected signature, SIWT is needed to bind it")
+		return "", InvalidSignature, false
+	}
+
+	if v.SignatureRequired() {
+		var valid bool
+		var err error
+		if evm {
+			valid, err = etherlink.VerifySignature(address, req.Signature, req.Message)
+		} else {
+			valid, err = tezos.VerifySignature(address, req.PublicKey, req.Signature, req.Message)
+		}
+		if err != nil {
+			log.FromContext(ctx).WithError(err).WithField("wallet", address).Warn("could not verify signature")
+		}
+
+		if !valid {
+			return "", InvalidSignature, false
+		}
+
+		log.FromContext(ctx).WithField("wallet", address).Debug("valid signature")
+	}
+
+	if v.SIWT != nil {
+		message := req.Message
+		if !evm {
+			var err error
+			_, message, err = tezos.UnpackPayload(req.Message)
+			if err != nil {
+				return "", InvalidSignature, false
+			}
+		}
+
+		_, err := v.SIWT.Check(address, message)
+		if err != nil {
+			log.FromContext(ctx).WithError(err).WithField("wallet", address).Warn("rejected SIWT message")
+			return "", InvalidSignature, false
+		}
+
+		log.FromContext(ctx).WithField("wallet", address).Debug("valid SIWT message")
+	}
+
+	return address, Registered, true
+}
+
+// blocked reports whether the blocklist rejects address.
+func (v *Verifier) blocked(ctx context.Context, address string) (bool, error) {
+	if v.Blocklist == nil {
+		return false, nil
+	}
+
+	reason, blocked, err := v.Blocklist.Blocked(address)
+	if err != nil {
+		log.FromContext(ctx).WithError(err).Error("could not check blocklist")
+		return false, err
+	}
+
+	if blocked {
+		log.FromContext(ctx).WithFields(log.Fields{
+			"wallet": address,
+			"reason": reason,
+		}).Warn("rejected blocked wallet")
+	}
+	return blocked, nil
+}
+
+// Register runs the gating rules for a wallet whose ownership was already
+// proven by other means, such as a payment, and hands out its invite.
+func (v *Verifier) Register(ctx context.Context, address string) (Result, error) {
+	blocked, err := v.blocked(ctx, address)
+	if err != nil {
+		return Result{}, err
+	}
+	if blocked {
+		v.rejected(address, Blocked)
+		return Result{Outcome: Blocked}, nil
+	}
+
+	log.FromContext(ctx).WithField("wallet", address).Debug("checking if wallet is already registered")
+
+	registered, err := v.Store.IsRegistered(address)
+	if err != nil {
+		log.FromContext(ctx).WithError(err).Error("could not check registration")
+		return Result{}, err
+	}
+
+	if registered {
+		log.FromContext(ctx).WithField("wallet", address).Debug("wallet already registered")
+		inviteURL, err := v.invite(ctx, address)
+		if limit, ok := err.(*discord.RateLimited); ok {
+			return Result{Outcome: Unavailable, RetryAfter: limit.RetryAfter}, nil
+		}
+		if err != nil {
+			return Result{}, err
+		}
+		return Result{Outcome: AlreadyRegistered, Wallet: address, Invite: inviteURL}, nil
+	}
+
+	if v.Paused != nil && v.Paused.Load() {
+		log.FromContext(ctx).WithField("wallet", address).Info("invites paused for maintenance")
+		return Result{Outcome: Unavailable, RetryAfter: PausedRetryAfter}, nil
+	}
+
+	if v.Slots != nil {
+		select {
+		case v.Slots <- struct{}{}:
+			metrics.InFlight.Inc()
+			defer func() {
+				<-v.Slots
+				metrics.InFlight.Dec()
+			}()
+		default:
+			log.FromContext(ctx).WithField("wallet", address).Warn("too many verifications in flight, shedding")
+			return Result{Outcome: Unavailable, RetryAfter: BusyRetryAfter}, nil
+		}
+	}
+
+	if v.Attempts != nil {
+		ok, wait := v.Attempts.Allow(address)
+		if !ok {
+			log.FromContext(ctx).WithField("wallet", address).Warn("too many attempts")
+			return Result{Outcome: TooManyAttempts, RetryAfter: wait}, nil
+		}
+	}
+
+	log.FromContext(ctx).WithField("wallet", address).Debug("evaluating gating rules")
+
+	report, err := v.Evaluate(ctx, address)
+	if err == breaker.ErrOpen {
+		log.FromContext(ctx).WithField("wallet", address).Warn("tezos upstream unavailable, failing fast")
+		return Result{Outcome: Unavailable, RetryAfter: v.Breaker.RetryAfter()}, nil
+	}
+
+	if err != nil {
+		log.FromContext(ctx).WithError(err).Error("could not verify unregistered wallet validity")
+		return Result{}, err
+	}
+
+	if !report.Passed {
+		v.rejected(address, NotEligible)
+		return Result{Outcome: NotEligible, Report: &report}, nil
+	}
+
+	log.FromContext(ctx).Debug("generating invite link!")
+	inviteURL, shared, err := v.newInvite(ctx, address)
+	if limit, ok := err.(*discord.RateLimited); ok {
+		log.FromContext(ctx).WithField("wallet", address).Warn("invites rate limited, failing fast")
+		return Result{Outcome: Unavailable, RetryAfter: limit.RetryAfter}, nil
+	}
+	if err != nil {
+		log.FromContext(ctx).WithError(err).Error("could not generate invite link")
+		return Result{}, err
+	}
+
+	log.FromContext(ctx).WithField("wallet", address).Debug("registering address")
+
+	err = v.Store.Register(address, inviteURL, shared)
+	if err != nil {
+		log.FromContext(ctx).WithError(err).Error("could not update db with address")
+		return Result{}, err
+	}
+
+	if v.OnRegistered != nil {
+		v.OnRegistered(address, inviteURL)
+	}
+
+	return Result{Outcome: Registered, Wallet: address, Invite: inviteURL, Report: &report}, nil
+}
+
+// invite returns the invite of the registered address, replaced by a fresh
+// one if it died unused and ReissueInvites is set.
+func (v *Verifier) invite(ctx context.Context, address string) (string, error) {
+	reg, err := v.Store.Owner(address)
+	if err != nil || reg == nil {
+		return "", err
+	}
+	if !v.ReissueInvites || !reg.Redeemed.IsZero() || reg.DiscordID != "" {
+		return reg.Invite, nil
+	}
+
+	dead, err := v.Inviter.Dead(reg.Invite)
+	if err != nil {
+		log.FromContext(ctx).WithError(err).WithField("wallet", address).Warn("could not check invite")
+		return reg.Invite, nil
+	}
+	if !dead {
+		return reg.Invite, nil
+	}
+
+	inviteURL, err := v.reissue(ctx, reg)
+	if err != nil {
+		return "", err
+	}
+
+	log.FromContext(ctx).WithField("wallet", reg.Wallet).Info("reissued dead invite")
+	return inviteURL, nil
+}
+
+// Reissue replaces the invite of the registration owning address with a
+// fresh one, whether the previous one was used or not. It returns "" if
+// address isn't registered.
+func (v *Verifier) Reissue(ctx context.Context, address string) (string, error) {
+	reg, err := v.Store.Owner(address)
+	if err != nil || reg == nil {
+		return "", err
+	}
+	return v.reissue(ctx, reg)
+}
+
+func (v *Verifier) reissue(ctx context.Context, reg *store.Registration) (string, error) {
+	inviteURL, shared, err := v.newInvite(ctx, reg.Wallet)
+	if err != nil {
+		return "", err
+	}
+
+	err = v.Store.Reissue(reg, inviteURL, shared)
+	if err != nil {
+		log.FromContext(ctx).WithError(err).Error("could not update db with invite")
+		return "", err
+	}
+	return inviteURL, nil
+}
+
+// newInvite returns the invite to hand out to address, and whether other
+// wallets get it too.
+func (v *Verifier) newInvite(ctx context.Context, address string) (string, bool, error) {
+	for _, tier := range v.Invites {
+		report, err := tier.Rule.Evaluate(ctx, address)
+		if err != nil {
+			return "", false, err
+		}
+		if report.Passed {
+			log.FromContext(ctx).WithFields(log.Fields{
+				"wallet": address,
+				"tier":   tier.Name,
+			}).Debug("handing out tier invite")
+			return fmt.Sprintf("%v/%v", v.Inviter.URL, tier.ID), true, nil
+		}
+	}
+
+	channelID, err := v.channel(ctx, address)
+	if err != nil {
+		return "", false, err
+	}
+	inviteURL, err := v.Inviter.CreateIn(ctx, channelID)
+	if err != nil {
+		return "", false, err
+	}
+	return inviteURL, inviteURL == v.Inviter.Fixed, nil
+}
+
+// channel returns the channel to invite address to.
+func (v *Verifier) channel(ctx context.Context, address string) (string, error) {
+	for _, tier := range v.Channels {
+		report, err := tier.Rule.Evaluate(ctx, address)
+		if err != nil {
+			return "", err
+		}
+		if report.Passed {
+			log.FromContext(ctx).WithFields(log.Fields{
+				"wallet": address,
+				"tier":   tier.Name,
+			}).Debug("inviting to tier channel")
+			return tier.ID, nil
+		}
+	}
+	return v.Inviter.ChannelID, nil
+}
+
+// Link adds the wallet of req to the registration owning the wallet of
+// owner, once the sender proved control of both, and returns the report of
+// the gating rules over the combined holdings.
+func (v *Verifier) Link(ctx context.Context, owner, req Request) (Result, error) {
+	address, outcome, ok := v.authenticate(ctx, owner)
+	if !ok {
+		return Result{Outcome: outcome}, nil
+	}
+
+	wallet, outcome, ok := v.authenticate(ctx, req)
+	if !ok {
+		return Result{Outcome: outcome}, nil
+	}
+
+	blocked, err := v.blocked(ctx, wallet)
+	if err != nil {
+		return Result{}, err
+	}
+	if blocked {
+		return Result{Outcome: Blocked}, nil
+	}
+
+	reg, err := v.Store.Owner(address)
+	if err != nil {
+		log.FromContext(ctx).WithError(err).Error("could not check registration")
+		return Result{}, err
+	}
+	if reg == nil {
+		return Result{Outcome: NotRegistered}, nil
+	}
+
+	registered, err := v.Store.IsRegistered(wallet)
+	if err != nil {
+		return Result{}, err
+	}
+	if registered {
+		return Result{Outcome: AlreadyRegistered}, nil
+	}
+
+	err = v.Store.Link(reg, wallet)
+	if err != nil {
+		log.FromContext(ctx).WithError(err).Error("could not link wallet")
+		return Result{}, err
+	}
+
+	log.FromContext(ctx).WithFields(log.Fields{
+		"wallet": reg.Wallet,
+		"linked": wallet,
+	}).Info("linked wallet")
+
+	if v.OnLinked != nil {
+		v.OnLinked(reg.Wallet, wallet)
+	}
+
+	result := Result{Outcome: Linked, Wallet: reg.Wallet, Invite: reg.Invite}
+	report, err := v.Evaluate(ctx, reg.Wallets()...)
+	if err == nil {
+		result.Report = &report
+	}
+	return result, nil
+}
+
+// Unlink removes the wallet of req from the registration owning the wallet
+// of owner. Only control of the owner wallet has to be proven.
+func (v *Verifier) Unlink(ctx context.Context, owner Request, wallet string) (Result, error) {
+	address, outcome, ok := v.authenticate(ctx, owner)
+	if !ok {
+		return Result{Outcome: outcome}, nil
+	}
+
+	if etherlink.ValidAddress(wallet) {
+		wallet = etherlink.Normalize(wallet)
+	}
+
+	reg, err := v.Store.Owner(address)
+	if err != nil {
+		log.FromContext(ctx).WithError(err).Error("could not check registration")
+		return Result{}, err
+	}
+	if reg == nil {
+		return Result{Outcome: NotRegistered}, nil
+	}
+
+	found := false
+	for _, w := range reg.Linked {
+		found = found || w == wallet
+	}
+	if !found {
+		return Result{Outcome: BadInput}, nil
+	}
+
+	err = v.Store.Unlink(reg, wallet)
+	if err != nil {
+		log.FromContext(ctx).WithError(err).Error("could not unlink wallet")
+		return Result{}, err
+	}
+
+	log.FromContext(ctx).WithFields(log.Fields{
+		"wallet":   reg.Wallet,
+		"unlinked": wallet,
+	}).Info("unlinked wallet")
+
+	return Result{Outcome: Unlinked, Wallet: reg.Wallet, Invite: reg.Invite}, nil
+}
+
+// Evaluate runs the gating rules over the combined holdings of wallets
+// without registering them. It returns breaker.ErrOpen while the upstream is
+// considered unavailable.
+func (v *Verifier) Evaluate(ctx context.Context, wallets ...string) (rules.Report, error) {
+	if v.Breaker == nil {
+		return rules.EvaluateGroup(ctx, v.Rule, wallets)
+	}
+
+	var report rules.Report
+	err := v.Breaker.Do(func() error {
+		var err error
+		report, err = rules.EvaluateGroup(ctx, v.Rule, wallets)
+		return err
+	})
+	return report, err
+}
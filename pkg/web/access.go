@@ -0,0 +1,29 @@
+package web
+
+import "net/http"
+import "time"
+
+import log "github.com/apex/log"
+
+// logged records each request served by h in AccessLog, when set.
+func (s *Server) logged(h http.Handler) http.Handler {
+	if s.AccessLog == nil {
+		return h
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &recorder{ResponseWriter: w, code: http.StatusOK}
+		h.ServeHTTP(rec, r)
+
+		s.AccessLog.WithFields(log.Fields{
+			"request":    w.Header().Get(RequestIDHeader),
+			"method":     r.Method,
+			"path":       r.URL.Path,
+			"status":     rec.code,
+			"latency_ms": time.Since(start).Milliseconds(),
+			"ip":         clientIP(r),
+			"user_agent": r.UserAgent(),
+		}).Info("request")
+	})
+}
@@ -0,0 +1,264 @@
+package web
+
+import "errors"
+import "net/http"
+import "strconv"
+import "strings"
+import "time"
+
+import log "github.com/apex/log"
+
+import "github.com/aaronwinter/tezosagora/pkg/store"
+import "github.com/aaronwinter/tezosagora/pkg/verify"
+
+// Page sizes of the registration listings.
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+)
+
+// errPageFull stops scanning the store once a page is full.
+var errPageFull = errors.New("page full")
+
+// RegistrationsResponse is the body of a GET /api/v1/admin/registrations
+// response.
+type RegistrationsResponse struct {
+	Registrations []*store.Registration `json:"registrations"`
+	// Next is the cursor of the next page, if there is one.
+	Next string `json:"next,omitempty"`
+}
+
+// ReissueResponse is the body of a POST
+// /api/v1/admin/registrations/{wallet}/reissue response.
+type ReissueResponse struct {
+	Wallet string `json:"wallet"`
+	Invite string `json:"invite"`
+}
+
+// BlockRequest is the body of POST /api/v1/admin/blocks.
+type BlockRequest struct {
+	Address string `json:"address"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// BlocksResponse is the body of a GET /api/v1/admin/blocks response.
+type BlocksResponse struct {
+	Blocks []*store.Ban `json:"blocks"`
+}
+
+// matches reports whether reg has a wallet starting with q, or a Discord
+// user or invite containing it, ignoring case. q must be lowercase.
+func matches(reg *store.Registration, q string) bool {
+	if strings.Contains(reg.DiscordID, q) || strings.Contains(strings.ToLower(reg.Invite), q) {
+		return true
+	}
+	for _, wallet := range reg.Wallets() {
+		if strings.HasPrefix(strings.ToLower(wallet), q) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseDate parses the RFC 3339 time or date v, the zero time if empty.
+func parseDate(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		t, err = time.Parse(time.DateOnly, v)
+	}
+	return t, err
+}
+
+// handleRegistrations lists the registrations in address order, a page of
+// "limit" at a time starting after the "cursor" wallet. They can be
+// filtered by address "prefix", by registration time, as those
+// registered at or after "from" and before "to", and by wallet starting
+// with, or Discord user or invite containing "q", whatever its case.
+func (s *Server) handleRegistrations(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeJSON(w, http.StatusMethodNotAllowed, ErrorResponse{Error: "method not allowed", Code: CodeInvalidRequest})
+		return
+	}
+
+	query := r.URL.Query()
+	q := strings.ToLower(strings.TrimSpace(query.Get("q")))
+	prefix := strings.TrimSpace(query.Get("prefix"))
+	cursor := query.Get("cursor")
+	from, err := parseDate(query.Get("from"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "from is not a date", Code: CodeInvalidRequest})
+		return
+	}
+	to, err := parseDate(query.Get("to"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "to is not a date", Code: CodeInvalidRequest})
+		return
+	}
+	limit := defaultPageSize
+	if v := query.Get("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "limit is not a positive integer", Code: CodeInvalidRequest})
+			return
+		}
+		limit = min(limit, maxPageSize)
+	}
+
+	start := max(cursor, prefix)
+	response := RegistrationsResponse{Registrations: []*store.Registration{}}
+	err = s.Verifier.Store.EachFrom(start, func(reg *store.Registration) error {
+		if !strings.HasPrefix(reg.Wallet, prefix) {
+			return errPageFull
+		}
+		if reg.Wallet == cursor || !matches(reg, q) {
+			return nil
+		}
+		if !from.IsZero() && reg.Registered.Before(from) || !to.IsZero() && !reg.Registered.Before(to) {
+			return nil
+		}
+		if len(response.Registrations) == limit {
+			response.Next = response.Registrations[limit-1].Wallet
+			return errPageFull
+		}
+		response.Registrations = append(response.Registrations, reg)
+		return nil
+	})
+	if err == errPageFull {
+		err = nil
+	}
+	if err != nil {
+		log.FromContext(r.Context()).WithError(err).Error("could not list registrations")
+		s.writeError(w, r, http.StatusInternalServerError, internalError)
+		return
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// handleRegistration shows the registration owning the wallet in the path
+// on GET, revokes it on DELETE and hands it a fresh invite on POST to its
+// /reissue path.
+func (s *Server) handleRegistration(w http.ResponseWriter, r *http.Request) {
+	wallet, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, APIVersion+"/admin/registrations/"), "/")
+
+	reg, err := s.Verifier.Store.Owner(wallet)
+	if err != nil {
+		log.FromContext(r.Context()).WithError(err).WithField("wallet", wallet).Error("could not look registration up")
+		s.writeError(w, r, http.StatusInternalServerError, internalError)
+		return
+	}
+	if reg == nil {
+		s.writeError(w, r, http.StatusNotFound, statuses[verify.NotRegistered])
+		return
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, reg)
+
+	case action == "" && r.Method == http.MethodDelete:
+		reason := r.URL.Query().Get("reason")
+		if reason == "" {
+			reason = "revoked through the admin API"
+		}
+		err = s.revoke(reg, reason)
+		if err != nil {
+			log.FromContext(r.Context()).WithError(err).WithField("wallet", reg.Wallet).Error("could not revoke registration")
+			s.writeError(w, r, http.StatusInternalServerError, internalError)
+			return
+		}
+		log.FromContext(r.Context()).WithFields(log.Fields{
+			"wallet": reg.Wallet,
+			"reason": reason,
+		}).Warn("revoked registration through the admin API")
+		writeJSON(w, http.StatusOK, RevokeResponse{Wallet: reg.Wallet})
+
+	case action == "reissue" && r.Method == http.MethodPost:
+		inviteURL, err := s.Verifier.Reissue(r.Context(), reg.Wallet)
+		if err != nil {
+			log.FromContext(r.Context()).WithError(err).WithField("wallet", reg.Wallet).Error("could not reissue invite")
+			s.writeError(w, r, http.StatusBadGateway, "web.unavailable")
+			return
+		}
+		log.FromContext(r.Context()).WithField("wallet", reg.Wallet).Info("reissued invite through the admin API")
+		writeJSON(w, http.StatusOK, ReissueResponse{Wallet: reg.Wallet, Invite: inviteURL})
+
+	case action == "reissue":
+		w.Header().Set("Allow", http.MethodPost)
+		writeJSON(w, http.StatusMethodNotAllowed, ErrorResponse{Error: "method not allowed", Code: CodeInvalidRequest})
+
+	case action == "":
+		w.Header().Set("Allow", "GET, DELETE")
+		writeJSON(w, http.StatusMethodNotAllowed, ErrorResponse{Error: "method not allowed", Code: CodeInvalidRequest})
+
+	default:
+		s.writeError(w, r, http.StatusNotFound, statuses[verify.NotRegistered])
+	}
+}
+
+// handleBlocks lists the wallets blocked in the store on GET, blocks the
+// posted one on POST and unblocks the wallet in the path on DELETE.
+func (s *Server) handleBlocks(w http.ResponseWriter, r *http.Request) {
+	db := s.Verifier.Store
+	wallet := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, APIVersion+"/admin/blocks"), "/")
+
+	switch {
+	case wallet == "" && r.Method == http.MethodGet:
+		response := BlocksResponse{Blocks: []*store.Ban{}}
+		err := db.EachBan(func(b *store.Ban) error {
+			response.Blocks = append(response.Blocks, b)
+			return nil
+		})
+		if err != nil {
+			log.FromContext(r.Context()).WithError(err).Error("could not list blocks")
+			s.writeError(w, r, http.StatusInternalServerError, internalError)
+			return
+		}
+		writeJSON(w, http.StatusOK, response)
+
+	case wallet == "" && r.Method == http.MethodPost:
+		var req BlockRequest
+		if !decode(w, r, &req) {
+			return
+		}
+		address := strings.TrimSpace(req.Address)
+		if !s.Verifier.ValidAddress(address) {
+			s.writeError(w, r, http.StatusBadRequest, statuses[verify.BadInput])
+			return
+		}
+
+		ban := &store.Ban{Wallet: address, Reason: req.Reason, Banned: time.Now().UTC()}
+		err := db.PutBan(ban)
+		if err != nil {
+			log.FromContext(r.Context()).WithError(err).WithField("wallet", address).Error("could not block wallet")
+			s.writeError(w, r, http.StatusInternalServerError, internalError)
+			return
+		}
+		log.FromContext(r.Context()).WithFields(log.Fields{
+			"wallet": address,
+			"reason": req.Reason,
+		}).Warn("blocked wallet through the admin API")
+		writeJSON(w, http.StatusOK, ban)
+
+	case wallet != "" && r.Method == http.MethodDelete:
+		err := db.DeleteBan(wallet)
+		if err != nil {
+			log.FromContext(r.Context()).WithError(err).WithField("wallet", wallet).Error("could not unblock wallet")
+			s.writeError(w, r, http.StatusInternalServerError, internalError)
+			return
+		}
+		log.FromContext(r.Context()).WithField("wallet", wallet).Warn("unblocked wallet through the admin API")
+		w.WriteHeader(http.StatusNoContent)
+
+	case wallet == "":
+		w.Header().Set("Allow", "GET, POST")
+		writeJSON(w, http.StatusMethodNotAllowed, ErrorResponse{Error: "method not allowed", Code: CodeInvalidRequest})
+
+	default:
+		w.Header().Set("Allow", http.MethodDelete)
+		writeJSON(w, http.StatusMethodNotAllowed, ErrorResponse{Error: "method not allowed", Code: CodeInvalidRequest})
+	}
+}
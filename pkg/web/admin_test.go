@@ -0,0 +1,55 @@
+package web
+
+import "encoding/json"
+import "net/http"
+import "net/http/httptest"
+import "net/url"
+import "path/filepath"
+import "testing"
+
+import "github.com/aaronwinter/tezosagora/pkg/store"
+import "github.com/aaronwinter/tezosagora/pkg/verify"
+
+func TestRegistrationsSearch(t *testing.T) {
+	db, err := store.Open(filepath.Join(t.TempDir(), "db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	err = db.Register("tz1VSUr8wwNhLAzempoch5d6hLRiTh8Cjcjb", "https://discord.gg/AbCdEf", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Register("tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx", "https://discord.gg/xyz123", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewServer(&verify.Verifier{Store: db}, testAssets)
+	s.AdminToken = "secret"
+
+	for q, want := range map[string]string{
+		"AbCdEf":   "tz1VSUr8wwNhLAzempoch5d6hLRiTh8Cjcjb",
+		"abcdef":   "tz1VSUr8wwNhLAzempoch5d6hLRiTh8Cjcjb",
+		"TZ1KQTPE": "tz1KqTpEZ7Yob7QbPE4Hy4Wo8fHG8LhKxZSx",
+		"Cjcjb":    "",
+	} {
+		r := httptest.NewRequest(http.MethodGet, APIVersion+"/admin/registrations?q="+url.QueryEscape(q), nil)
+		r.Header.Set("Authorization", "Bearer secret")
+		w := httptest.NewRecorder()
+		s.Handler().ServeHTTP(w, r)
+
+		var response RegistrationsResponse
+		err = json.NewDecoder(w.Body).Decode(&response)
+		if err != nil {
+			t.Fatalf("q=%v: %v", q, err)
+		}
+		var got string
+		for _, reg := range response.Registrations {
+			got += reg.Wallet
+		}
+		if got != want {
+			t.Errorf("q=%v: got %q, want %q", q, got, want)
+		}
+	}
+}
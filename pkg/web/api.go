@@ -0,0 +1,302 @@
+package web
+
+import "encoding/json"
+import "net/http"
+import "strings"
+import "time"
+
+import log "github.com/apex/log"
+
+import "github.com/aaronwinter/tezosagora/pkg/rules"
+import "github.com/aaronwinter/tezosagora/pkg/store"
+import "github.com/aaronwinter/tezosagora/pkg/verify"
+
+// APIVersion prefixes the routes of the versioned API. Their request and
+// response schemas only ever gain optional fields; breaking changes go to a
+// new version.
+const APIVersion = "/api/v1"
+
+// SubmitRequest is the body of POST /api/v1/submit. PublicKey, Signature and
+// Message are needed when signatures are required. Guild names another
+// guild than the default one, Token is the ticket handed out by /verify or
+// signed by the admin API.
+type SubmitRequest struct {
+	Address   string `json:"address"`
+	PublicKey string `json:"public_key,omitempty"`
+	Signature string `json:"signature,omitempty"`
+	Message   string `json:"message,omitempty"`
+	Guild     string `json:"guild,omitempty"`
+	Token     string `json:"token,omitempty"`
+	// Captcha is the response of the CAPTCHA widget, when one is required.
+	Captcha string `json:"captcha,omitempty"`
+	// PowChallenge and PowNonce are the proof of work challenge from /pow
+	// and its solution, when one is required.
+	PowChallenge string `json:"pow_challenge,omitempty"`
+	PowNonce     string `json:"pow_nonce,omitempty"`
+}
+
+// SubmitResponse is the body of a POST /api/v1/submit response.
+type SubmitResponse struct {
+	Outcome string        `json:"outcome"`
+	Message string        `json:"message"`
+	Wallet  string        `json:"wallet,omitempty"`
+	Invite  string        `json:"invite,omitempty"`
+	Report  *rules.Report `json:"report,omitempty"`
+	// Link is the Discord authorization URL granting the member role.
+	Link string `json:"link,omitempty"`
+	// RetryAfter is in seconds, for the unavailable and too_many_attempts
+	// outcomes.
+	RetryAfter int `json:"retry_after,omitempty"`
+	// Code is one of the Code constants, for outcomes other than registered.
+	Code string `json:"code,omitempty"`
+}
+
+// StatusResponse is the body of a GET /api/v1/status?address= response.
+type StatusResponse struct {
+	Address    string `json:"address"`
+	Registered bool   `json:"registered"`
+	// Owner is the registered wallet address is linked to, if not itself.
+	Owner string `json:"owner,omitempty"`
+	// Redeemed tells whether the invite was used, when known.
+	Redeemed bool `json:"redeemed"`
+	// Claimed tells whether a Discord account is tied to the registration.
+	Claimed      bool `json:"claimed"`
+	Disqualified bool `json:"disqualified"`
+	// RegisteredAt and RedeemedAt are set when known.
+	RegisteredAt *time.Time `json:"registered_at,omitempty"`
+	RedeemedAt   *time.Time `json:"redeemed_at,omitempty"`
+}
+
+// RevokeRequest is the body of POST /api/v1/revoke.
+type RevokeRequest struct {
+	Address string `json:"address"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// RevokeResponse is the body of a POST /api/v1/revoke response.
+type RevokeResponse struct {
+	Wallet string `json:"wallet"`
+}
+
+// ErrorResponse is the body of failed requests to the versioned API.
+type ErrorResponse struct {
+	Error string `json:"error"`
+	// Code is one of the Code constants.
+	Code string `json:"code"`
+	// Request is the ID of the request, to mention when asking for help.
+	Request string `json:"request,omitempty"`
+}
+
+// apiHandler returns the routes of the versioned API, described by the
+// OpenAPI document served at /api/v1/openapi.json.
+func (s *Server) apiHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(APIVersion+"/openapi.json", handleOpenAPI)
+	mux.HandleFunc(APIVersion+"/submit", s.limited(validated(s.handleSubmit)))
+	mux.HandleFunc(APIVersion+"/status", validated(s.handleStatus))
+	if !s.SplitAdmin {
+		s.mountAdminAPI(mux)
+	}
+	mux.HandleFunc(APIVersion+"/", s.notFound)
+	return mux
+}
+
+// adminAPIHandler returns the admin routes of the versioned API alone.
+func (s *Server) adminAPIHandler() http.Handler {
+	mux := http.NewServeMux()
+	s.mountAdminAPI(mux)
+	mux.HandleFunc(APIVersion+"/", s.notFound)
+	return mux
+}
+
+// mountAdminAPI adds the admin routes of the versioned API to mux.
+func (s *Server) mountAdminAPI(mux *http.ServeMux) {
+	if s.administered() {
+		mux.HandleFunc(APIVersion+"/revoke", s.requireAdmin(validated(s.handleRevoke)))
+		mux.HandleFunc(APIVersion+"/admin/registrations", s.requireAdmin(validated(s.handleRegistrations)))
+		mux.HandleFunc(APIVersion+"/admin/registrations/", s.requireAdmin(validated(s.handleRegistration)))
+		mux.HandleFunc(APIVersion+"/admin/blocks", s.requireAdmin(validated(s.handleBlocks)))
+		mux.HandleFunc(APIVersion+"/admin/blocks/", s.requireAdmin(validated(s.handleBlocks)))
+		mux.HandleFunc(APIVersion+"/admin/stats", s.requireAdmin(validated(s.handleStats)))
+		mux.HandleFunc(APIVersion+"/admin/maintenance", s.requireAdmin(validated(s.handleMaintenance)))
+	}
+	if s.administered() && s.Tickets != nil {
+		mux.HandleFunc(APIVersion+"/admin/tickets", s.requireAdmin(validated(s.handleTickets)))
+	}
+}
+
+// writeJSON writes v as the JSON body of a response with the status code.
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+// decode reads the JSON body of r into v, answering the request itself and
+// returning false when it isn't a POST or the body is malformed.
+func decode(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeJSON(w, http.StatusMethodNotAllowed, ErrorResponse{Error: "method not allowed", Code: CodeInvalidRequest})
+		return false
+	}
+
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(v)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "malformed JSON body", Code: CodeInvalidRequest})
+		return false
+	}
+	return true
+}
+
+func (s *Server) handleSubmit(w http.ResponseWriter, r *http.Request) {
+	var req SubmitRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	verifier, err := s.verifierOf(r, req.Guild)
+	if err == errUnknownGuild {
+		s.writeError(w, r, http.StatusNotFound, unknownGuild)
+		return
+	}
+	if err != nil {
+		s.writeError(w, r, http.StatusInternalServerError, internalError)
+		return
+	}
+
+	code, status := s.challenge(r, req.Captcha, req.PowChallenge, req.PowNonce)
+	if code == 0 {
+		code, status = s.checkTicket(r, req.Token)
+	}
+	if code != 0 {
+		s.writeError(w, r, code, status)
+		return
+	}
+
+	result, err := verifier.Verify(r.Context(), verify.Request{
+		Address:   strings.TrimSpace(req.Address),
+		PublicKey: req.PublicKey,
+		Signature: req.Signature,
+		Message:   req.Message,
+	})
+	if err != nil {
+		s.writeError(w, r, http.StatusInternalServerError, internalError)
+		return
+	}
+	s.failed(r, result)
+
+	if req.Token != "" && claimable(verifier, result) {
+		s.claim(r, verifier, req.Guild, result.Wallet, req.Token)
+	}
+
+	response := SubmitResponse{
+		Outcome: result.Outcome.String(),
+		Message: s.tr(r, statuses[result.Outcome]),
+		Code:    codes[statuses[result.Outcome]],
+		Wallet:  result.Wallet,
+		Invite:  result.Invite,
+		Report:  result.Report,
+	}
+	if s.Linker != nil && claimable(verifier, result) {
+		response.Link = s.Linker.AuthURL(result.Wallet)
+	}
+	if result.Outcome == verify.Unavailable || result.Outcome == verify.TooManyAttempts {
+		response.RetryAfter = int(result.RetryAfter.Seconds()) + 1
+	}
+	writeJSON(w, resultCode(w, result), response)
+}
+
+// handleStatus tells whether the address in the query is registered, served
+// both at /status and in the versioned API. It changes nothing.
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeJSON(w, http.StatusMethodNotAllowed, ErrorResponse{Error: "method not allowed", Code: CodeInvalidRequest})
+		return
+	}
+
+	address := strings.TrimSpace(r.URL.Query().Get("address"))
+	if !s.Verifier.ValidAddress(address) {
+		s.writeError(w, r, http.StatusBadRequest, statuses[verify.BadInput])
+		return
+	}
+
+	verifier, err := s.verifierOf(r, r.URL.Query().Get("guild"))
+	if err == errUnknownGuild {
+		s.writeError(w, r, http.StatusNotFound, unknownGuild)
+		return
+	}
+	if err != nil {
+		s.writeError(w, r, http.StatusInternalServerError, internalError)
+		return
+	}
+
+	reg, err := verifier.Store.Owner(address)
+	if err != nil {
+		log.FromContext(r.Context()).WithError(err).WithField("wallet", address).Error("could not look registration up")
+		s.writeError(w, r, http.StatusInternalServerError, internalError)
+		return
+	}
+
+	response := StatusResponse{Address: address}
+	if reg != nil {
+		response.Registered = true
+		if reg.Wallet != address {
+			response.Owner = reg.Wallet
+		}
+		response.Redeemed = !reg.Redeemed.IsZero()
+		response.Claimed = reg.DiscordID != ""
+		response.Disqualified = !reg.Disqualified.IsZero()
+		if !reg.Registered.IsZero() {
+			response.RegisteredAt = &reg.Registered
+		}
+		if response.Redeemed {
+			response.RedeemedAt = &reg.Redeemed
+		}
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (s *Server) handleRevoke(w http.ResponseWriter, r *http.Request) {
+	var req RevokeRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	reg, err := s.Verifier.Store.Owner(strings.TrimSpace(req.Address))
+	if err != nil {
+		log.FromContext(r.Context()).WithError(err).WithField("wallet", req.Address).Error("could not look registration up")
+		s.writeError(w, r, http.StatusInternalServerError, internalError)
+		return
+	}
+	if reg == nil {
+		s.writeError(w, r, http.StatusNotFound, statuses[verify.NotRegistered])
+		return
+	}
+
+	reason := req.Reason
+	if reason == "" {
+		reason = "revoked through the API"
+	}
+	err = s.revoke(reg, reason)
+	if err != nil {
+		log.FromContext(r.Context()).WithError(err).WithField("wallet", reg.Wallet).Error("could not revoke registration")
+		s.writeError(w, r, http.StatusInternalServerError, internalError)
+		return
+	}
+
+	log.FromContext(r.Context()).WithFields(log.Fields{
+		"wallet": reg.Wallet,
+		"reason": reason,
+	}).Warn("revoked registration through the API")
+	writeJSON(w, http.StatusOK, RevokeResponse{Wallet: reg.Wallet})
+}
+
+// revoke deletes reg through OnRevoke, or from the store if it isn't set.
+func (s *Server) revoke(reg *store.Registration, reason string) error {
+	if s.OnRevoke != nil {
+		return s.OnRevoke(reg, reason)
+	}
+	return s.Verifier.Store.Delete(reg.Wallet)
+}
@@ -0,0 +1,42 @@
+package web
+
+import "crypto/subtle"
+import "net/http"
+import "strings"
+
+import log "github.com/apex/log"
+
+// authorized reports whether r carries the admin token as a bearer token,
+// or the session of one of the Admins.
+func (s *Server) authorized(r *http.Request) bool {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if s.AdminToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(s.AdminToken)) == 1 {
+		return true
+	}
+	return s.logins() && s.isAdmin(s.sessionUser(r))
+}
+
+// logins reports whether Admins may log in with Discord, which they can't
+// when the admin endpoints are served apart.
+func (s *Server) logins() bool {
+	return s.Linker != nil && len(s.Admins) > 0 && !s.SplitAdmin
+}
+
+// administered reports whether the admin endpoints are enabled, which takes
+// AdminToken or logins.
+func (s *Server) administered() bool {
+	return s.AdminToken != "" || s.logins()
+}
+
+// requireAdmin only lets requests carrying the admin token through to h.
+func (s *Server) requireAdmin(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.authorized(r) {
+			log.FromContext(r.Context()).WithField("path", r.URL.Path).Warn("unauthorized admin request")
+			w.Header().Set("WWW-Authenticate", `Bearer realm="tezosagora"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		h(w, r)
+	}
+}
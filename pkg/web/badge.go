@@ -0,0 +1,69 @@
+package web
+
+import "fmt"
+import "net/http"
+import "strings"
+
+import log "github.com/apex/log"
+
+// badgeMaxAge is how long, in seconds, badges may be cached.
+const badgeMaxAge = 300
+
+// Badge is the body of the responses of /badge/, in the format of the
+// shields.io endpoint badges.
+type Badge struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Label         string `json:"label"`
+	Message       string `json:"message"`
+	Color         string `json:"color"`
+	IsError       bool   `json:"isError,omitempty"`
+	CacheSeconds  int    `json:"cacheSeconds"`
+}
+
+// handleBadge tells whether the wallet at the end of the path is verified,
+// for shields.io to draw it as a badge, as in
+// https://img.shields.io/endpoint?url=https://agora.example/badge/tz1...
+// Wallets that got disqualified since registering are unverified. The
+// "guild" query parameter names another guild than the default one.
+func (s *Server) handleBadge(w http.ResponseWriter, r *http.Request) {
+	if !s.allowed(w, r, http.MethodGet) {
+		return
+	}
+
+	label := s.Name
+	if label == "" {
+		label = "tezosagora"
+	}
+	badge := Badge{SchemaVersion: 1, Label: label, CacheSeconds: badgeMaxAge}
+
+	address := strings.TrimPrefix(r.URL.Path, "/badge/")
+	verifier, err := s.verifierOf(r, r.URL.Query().Get("guild"))
+	switch {
+	case !s.Verifier.ValidAddress(address):
+		badge.Message, badge.Color, badge.IsError = s.tr(r, "web.badge.invalid"), "lightgrey", true
+	case err == errUnknownGuild:
+		badge.Message, badge.Color, badge.IsError = s.tr(r, unknownGuild), "lightgrey", true
+	case err != nil:
+		badge.Message, badge.Color, badge.IsError = s.tr(r, "web.badge.unavailable"), "lightgrey", true
+	}
+	if badge.IsError {
+		writeJSON(w, http.StatusOK, badge)
+		return
+	}
+
+	reg, err := verifier.Store.Owner(address)
+	if err != nil {
+		log.FromContext(r.Context()).WithError(err).WithField("wallet", address).Error("could not look registration up")
+		badge.Message, badge.Color, badge.IsError = s.tr(r, "web.badge.unavailable"), "lightgrey", true
+		writeJSON(w, http.StatusOK, badge)
+		return
+	}
+
+	if reg != nil && reg.Disqualified.IsZero() {
+		badge.Message, badge.Color = s.tr(r, "web.badge.verified"), "brightgreen"
+	} else {
+		badge.Message, badge.Color = s.tr(r, "web.badge.unverified"), "lightgrey"
+	}
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%v", badgeMaxAge))
+	writeJSON(w, http.StatusOK, badge)
+}
@@ -0,0 +1,109 @@
+package web
+
+import "context"
+import "encoding/json"
+import "fmt"
+import "net/http"
+import "sync"
+
+import log "github.com/apex/log"
+
+import "github.com/aaronwinter/tezosagora/pkg/rules"
+import "github.com/aaronwinter/tezosagora/pkg/verify"
+
+// MaxBatchSize caps the number of addresses in a single batch request.
+const MaxBatchSize = 1000
+
+const batchWorkers = 8
+
+// BatchRequest is the body of a POST /batch request.
+type BatchRequest struct {
+	Addresses []string `json:"addresses"`
+}
+
+// BatchResult is the verification result of one address.
+type BatchResult struct {
+	Address    string        `json:"address"`
+	Registered bool          `json:"registered"`
+	Report     *rules.Report `json:"report,omitempty"`
+	Error      string        `json:"error,omitempty"`
+}
+
+// BatchResponse is the body of a POST /batch response, results being in the
+// order of the requested addresses.
+type BatchResponse struct {
+	Results []BatchResult `json:"results"`
+}
+
+func (s *Server) handleBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req BatchRequest
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req)
+	if err != nil || len(req.Addresses) == 0 || len(req.Addresses) > MaxBatchSize {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	log.FromContext(r.Context()).WithField("count", len(req.Addresses)).Debug("batch verification")
+
+	results := make([]BatchResult, len(req.Addresses))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < batchWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				results[j] = s.check(r.Context(), req.Addresses[j])
+			}
+		}()
+	}
+	for i := range req.Addresses {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(BatchResponse{Results: results})
+}
+
+func (s *Server) check(ctx context.Context, address string) BatchResult {
+	result := BatchResult{Address: address}
+	if !s.Verifier.ValidAddress(address) {
+		result.Error = s.Catalog.T(s.Language, statuses[verify.BadInput])
+		return result
+	}
+
+	if s.Verifier.Blocklist != nil {
+		reason, blocked, err := s.Verifier.Blocklist.Blocked(address)
+		if err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		if blocked {
+			result.Error = fmt.Sprintf("blocked: %v", reason)
+			return result
+		}
+	}
+
+	registered, err := s.Verifier.Store.IsRegistered(address)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Registered = registered
+
+	report, err := s.Verifier.Evaluate(ctx, address)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Report = &report
+
+	return result
+}
@@ -0,0 +1,34 @@
+package web
+
+import "net/http"
+import "strconv"
+import "time"
+
+// Form fields of the bot heuristics: a honeypot left empty by humans, as it
+// isn't displayed, and the milliseconds spent on the page before submitting,
+// filled in by its script.
+const (
+	honeypotField = "website"
+	elapsedField  = "elapsed"
+)
+
+// automated returns why the invite form posted in r looks like it was sent
+// by a bot, or "" when it doesn't or BotChecks is off.
+func (s *Server) automated(r *http.Request) string {
+	if !s.BotChecks {
+		return ""
+	}
+
+	if r.Form.Get(honeypotField) != "" {
+		return "honeypot filled"
+	}
+	if r.UserAgent() == "" || r.Header.Get("Accept-Language") == "" {
+		return "missing headers"
+	}
+	// only browsers running the page script tell how long they took
+	elapsed, err := strconv.Atoi(r.Form.Get(elapsedField))
+	if err == nil && time.Duration(elapsed)*time.Millisecond < s.MinFillTime {
+		return "submitted too fast"
+	}
+	return ""
+}
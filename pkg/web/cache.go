@@ -0,0 +1,78 @@
+package web
+
+import "crypto/sha256"
+import "encoding/base64"
+import "io/fs"
+import "net/http"
+import "path"
+import "strings"
+import "sync"
+
+// assetMaxAge is how long, in seconds, browsers may use assets without
+// asking whether they changed. Pages are always revalidated.
+const assetMaxAge = "3600"
+
+// noStore keeps the responses of h out of caches, unless h says otherwise.
+func noStore(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+		h.ServeHTTP(w, r)
+	})
+}
+
+// etags caches the ETags of assets by name.
+type etags struct {
+	mu    sync.Mutex
+	byKey map[string]string
+}
+
+// etag returns the ETag of the asset name, hashing it on first use, or
+// every time when Reload is set. It returns "" for missing files and
+// directories.
+func (s *Server) etag(name string) string {
+	s.etags.mu.Lock()
+	defer s.etags.mu.Unlock()
+	if tag, ok := s.etags.byKey[name]; ok && !s.Reload {
+		return tag
+	}
+
+	b, err := fs.ReadFile(s.Assets, name)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(b)
+	// weak, as the compressed representations share it
+	tag := `W/"` + base64.RawURLEncoding.EncodeToString(sum[:12]) + `"`
+	if s.etags.byKey == nil {
+		s.etags.byKey = make(map[string]string)
+	}
+	s.etags.byKey[name] = tag
+	return tag
+}
+
+// static serves the files of Assets with ETags, which http.FileServer
+// checks If-None-Match against, and Cache-Control headers. Missing files
+// get the error page.
+func (s *Server) static() http.Handler {
+	files := http.FileServer(http.FS(s.Assets))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(path.Clean(r.URL.Path), "/")
+		if name == "" || strings.HasSuffix(r.URL.Path, "/") {
+			name = path.Join(name, "index.html")
+		}
+		if _, err := fs.Stat(s.Assets, name); err != nil {
+			s.notFound(w, r)
+			return
+		}
+
+		if tag := s.etag(name); tag != "" {
+			w.Header().Set("ETag", tag)
+		}
+		if strings.HasSuffix(name, ".html") {
+			w.Header().Set("Cache-Control", "no-cache")
+		} else {
+			w.Header().Set("Cache-Control", "public, max-age="+assetMaxAge)
+		}
+		files.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,42 @@
+package web
+
+import "encoding/json"
+import "net/http"
+
+import log "github.com/apex/log"
+
+const (
+	captchaRequired    = "web.captcha_required"
+	captchaUnavailable = "web.captcha_unavailable"
+)
+
+// handleCaptcha describes the widget the landing page has to render, or
+// answers 204 when the client doesn't have to solve it for now.
+func (s *Server) handleCaptcha(w http.ResponseWriter, r *http.Request) {
+	if !s.flagged(r) {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.Captcha)
+}
+
+// solved checks the CAPTCHA response of the client of r, if Captcha is set.
+// It returns the status code and message to answer with when it's not
+// solved, and 0 otherwise.
+func (s *Server) solved(r *http.Request, response string) (int, string) {
+	if s.Captcha == nil {
+		return 0, ""
+	}
+
+	ok, err := s.Captcha.Check(response, clientIP(r))
+	if err != nil {
+		log.FromContext(r.Context()).WithError(err).Error("could not check CAPTCHA")
+		return http.StatusServiceUnavailable, captchaUnavailable
+	}
+	if !ok {
+		log.FromContext(r.Context()).WithField("ip", clientIP(r)).Debug("CAPTCHA not solved")
+		return http.StatusForbidden, captchaRequired
+	}
+	return 0, ""
+}
@@ -0,0 +1,60 @@
+package web
+
+import "net/http"
+
+// Codes of failed requests, in the code field of JSON responses and in the
+// Code of WebResp for templates, so that clients can tell failures apart
+// without parsing translated messages.
+const (
+	CodeInvalidRequest    = "invalid_request"
+	CodeInvalidAddress    = "invalid_address"
+	CodeInvalidSignature  = "invalid_signature"
+	CodeNotFound          = "not_found"
+	CodeAlreadyRegistered = "already_registered"
+	CodeNotEligible       = "not_eligible"
+	CodeForbidden         = "forbidden"
+	CodeChallenge         = "challenge_required"
+	CodeUpstreamError     = "upstream_error"
+	CodeRateLimited       = "rate_limited"
+	CodeUnavailable       = "unavailable"
+	CodeInternalError     = "internal_error"
+)
+
+// codes hold the codes of the failures described by each message key.
+var codes = map[string]string{
+	"web.bad_input":          CodeInvalidAddress,
+	"web.invalid_signature":  CodeInvalidSignature,
+	"web.not_registered":     CodeNotFound,
+	"web.not_found":          CodeNotFound,
+	unknownGuild:             CodeNotFound,
+	"web.already_registered": CodeAlreadyRegistered,
+	"web.claim.wallet":       CodeAlreadyRegistered,
+	"web.claim.account":      CodeAlreadyRegistered,
+	"web.not_eligible":       CodeNotEligible,
+	"web.blocked":            CodeForbidden,
+	"web.csrf":               CodeForbidden,
+	invalidTicket:            CodeForbidden,
+	"web.discord.denied":     CodeForbidden,
+	"web.admin.forbidden":    CodeForbidden,
+	captchaRequired:          CodeChallenge,
+	powRequired:              CodeChallenge,
+	"web.unavailable":        CodeUpstreamError,
+	"web.maintenance":        CodeUnavailable,
+	"web.payment.busy":       CodeUnavailable,
+	captchaUnavailable:       CodeUpstreamError,
+	"web.discord.failed":     CodeUpstreamError,
+	"web.rate_limited":       CodeRateLimited,
+	"web.too_many_attempts":  CodeRateLimited,
+	"web.method_not_allowed": CodeInvalidRequest,
+	internalError:            CodeInternalError,
+}
+
+// writeError writes the message key, translated for r, and its code as an
+// ErrorResponse with the status code.
+func (s *Server) writeError(w http.ResponseWriter, r *http.Request, code int, key string) {
+	writeJSON(w, code, ErrorResponse{
+		Error:   s.tr(r, key),
+		Code:    codes[key],
+		Request: w.Header().Get(RequestIDHeader),
+	})
+}
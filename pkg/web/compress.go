@@ -0,0 +1,121 @@
+package web
+
+import "compress/gzip"
+import "io"
+import "net/http"
+import "strings"
+
+import "github.com/andybalholm/brotli"
+
+// compressible reports whether responses of contentType are worth
+// compressing. Event streams are left alone, they have to be flushed as
+// they go.
+func compressible(contentType string) bool {
+	switch {
+	case strings.HasPrefix(contentType, "text/event-stream"):
+		return false
+	case strings.HasPrefix(contentType, "text/"),
+		strings.HasPrefix(contentType, "application/json"),
+		strings.HasPrefix(contentType, "application/javascript"),
+		strings.HasPrefix(contentType, "image/svg+xml"):
+		return true
+	}
+	return false
+}
+
+// encoding returns the encoding to compress the response to r with, brotli
+// being preferred to gzip, or "" if the client accepts neither.
+func encoding(r *http.Request) string {
+	var gz bool
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if strings.TrimSpace(params) == "q=0" {
+			continue
+		}
+		switch name {
+		case "br":
+			return "br"
+		case "gzip":
+			gz = true
+		}
+	}
+	if gz {
+		return "gzip"
+	}
+	return ""
+}
+
+// compressor compresses what is written through it with encoding, once the
+// status and headers tell the response is worth it.
+type compressor struct {
+	http.ResponseWriter
+	encoding    string
+	encoder     io.WriteCloser
+	wroteHeader bool
+}
+
+func (c *compressor) WriteHeader(code int) {
+	if c.wroteHeader {
+		return
+	}
+	c.wroteHeader = true
+
+	h := c.Header()
+	if code == http.StatusOK && h.Get("Content-Encoding") == "" && compressible(h.Get("Content-Type")) {
+		h.Set("Content-Encoding", c.encoding)
+		h.Del("Content-Length")
+		if c.encoding == "br" {
+			c.encoder = brotli.NewWriter(c.ResponseWriter)
+		} else {
+			c.encoder = gzip.NewWriter(c.ResponseWriter)
+		}
+	}
+	c.ResponseWriter.WriteHeader(code)
+}
+
+func (c *compressor) Write(b []byte) (int, error) {
+	if !c.wroteHeader {
+		if c.Header().Get("Content-Type") == "" {
+			c.Header().Set("Content-Type", http.DetectContentType(b))
+		}
+		c.WriteHeader(http.StatusOK)
+	}
+	if c.encoder != nil {
+		return c.encoder.Write(b)
+	}
+	return c.ResponseWriter.Write(b)
+}
+
+// Flush sends what was compressed so far.
+func (c *compressor) Flush() {
+	if f, ok := c.encoder.(interface{ Flush() error }); ok {
+		f.Flush()
+	}
+	http.NewResponseController(c.ResponseWriter).Flush()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (c *compressor) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
+
+// compressed compresses the responses of h for the clients accepting it.
+// HEAD and range requests are served as they are.
+func compressed(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		enc := encoding(r)
+		if enc == "" || r.Method == http.MethodHead || r.Header.Get("Range") != "" {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		c := &compressor{ResponseWriter: w, encoding: enc}
+		defer func() {
+			if c.encoder != nil {
+				c.encoder.Close()
+			}
+		}()
+		h.ServeHTTP(c, r)
+	})
+}
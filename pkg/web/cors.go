@@ -0,0 +1,50 @@
+package web
+
+import "net/http"
+import "strings"
+
+// cors lets the browsers of the CORSOrigins call the /api/ routes of h with
+// the CORSMethods, answering their preflight requests itself.
+func (s *Server) cors(h http.Handler) http.Handler {
+	if len(s.CORSOrigins) == 0 {
+		return h
+	}
+	methods := strings.Join(s.CORSMethods, ", ")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" || !strings.HasPrefix(r.URL.Path, "/api/") {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		header := w.Header()
+		header.Add("Vary", "Origin")
+		if !s.allowedOrigin(origin) {
+			h.ServeHTTP(w, r)
+			return
+		}
+		header.Set("Access-Control-Allow-Origin", origin)
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			header.Set("Access-Control-Allow-Methods", methods)
+			header.Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+			header.Set("Access-Control-Max-Age", "600")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		header.Set("Access-Control-Expose-Headers", "Retry-After, "+RequestIDHeader)
+		h.ServeHTTP(w, r)
+	})
+}
+
+// allowedOrigin reports whether origin is one of CORSOrigins, "*" allowing
+// any.
+func (s *Server) allowedOrigin(origin string) bool {
+	for _, allowed := range s.CORSOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package web
+
+import "crypto/rand"
+import "crypto/subtle"
+import "encoding/base64"
+import "encoding/json"
+import "mime"
+import "net/http"
+
+// csrfName names both the cookie and the form field carrying the CSRF token
+// of the invite and payment forms, which have to match.
+const csrfName = "csrf"
+
+// handleCSRF hands out a CSRF token for the forms, also set as a
+// cookie that other sites can't read nor send along.
+func (s *Server) handleCSRF(w http.ResponseWriter, r *http.Request) {
+	token, err := r.Cookie(csrfName)
+	if err != nil {
+		b := make([]byte, 32)
+		rand.Read(b)
+		token = &http.Cookie{
+			Name:     csrfName,
+			Value:    base64.RawURLEncoding.EncodeToString(b),
+			Path:     "/",
+			HttpOnly: true,
+			Secure:   r.TLS != nil,
+			SameSite: http.SameSiteStrictMode,
+		}
+		http.SetCookie(w, token)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"token": token.Value})
+}
+
+// forged reports whether the form posted in r lacks the CSRF token
+// matching its cookie, when CSRF is on. Only JSON bodies, which other sites
+// can't send without the CORS checks, are exempt, whatever the path.
+func (s *Server) forged(r *http.Request) bool {
+	if !s.CSRF {
+		return false
+	}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "application/json" {
+		return false
+	}
+
+	cookie, err := r.Cookie(csrfName)
+	if err != nil || cookie.Value == "" {
+		return true
+	}
+	return subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(r.Form.Get(csrfName))) != 1
+}
@@ -0,0 +1,36 @@
+package web
+
+import "net/http"
+import "net/http/httptest"
+import "strings"
+import "testing"
+import "testing/fstest"
+import "time"
+
+import "github.com/aaronwinter/tezosagora/pkg/payment"
+import "github.com/aaronwinter/tezosagora/pkg/verify"
+
+// testAssets holds the templates NewServer parses.
+var testAssets = fstest.MapFS{
+	"invite.html":      {Data: []byte(`{{ .Status }}`)},
+	"payment.html":     {Data: []byte(`{{ .Status }}`)},
+	"maintenance.html": {Data: []byte(`{{ .Status }}`)},
+	"error.html":       {Data: []byte(`{{ .Status }}`)},
+}
+
+func TestCSRFFormToAPI(t *testing.T) {
+	s := NewServer(&verify.Verifier{}, testAssets)
+	s.CSRF = true
+	s.Payments = payment.NewWatcher(nil, "tz1VSUr8wwNhLAzempoch5d6hLRiTh8Cjcjb", time.Hour, 1)
+
+	for _, path := range []string{"/invite", "/api/invite", "/payment"} {
+		r := httptest.NewRequest(http.MethodPost, path, strings.NewReader("address=tz1VSUr8wwNhLAzempoch5d6hLRiTh8Cjcjb"))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.Header.Set("Accept", "application/json")
+		w := httptest.NewRecorder()
+		s.Handler().ServeHTTP(w, r)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("form posted to %v without a CSRF token: got %v, want 403", path, w.Code)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package web
+
+import "net/http"
+import "sort"
+import "sync"
+import "time"
+
+import log "github.com/apex/log"
+
+import "github.com/aaronwinter/tezosagora/pkg/store"
+import "github.com/aaronwinter/tezosagora/pkg/verify"
+
+// maxActivity is how many verifications the dashboard remembers.
+const maxActivity = 50
+
+// recentRegistrations is how many of the last registrations the dashboard
+// shows.
+const recentRegistrations = 10
+
+// Activity is a verification shown on the dashboard.
+type Activity struct {
+	Time    time.Time `json:"time"`
+	Wallet  string    `json:"wallet"`
+	Outcome string    `json:"outcome"`
+}
+
+// activity holds the last verifications, newest first. They are only kept
+// for the lifetime of the process.
+type activity struct {
+	mu      sync.Mutex
+	entries []Activity
+}
+
+// Record adds a verification of wallet to the recent activity shown on the
+// dashboard.
+func (s *Server) Record(wallet string, outcome verify.Outcome) {
+	s.activity.mu.Lock()
+	defer s.activity.mu.Unlock()
+	entries := append([]Activity{{Time: time.Now().UTC(), Wallet: wallet, Outcome: outcome.String()}}, s.activity.entries...)
+	if len(entries) > maxActivity {
+		entries = entries[:maxActivity]
+	}
+	s.activity.entries = entries
+}
+
+// StatsResponse is the body of a GET /api/v1/admin/stats response.
+type StatsResponse struct {
+	Registrations int `json:"registrations"`
+	Claimed       int `json:"claimed"`
+	Redeemed      int `json:"redeemed"`
+	Disqualified  int `json:"disqualified"`
+	Blocks        int `json:"blocks"`
+	// Recent are the last registrations, newest first.
+	Recent []*store.Registration `json:"recent"`
+	// Activity are the last verifications since the service started,
+	// newest first.
+	Activity []Activity `json:"activity"`
+}
+
+// handleStats counts the registrations and blocks for the dashboard.
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeJSON(w, http.StatusMethodNotAllowed, ErrorResponse{Error: "method not allowed", Code: CodeInvalidRequest})
+		return
+	}
+
+	db := s.Verifier.Store
+	var response StatsResponse
+	err := db.Each(func(reg *store.Registration) error {
+		response.Registrations++
+		if reg.DiscordID != "" {
+			response.Claimed++
+		}
+		if !reg.Redeemed.IsZero() {
+			response.Redeemed++
+		}
+		if !reg.Disqualified.IsZero() {
+			response.Disqualified++
+		}
+		response.Recent = append(response.Recent, reg)
+		return nil
+	})
+	if err == nil {
+		err = db.EachBan(func(*store.Ban) error {
+			response.Blocks++
+			return nil
+		})
+	}
+	if err != nil {
+		log.FromContext(r.Context()).WithError(err).Error("could not count registrations")
+		s.writeError(w, r, http.StatusInternalServerError, internalError)
+		return
+	}
+
+	sort.Slice(response.Recent, func(i, j int) bool {
+		return response.Recent[i].Registered.After(response.Recent[j].Registered)
+	})
+	if len(response.Recent) > recentRegistrations {
+		response.Recent = response.Recent[:recentRegistrations]
+	}
+	if response.Recent == nil {
+		response.Recent = []*store.Registration{}
+	}
+
+	s.activity.mu.Lock()
+	response.Activity = append([]Activity{}, s.activity.entries...)
+	s.activity.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// dashboard serves the admin pages of Assets, sending those who aren't
+// authorized to log in first when they can.
+func (s *Server) dashboard() http.Handler {
+	files := s.static()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.logins() && !s.authorized(r) {
+			http.Redirect(w, r, "/admin/login", http.StatusFound)
+			return
+		}
+		files.ServeHTTP(w, r)
+	})
+}
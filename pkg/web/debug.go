@@ -0,0 +1,14 @@
+package web
+
+import "net/http"
+import "net/http/pprof"
+
+// mountPprof serves the net/http/pprof profiles under /debug/pprof/, to
+// requests bearing the admin token only.
+func (s *Server) mountPprof(mux *http.ServeMux) {
+	mux.HandleFunc("/debug/pprof/", s.requireAdmin(pprof.Index))
+	mux.HandleFunc("/debug/pprof/cmdline", s.requireAdmin(pprof.Cmdline))
+	mux.HandleFunc("/debug/pprof/profile", s.requireAdmin(pprof.Profile))
+	mux.HandleFunc("/debug/pprof/symbol", s.requireAdmin(pprof.Symbol))
+	mux.HandleFunc("/debug/pprof/trace", s.requireAdmin(pprof.Trace))
+}
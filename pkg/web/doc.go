@@ -0,0 +1,3 @@
+// Package web serves the landing page and the /invite form handler on top of
+// a verify.Verifier.
+package web
@@ -0,0 +1,27 @@
+package web
+
+import "net/http"
+
+// fail answers r with the message key and the status code, on the error
+// page or as an ErrorResponse for clients asking for JSON. Both carry the
+// ID of the request.
+func (s *Server) fail(w http.ResponseWriter, r *http.Request, code int, key string) {
+	if wantsJSON(r) {
+		s.writeError(w, r, code, key)
+		return
+	}
+
+	resp := &WebResp{
+		Status:  s.tr(r, key),
+		Code:    codes[key],
+		Request: w.Header().Get(RequestIDHeader),
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(code)
+	s.render(w, r, "error.html", resp)
+}
+
+// notFound answers requests for missing pages and routes.
+func (s *Server) notFound(w http.ResponseWriter, r *http.Request) {
+	s.fail(w, r, http.StatusNotFound, "web.not_found")
+}
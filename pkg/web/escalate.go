@@ -0,0 +1,52 @@
+package web
+
+import "net/http"
+
+import "github.com/aaronwinter/tezosagora/pkg/verify"
+
+// failures are the outcomes counting as suspicious behavior.
+var failures = map[verify.Outcome]bool{
+	verify.BadInput:         true,
+	verify.InvalidSignature: true,
+	verify.NotEligible:      true,
+	verify.Blocked:          true,
+	verify.NotRegistered:    true,
+}
+
+// challenged reports whether the client of r has to solve the CAPTCHA and
+// proof of work challenges, which is always the case unless Suspicion is set.
+// Each call counts as a request from the client.
+func (s *Server) challenged(r *http.Request) bool {
+	if s.Suspicion == nil {
+		return true
+	}
+	ok, _ := s.Suspicion.Allow(clientIP(r))
+	return !ok
+}
+
+// flagged reports whether the client of r would have to solve challenges
+// right now, without counting a request.
+func (s *Server) flagged(r *http.Request) bool {
+	return s.Suspicion == nil || s.Suspicion.Exhausted(clientIP(r))
+}
+
+// challenge checks the CAPTCHA response and proof of work solution of the
+// client of r when it is challenged. It returns the status code and message
+// to answer with when they don't pass, and 0 otherwise.
+func (s *Server) challenge(r *http.Request, response, challenge, nonce string) (int, string) {
+	if !s.challenged(r) {
+		return 0, ""
+	}
+	code, status := s.solved(r, response)
+	if code == 0 {
+		code, status = s.proved(r, challenge, nonce)
+	}
+	return code, status
+}
+
+// failed counts a failed verification against the client of r.
+func (s *Server) failed(r *http.Request, result verify.Result) {
+	if s.Suspicion != nil && failures[result.Outcome] {
+		s.Suspicion.Allow(clientIP(r))
+	}
+}
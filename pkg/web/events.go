@@ -0,0 +1,149 @@
+package web
+
+import "encoding/json"
+import "fmt"
+import "net/http"
+import "sync"
+import "time"
+
+import log "github.com/apex/log"
+
+import "github.com/aaronwinter/tezosagora/pkg/verify"
+
+// keepAlive is how often idle event streams get a comment, so that proxies
+// don't close them.
+const keepAlive = 15 * time.Second
+
+// Event is a status update of the verification of a wallet, pushed to the
+// pages following it. Key names the message describing it, formatted with
+// Args in the language of each page.
+type Event struct {
+	Key    string
+	Args   []interface{}
+	Invite string
+	// Done ends the streams following the wallet.
+	Done bool
+}
+
+// ResultEvent returns the Event ending a verification with result, or with
+// err if it failed.
+func ResultEvent(result verify.Result, err error) Event {
+	if err != nil {
+		return Event{Key: internalError, Done: true}
+	}
+	return Event{Key: statuses[result.Outcome], Invite: result.Invite, Done: true}
+}
+
+// progress fans the events of wallets out to the streams following them.
+type progress struct {
+	mu      sync.Mutex
+	streams map[string]map[chan Event]struct{}
+}
+
+func (p *progress) follow(wallet string) (chan Event, func()) {
+	events := make(chan Event, 8)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.streams == nil {
+		p.streams = make(map[string]map[chan Event]struct{})
+	}
+	if p.streams[wallet] == nil {
+		p.streams[wallet] = make(map[chan Event]struct{})
+	}
+	p.streams[wallet][events] = struct{}{}
+
+	return events, func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		delete(p.streams[wallet], events)
+		if len(p.streams[wallet]) == 0 {
+			delete(p.streams, wallet)
+		}
+	}
+}
+
+// Publish pushes e to the pages following wallet. Streams too slow to keep
+// up miss it.
+func (s *Server) Publish(wallet string, e Event) {
+	s.progress.mu.Lock()
+	defer s.progress.mu.Unlock()
+	for events := range s.progress.streams[wallet] {
+		select {
+		case events <- e:
+		default:
+		}
+	}
+}
+
+// handleEvents streams the events of the wallet named by the query as
+// server-sent events, starting with the current status of its payment.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if !s.allowed(w, r, http.MethodGet) {
+		return
+	}
+
+	wallet := r.URL.Query().Get("wallet")
+	if !s.Verifier.ValidAddress(wallet) {
+		s.respond(w, r, http.StatusBadRequest, NewWebResp(statuses[verify.BadInput], ""))
+		return
+	}
+
+	// The stream outlives the write timeout of the server.
+	err := http.NewResponseController(w).SetWriteDeadline(time.Time{})
+	if err != nil {
+		log.FromContext(r.Context()).WithError(err).Warn("could not lift write deadline of event stream")
+	}
+
+	events, unfollow := s.progress.follow(wallet)
+	defer unfollow()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	lang := s.lang(r)
+	send := func(e Event) error {
+		data, err := json.Marshal(map[string]interface{}{
+			"status": s.Catalog.T(lang, e.Key, e.Args...),
+			"code":   codes[e.Key],
+			"invite": e.Invite,
+			"done":   e.Done,
+		})
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "data: %s\n\n", data)
+		if err != nil {
+			return err
+		}
+		return http.NewResponseController(w).Flush()
+	}
+
+	err = send(Event{Key: "web.payment.waiting"})
+	if err != nil {
+		return
+	}
+
+	ticker := time.NewTicker(keepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			_, err = fmt.Fprint(w, ": ping\n\n")
+			if err == nil {
+				err = http.NewResponseController(w).Flush()
+			}
+		case e := <-events:
+			err = send(e)
+			if e.Done {
+				return
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
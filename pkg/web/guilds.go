@@ -0,0 +1,135 @@
+package web
+
+import "encoding/json"
+import "errors"
+import "net/http"
+
+import log "github.com/apex/log"
+
+import "github.com/aaronwinter/tezosagora/pkg/store"
+import "github.com/aaronwinter/tezosagora/pkg/verify"
+
+var errUnknownGuild = errors.New("unknown guild")
+
+const unknownGuild = "web.unknown_guild"
+
+// verifierOf returns the Verifier of guild id, or the default one when id is
+// empty, for the request r.
+func (s *Server) verifierOf(r *http.Request, id string) (*verify.Verifier, error) {
+	if id == "" || s.Guild == nil {
+		return s.Verifier, nil
+	}
+
+	verifier, err := s.Guild(id)
+	if err != nil {
+		log.FromContext(r.Context()).WithError(err).WithField("guild", id).Error("could not load guild")
+		return nil, err
+	}
+	if verifier == nil {
+		return nil, errUnknownGuild
+	}
+	return verifier, nil
+}
+
+// verifierFor returns the Verifier of the guild named in the "guild" field
+// of r, or the default one. It answers the request itself and returns nil
+// when the guild can't be served.
+func (s *Server) verifierFor(w http.ResponseWriter, r *http.Request) *verify.Verifier {
+	verifier, err := s.verifierOf(r, r.Form.Get("guild"))
+	if err == errUnknownGuild {
+		s.respond(w, r, http.StatusNotFound, NewWebResp(unknownGuild, ""))
+		return nil
+	}
+	if err != nil {
+		s.respond(w, r, http.StatusInternalServerError, NewWebResp(internalError, ""))
+		return nil
+	}
+	return verifier
+}
+
+// Destination is a guild offered on the web form, the default one having an
+// empty ID.
+type Destination struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// handleDestinations lists the default guild and the listed ones.
+func (s *Server) handleDestinations(w http.ResponseWriter, r *http.Request) {
+	guilds, err := s.Verifier.Store.Guilds()
+	if err != nil {
+		log.FromContext(r.Context()).WithError(err).Error("could not list guilds")
+		s.writeError(w, r, http.StatusInternalServerError, internalError)
+		return
+	}
+
+	destinations := []Destination{{Name: s.Name}}
+	for _, g := range guilds {
+		if !g.Listed {
+			continue
+		}
+		name := g.Name
+		if name == "" {
+			name = g.ID
+		}
+		destinations = append(destinations, Destination{ID: g.ID, Name: name})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(destinations)
+}
+
+// handleGuilds lists the guild settings on GET, creates or replaces the
+// settings posted as JSON on POST and removes the guild given by the "id"
+// query parameter on DELETE.
+func (s *Server) handleGuilds(w http.ResponseWriter, r *http.Request) {
+	db := s.Verifier.Store
+
+	switch r.Method {
+	case http.MethodGet:
+		guilds, err := db.Guilds()
+		if err != nil {
+			log.FromContext(r.Context()).WithError(err).Error("could not list guilds")
+			s.writeError(w, r, http.StatusInternalServerError, internalError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(guilds)
+
+	case http.MethodPost:
+		var g store.Guild
+		err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&g)
+		if err != nil || g.ID == "" || g.ChannelID == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		err = db.PutGuild(&g)
+		if err != nil {
+			log.FromContext(r.Context()).WithError(err).WithField("guild", g.ID).Error("could not save guild")
+			s.writeError(w, r, http.StatusInternalServerError, internalError)
+			return
+		}
+		log.FromContext(r.Context()).WithField("guild", g.ID).Info("saved guild settings")
+		w.WriteHeader(http.StatusNoContent)
+
+	case http.MethodDelete:
+		id := r.URL.Query().Get("id")
+		if id == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		err := db.DeleteGuild(id)
+		if err != nil {
+			log.FromContext(r.Context()).WithError(err).WithField("guild", id).Error("could not delete guild")
+			s.writeError(w, r, http.StatusInternalServerError, internalError)
+			return
+		}
+		log.FromContext(r.Context()).WithField("guild", id).Info("deleted guild settings")
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
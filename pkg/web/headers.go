@@ -0,0 +1,48 @@
+package web
+
+import "fmt"
+import "net/http"
+import "strings"
+
+// DefaultCSP is the Content-Security-Policy allowing the bundled pages and
+// their fonts. The %v verbs take the sources of the CAPTCHA widget, if any.
+const DefaultCSP = "default-src 'self'; script-src 'self'%v; style-src 'self' https://fonts.googleapis.com%v; " +
+	"font-src https://fonts.gstatic.com; frame-src 'self'%v; connect-src 'self'%v; " +
+	"frame-ancestors 'none'; form-action 'self'"
+
+// csp returns the Content-Security-Policy of the site.
+func (s *Server) csp() string {
+	if s.CSP != "" {
+		return s.CSP
+	}
+
+	var sources string
+	if s.Captcha != nil && len(s.Captcha.Sources) > 0 {
+		sources = " " + strings.Join(s.Captcha.Sources, " ")
+	}
+	return fmt.Sprintf(DefaultCSP, sources, sources, sources, sources)
+}
+
+// secured sets the security headers on the responses of h, and caps the
+// size of the request bodies it reads.
+func (s *Server) secured(h http.Handler) http.Handler {
+	csp := s.csp()
+	hsts := ""
+	if s.HSTS > 0 {
+		hsts = fmt.Sprintf("max-age=%v; includeSubDomains", int(s.HSTS.Seconds()))
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+		header.Set("Content-Security-Policy", csp)
+		header.Set("X-Content-Type-Options", "nosniff")
+		header.Set("X-Frame-Options", "DENY")
+		if hsts != "" {
+			header.Set("Strict-Transport-Security", hsts)
+		}
+		if s.MaxBodySize > 0 {
+			r.Body = http.MaxBytesReader(w, r.Body, s.MaxBodySize)
+		}
+		h.ServeHTTP(w, r)
+	})
+}
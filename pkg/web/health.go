@@ -0,0 +1,37 @@
+package web
+
+import "net/http"
+
+import log "github.com/apex/log"
+
+import "github.com/aaronwinter/tezosagora/pkg/build"
+
+// handleHealth answers as long as the process serves requests.
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain")
+	w.Write([]byte("ok\n"))
+}
+
+// handleVersion tells which build is running.
+func (s *Server) handleVersion(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, build.Get())
+}
+
+// handleReady runs the Ready checks, answering 503 when any fails along with
+// the result of each.
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	code := http.StatusOK
+	results := make(map[string]string, len(s.Ready))
+	for name, check := range s.Ready {
+		err := check()
+		if err != nil {
+			log.FromContext(r.Context()).WithError(err).WithField("check", name).Warn("not ready")
+			results[name] = err.Error()
+			code = http.StatusServiceUnavailable
+			continue
+		}
+		results[name] = "ok"
+	}
+
+	writeJSON(w, code, results)
+}
@@ -0,0 +1,70 @@
+package web
+
+import "net/http"
+import "sort"
+import "strconv"
+import "strings"
+
+// lang returns the language to answer r in: the one named by its "lang"
+// parameter, else the preferred one of its Accept-Language header that the
+// Catalog has, else Language.
+func (s *Server) lang(r *http.Request) string {
+	lang := r.URL.Query().Get("lang")
+	if lang == "" && r.PostForm != nil {
+		lang = r.PostForm.Get("lang")
+	}
+	if s.Catalog.Has(lang) {
+		return lang
+	}
+
+	for _, tag := range acceptedLanguages(r.Header.Get("Accept-Language")) {
+		if s.Catalog.Has(tag) {
+			return tag
+		}
+		base, _, _ := strings.Cut(tag, "-")
+		if s.Catalog.Has(base) {
+			return base
+		}
+	}
+	return s.Language
+}
+
+// tr translates the message key for r, see lang.
+func (s *Server) tr(r *http.Request, key string, args ...interface{}) string {
+	return s.Catalog.T(s.lang(r), key, args...)
+}
+
+// acceptedLanguages returns the lowercased language tags of an
+// Accept-Language header, most preferred first.
+func acceptedLanguages(header string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+
+	var tags []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil || parsed <= 0 {
+				continue
+			}
+			q = parsed
+		}
+		tags = append(tags, weighted{strings.ToLower(tag), q})
+	}
+	sort.SliceStable(tags, func(i, j int) bool {
+		return tags[i].q > tags[j].q
+	})
+
+	langs := make([]string, len(tags))
+	for i, t := range tags {
+		langs[i] = t.tag
+	}
+	return langs
+}
@@ -0,0 +1,39 @@
+package web
+
+import "fmt"
+import "net"
+import "net/http"
+
+import log "github.com/apex/log"
+
+// clientIP returns the address of the client of r.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// limited answers requests beyond the allowance of their client IP with 429
+// rather than passing them to h, when IPLimiter is set.
+func (s *Server) limited(h http.HandlerFunc) http.HandlerFunc {
+	if s.IPLimiter == nil {
+		return h
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		ip := clientIP(r)
+		ok, wait := s.IPLimiter.Allow(ip)
+		if !ok {
+			log.FromContext(r.Context()).WithFields(log.Fields{
+				"ip":   ip,
+				"path": r.URL.Path,
+			}).Warn("rate limited")
+			w.Header().Set("Retry-After", fmt.Sprint(int(wait.Seconds())+1))
+			s.respond(w, r, http.StatusTooManyRequests, NewWebResp("web.rate_limited", ""))
+			return
+		}
+		h(w, r)
+	}
+}
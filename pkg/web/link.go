@@ -0,0 +1,61 @@
+package web
+
+import "net/http"
+
+import log "github.com/apex/log"
+
+import "github.com/aaronwinter/tezosagora/pkg/verify"
+
+// ownerRequest reads the signed request of the registered wallet from the
+// form.
+func ownerRequest(r *http.Request) verify.Request {
+	return verify.Request{
+		Address:   r.Form.Get("address"),
+		PublicKey: r.Form.Get("publickey"),
+		Signature: r.Form.Get("signature"),
+		Message:   r.Form.Get("message"),
+	}
+}
+
+// handleLink links the wallet signed for in the "wallet_" prefixed fields to
+// the registration of the wallet signed for in the usual fields.
+func (s *Server) handleLink(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		log.FromContext(r.Context()).WithError(err).Error("could not parse form for /link")
+		return
+	}
+
+	req := verify.Request{
+		Address:   r.Form.Get("wallet"),
+		PublicKey: r.Form.Get("wallet_publickey"),
+		Signature: r.Form.Get("wallet_signature"),
+		Message:   r.Form.Get("wallet_message"),
+	}
+
+	result, err := s.Verifier.Link(r.Context(), ownerRequest(r), req)
+	if err != nil {
+		s.respond(w, r, http.StatusInternalServerError, NewWebResp(internalError, ""))
+		return
+	}
+
+	s.writeResult(w, r, s.Verifier, result)
+}
+
+// handleUnlink removes the wallet named in the "wallet" field from the
+// registration of the wallet signed for.
+func (s *Server) handleUnlink(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		log.FromContext(r.Context()).WithError(err).Error("could not parse form for /unlink")
+		return
+	}
+
+	result, err := s.Verifier.Unlink(r.Context(), ownerRequest(r), r.Form.Get("wallet"))
+	if err != nil {
+		s.respond(w, r, http.StatusInternalServerError, NewWebResp(internalError, ""))
+		return
+	}
+
+	s.writeResult(w, r, s.Verifier, result)
+}
@@ -0,0 +1,52 @@
+package web
+
+import "encoding/json"
+import "net/http"
+import "net/http/httptest"
+import "path/filepath"
+import "strings"
+import "testing"
+
+import "github.com/aaronwinter/tezosagora/pkg/discord"
+import "github.com/aaronwinter/tezosagora/pkg/store"
+import "github.com/aaronwinter/tezosagora/pkg/verify"
+
+func TestNoLinkForUnsignedResubmission(t *testing.T) {
+	const wallet = "tz1VSUr8wwNhLAzempoch5d6hLRiTh8Cjcjb"
+
+	db, err := store.Open(filepath.Join(t.TempDir(), "db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	err = db.Register(wallet, "https://discord.gg/abcdef", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewServer(&verify.Verifier{Store: db}, testAssets)
+	s.Linker = discord.NewLinker(nil, "client", "secret", "https://agora.example/discord/callback", "guild", "role")
+
+	requests := []*http.Request{
+		httptest.NewRequest(http.MethodPost, "/api/invite", strings.NewReader("address="+wallet)),
+		httptest.NewRequest(http.MethodPost, APIVersion+"/submit", strings.NewReader(`{"address":"`+wallet+`"}`)),
+	}
+	requests[0].Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	requests[1].Header.Set("Content-Type", "application/json")
+
+	for _, r := range requests {
+		w := httptest.NewRecorder()
+		s.Handler().ServeHTTP(w, r)
+
+		var response struct {
+			Link string `json:"link"`
+		}
+		err = json.NewDecoder(w.Body).Decode(&response)
+		if err != nil {
+			t.Fatalf("%v: %v", r.URL.Path, err)
+		}
+		if w.Code != http.StatusOK || response.Link != "" {
+			t.Errorf("%v: got %v with link %q, want 200 without a link", r.URL.Path, w.Code, response.Link)
+		}
+	}
+}
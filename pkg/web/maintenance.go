@@ -0,0 +1,78 @@
+package web
+
+import "encoding/json"
+import "fmt"
+import "net/http"
+
+import log "github.com/apex/log"
+
+import "github.com/aaronwinter/tezosagora/pkg/verify"
+
+// Maintenance is the body of PUT /api/v1/admin/maintenance, and of the
+// responses to it and to GET.
+type Maintenance struct {
+	Enabled bool `json:"enabled"`
+}
+
+// paused reports whether the Verifier is paused for maintenance.
+func (s *Server) paused() bool {
+	return s.Verifier.Paused != nil && s.Verifier.Paused.Load()
+}
+
+// maintained answers requests to h with the maintenance page while the
+// Verifier is paused. Among the assets, only the landing page is concerned.
+func (s *Server) maintained(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		landing := r.URL.Path == "/" || r.URL.Path == "/index.html"
+		if !s.paused() || !landing && !gated(r.URL.Path) {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Retry-After", fmt.Sprint(int(verify.PausedRetryAfter.Seconds())))
+		if wantsJSON(r) {
+			s.respond(w, r, http.StatusServiceUnavailable, NewWebResp("web.maintenance", ""))
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+		s.render(w, r, "maintenance.html", &WebResp{Status: s.tr(r, "web.maintenance")})
+	})
+}
+
+// gated reports whether path leads to invites.
+func gated(path string) bool {
+	switch path {
+	case "/invite", "/api/invite", APIVersion + "/submit", "/payment":
+		return true
+	}
+	return false
+}
+
+// handleMaintenance tells whether the Verifier is paused on GET, and pauses
+// or resumes it on PUT.
+func (s *Server) handleMaintenance(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, Maintenance{Enabled: s.paused()})
+
+	case http.MethodPut:
+		if s.Verifier.Paused == nil {
+			s.writeError(w, r, http.StatusInternalServerError, internalError)
+			return
+		}
+		var req Maintenance
+		err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&req)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "malformed JSON body", Code: CodeInvalidRequest})
+			return
+		}
+
+		s.Verifier.Paused.Store(req.Enabled)
+		log.FromContext(r.Context()).WithField("enabled", req.Enabled).Warn("toggled maintenance through the admin API")
+		writeJSON(w, http.StatusOK, req)
+
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		writeJSON(w, http.StatusMethodNotAllowed, ErrorResponse{Error: "method not allowed", Code: CodeInvalidRequest})
+	}
+}
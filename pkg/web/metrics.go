@@ -0,0 +1,41 @@
+package web
+
+import "net/http"
+import "strconv"
+import "time"
+
+import "github.com/aaronwinter/tezosagora/pkg/metrics"
+
+// recorder remembers the status code written through it, for the metrics
+// and the access log.
+type recorder struct {
+	http.ResponseWriter
+	code int
+}
+
+func (rec *recorder) WriteHeader(code int) {
+	rec.code = code
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (rec *recorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// instrumented times the requests served by mux, labelled with the pattern
+// of the route they matched.
+func instrumented(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		_, route := mux.Handler(r)
+		rec := &recorder{ResponseWriter: w, code: http.StatusOK}
+		mux.ServeHTTP(rec, r)
+		metrics.HTTPRequests.WithLabelValues(route, r.Method, strconv.Itoa(rec.code)).Observe(metrics.Since(start))
+	})
+}
+
+// handleMetrics serves the Prometheus metrics.
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	metrics.Handler().ServeHTTP(w, r)
+}
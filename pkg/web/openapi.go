@@ -0,0 +1,192 @@
+package web
+
+import "bytes"
+import _ "embed"
+import "encoding/json"
+import "fmt"
+import "io"
+import "net/http"
+import "strings"
+
+// openAPI describes the versioned API. The request and response types of
+// api.go follow its schemas, and requests are validated against it.
+//
+//go:embed openapi.json
+var openAPI []byte
+
+var apiSpec = mustParseSpec(openAPI)
+
+// spec is the part of an OpenAPI document request validation relies on.
+type spec struct {
+	Paths      map[string]map[string]operation `json:"paths"`
+	Components struct {
+		Schemas map[string]*schema `json:"schemas"`
+	} `json:"components"`
+}
+
+type operation struct {
+	Parameters  []parameter `json:"parameters"`
+	RequestBody *struct {
+		Content map[string]struct {
+			Schema *schema `json:"schema"`
+		} `json:"content"`
+	} `json:"requestBody"`
+}
+
+type parameter struct {
+	Name     string `json:"name"`
+	In       string `json:"in"`
+	Required bool   `json:"required"`
+}
+
+// schema is the subset of JSON Schema used by the document.
+type schema struct {
+	Ref                  string             `json:"$ref"`
+	Type                 string             `json:"type"`
+	Required             []string           `json:"required"`
+	Properties           map[string]*schema `json:"properties"`
+	AdditionalProperties *bool              `json:"additionalProperties"`
+	Items                *schema            `json:"items"`
+	Enum                 []string           `json:"enum"`
+	MaxLength            int                `json:"maxLength"`
+}
+
+func mustParseSpec(doc []byte) *spec {
+	var s spec
+	err := json.Unmarshal(doc, &s)
+	if err != nil {
+		panic("web: bad OpenAPI document: " + err.Error())
+	}
+	return &s
+}
+
+// resolve follows the reference of sc, if any.
+func (s *spec) resolve(sc *schema) *schema {
+	if sc.Ref == "" {
+		return sc
+	}
+	return s.Components.Schemas[strings.TrimPrefix(sc.Ref, "#/components/schemas/")]
+}
+
+// validate checks the decoded JSON value v at path against sc.
+func (s *spec) validate(v interface{}, sc *schema, path string) error {
+	sc = s.resolve(sc)
+	if sc == nil {
+		return nil
+	}
+
+	switch sc.Type {
+	case "object":
+		object, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%v must be an object", path)
+		}
+		for _, name := range sc.Required {
+			if _, ok := object[name]; !ok {
+				return fmt.Errorf("%v.%v is required", path, name)
+			}
+		}
+		for name, value := range object {
+			property, ok := sc.Properties[name]
+			if !ok {
+				if sc.AdditionalProperties != nil && !*sc.AdditionalProperties {
+					return fmt.Errorf("%v.%v is not allowed", path, name)
+				}
+				continue
+			}
+			err := s.validate(value, property, path+"."+name)
+			if err != nil {
+				return err
+			}
+		}
+	case "array":
+		items, ok := v.([]interface{})
+		if !ok {
+			return fmt.Errorf("%v must be an array", path)
+		}
+		for i, item := range items {
+			err := s.validate(item, sc.Items, fmt.Sprintf("%v[%v]", path, i))
+			if err != nil {
+				return err
+			}
+		}
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("%v must be a string", path)
+		}
+		if sc.MaxLength > 0 && len(str) > sc.MaxLength {
+			return fmt.Errorf("%v is longer than %v", path, sc.MaxLength)
+		}
+		if len(sc.Enum) > 0 && !contains(sc.Enum, str) {
+			return fmt.Errorf("%v must be one of %v", path, strings.Join(sc.Enum, ", "))
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return fmt.Errorf("%v must be a boolean", path)
+		}
+	case "integer", "number":
+		n, ok := v.(float64)
+		if !ok || (sc.Type == "integer" && n != float64(int64(n))) {
+			return fmt.Errorf("%v must be an %v", path, sc.Type)
+		}
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// validated rejects requests to the versioned API whose query or body don't
+// match the OpenAPI document before they reach h. Undocumented methods are
+// left to h.
+func validated(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		op, ok := apiSpec.Paths[strings.TrimPrefix(r.URL.Path, APIVersion)][strings.ToLower(r.Method)]
+		if !ok {
+			h(w, r)
+			return
+		}
+
+		query := r.URL.Query()
+		for _, p := range op.Parameters {
+			if p.In == "query" && p.Required && query.Get(p.Name) == "" {
+				writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("%v is required", p.Name), Code: CodeInvalidRequest})
+				return
+			}
+		}
+
+		if op.RequestBody != nil {
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 1<<16))
+			if err != nil {
+				writeJSON(w, http.StatusRequestEntityTooLarge, ErrorResponse{Error: "body too large", Code: CodeInvalidRequest})
+				return
+			}
+			var v interface{}
+			err = json.Unmarshal(body, &v)
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "malformed JSON body", Code: CodeInvalidRequest})
+				return
+			}
+			err = apiSpec.validate(v, op.RequestBody.Content["application/json"].Schema, "body")
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: err.Error(), Code: CodeInvalidRequest})
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		h(w, r)
+	}
+}
+
+func handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPI)
+}
@@ -0,0 +1,35 @@
+package web
+
+import "encoding/json"
+import "net/http"
+
+import log "github.com/apex/log"
+
+const powRequired = "web.pow_required"
+
+// handlePow hands out a proof of work challenge for the next invite request,
+// or answers 204 when the client doesn't have to solve one for now.
+func (s *Server) handlePow(w http.ResponseWriter, r *http.Request) {
+	if !s.flagged(r) {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.Pow.Issue())
+}
+
+// proved checks the proof of work solution of the client of r, if Pow is
+// set. It returns the status code and message to answer with when it's not
+// valid, and 0 otherwise.
+func (s *Server) proved(r *http.Request, challenge, nonce string) (int, string) {
+	if s.Pow == nil {
+		return 0, ""
+	}
+
+	err := s.Pow.Check(challenge, nonce)
+	if err != nil {
+		log.FromContext(r.Context()).WithError(err).WithField("ip", clientIP(r)).Debug("proof of work rejected")
+		return http.StatusForbidden, powRequired
+	}
+	return 0, ""
+}
@@ -0,0 +1,86 @@
+package web
+
+import "net"
+import "net/http"
+import "net/netip"
+import "strings"
+
+// ParseProxies parses trusted proxy ranges, given in CIDR notation or as
+// bare addresses.
+func ParseProxies(ranges []string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, s := range ranges {
+		s = strings.TrimSpace(s)
+		if !strings.Contains(s, "/") {
+			addr, err := netip.ParseAddr(s)
+			if err != nil {
+				return nil, err
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// trusted reports whether the peer at addr is one of the TrustedProxies.
+// Peers connected through a unix socket always are, as they can only be
+// local processes.
+func (s *Server) trusted(addr string) bool {
+	ip, err := netip.ParseAddr(addr)
+	if err != nil {
+		return true
+	}
+	ip = ip.Unmap()
+	for _, prefix := range s.TrustedProxies {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// forwarded replaces the remote address of requests relayed by one of the
+// TrustedProxies with the client address they forwarded, taken from the
+// last untrusted hop of X-Forwarded-For, or from X-Real-IP.
+func (s *Server) forwarded(h http.Handler) http.Handler {
+	if len(s.TrustedProxies) == 0 {
+		return h
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.trusted(clientIP(r)) {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		var client string
+		hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if _, err := netip.ParseAddr(hop); err != nil {
+				break
+			}
+			client = hop
+			if !s.trusted(hop) {
+				break
+			}
+		}
+		if client == "" {
+			if ip, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP"))); err == nil {
+				client = ip.String()
+			}
+		}
+
+		if client != "" {
+			r = r.Clone(r.Context())
+			r.RemoteAddr = net.JoinHostPort(client, "0")
+		}
+		h.ServeHTTP(w, r)
+	})
+}
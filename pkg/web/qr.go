@@ -0,0 +1,38 @@
+package web
+
+import "net/http"
+import "strings"
+
+import log "github.com/apex/log"
+import qrcode "github.com/skip2/go-qrcode"
+
+import "github.com/aaronwinter/tezosagora/pkg/verify"
+
+// qrSize is the width and height of the QR codes, in pixels.
+const qrSize = 256
+
+// handleQR draws the invite URL named by the query as a PNG QR code, for
+// phones to open it in the Discord app. Only URLs under InviteURL are drawn,
+// lest the endpoint serve anyone's QR codes.
+func (s *Server) handleQR(w http.ResponseWriter, r *http.Request) {
+	if !s.allowed(w, r, http.MethodGet) {
+		return
+	}
+
+	url := r.URL.Query().Get("url")
+	if !strings.HasPrefix(url, strings.TrimSuffix(s.InviteURL, "/")+"/") {
+		s.respond(w, r, http.StatusBadRequest, NewWebResp(statuses[verify.BadInput], ""))
+		return
+	}
+
+	png, err := qrcode.Encode(url, qrcode.Medium, qrSize)
+	if err != nil {
+		log.FromContext(r.Context()).WithError(err).Error("could not draw QR code")
+		s.respond(w, r, http.StatusInternalServerError, NewWebResp(internalError, ""))
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "private, max-age=86400")
+	w.Write(png)
+}
@@ -0,0 +1,60 @@
+package web
+
+import "fmt"
+import "net/http"
+import "runtime/debug"
+
+import log "github.com/apex/log"
+
+import "github.com/aaronwinter/tezosagora/pkg/metrics"
+
+// guard remembers whether the response written through it was started.
+type guard struct {
+	http.ResponseWriter
+	started bool
+}
+
+func (g *guard) WriteHeader(code int) {
+	g.started = true
+	g.ResponseWriter.WriteHeader(code)
+}
+
+func (g *guard) Write(b []byte) (int, error) {
+	g.started = true
+	return g.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (g *guard) Unwrap() http.ResponseWriter {
+	return g.ResponseWriter
+}
+
+// recovered turns the panics of h into 500 responses, logged with their
+// stack, rather than closed connections. Responses already started can only
+// be cut short.
+func (s *Server) recovered(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g := &guard{ResponseWriter: w}
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+
+			metrics.Panics.Inc()
+			log.FromContext(r.Context()).WithFields(log.Fields{
+				"panic": fmt.Sprint(v),
+				"path":  r.URL.Path,
+				"stack": string(debug.Stack()),
+			}).Error("recovered from panic")
+			if g.started {
+				panic(http.ErrAbortHandler)
+			}
+			s.fail(w, r, http.StatusInternalServerError, internalError)
+		}()
+		h.ServeHTTP(g, r)
+	})
+}
@@ -0,0 +1,25 @@
+package web
+
+import "crypto/rand"
+import "encoding/hex"
+import "net/http"
+
+import log "github.com/apex/log"
+
+// RequestIDHeader carries the ID of each request in its response, which
+// users can report and operators find in the logs.
+const RequestIDHeader = "X-Request-ID"
+
+// traced gives each request an ID, returned in RequestIDHeader and attached
+// to the logger of its context.
+func traced(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b := make([]byte, 8)
+		rand.Read(b)
+		id := hex.EncodeToString(b)
+
+		w.Header().Set(RequestIDHeader, id)
+		ctx := log.NewContext(r.Context(), log.WithField("request", id))
+		h.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
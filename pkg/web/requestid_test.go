@@ -0,0 +1,53 @@
+package web
+
+import "net/http"
+import "net/http/httptest"
+import "path/filepath"
+import "strings"
+import "testing"
+
+import log "github.com/apex/log"
+import "github.com/apex/log/handlers/memory"
+
+import "github.com/aaronwinter/tezosagora/pkg/store"
+import "github.com/aaronwinter/tezosagora/pkg/verify"
+
+func TestPipelineLogsCarryRequestID(t *testing.T) {
+	const wallet = "tz1VSUr8wwNhLAzempoch5d6hLRiTh8Cjcjb"
+
+	db, err := store.Open(filepath.Join(t.TempDir(), "db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	err = db.Register(wallet, "https://discord.gg/abcdef", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger := log.Log.(*log.Logger)
+	handler, level := logger.Handler, logger.Level
+	defer func() { logger.Handler, logger.Level = handler, level }()
+	entries := memory.New()
+	logger.Handler, logger.Level = entries, log.DebugLevel
+
+	s := NewServer(&verify.Verifier{Store: db}, testAssets)
+	r := httptest.NewRequest(http.MethodPost, "/api/invite", strings.NewReader("address="+wallet))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, r)
+
+	id := w.Header().Get(RequestIDHeader)
+	found := false
+	for _, e := range entries.Entries {
+		if e.Message == "wallet already registered" {
+			found = true
+			if e.Fields.Get("request") != id {
+				t.Errorf("got request %v in the pipeline logs, want %v", e.Fields.Get("request"), id)
+			}
+		}
+	}
+	if !found {
+		t.Error("the pipeline logged nothing")
+	}
+}
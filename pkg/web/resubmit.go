@@ -0,0 +1,151 @@
+package web
+
+import "bytes"
+import "crypto/rand"
+import "encoding/base64"
+import "net/http"
+import "sync"
+import "time"
+
+import log "github.com/apex/log"
+
+// formSessionName names the cookie set on the landing page, which tells the
+// submissions of the invite form apart.
+const formSessionName = "form_session"
+
+// formSessionTTL is how long the landing page session lasts.
+const formSessionTTL = time.Hour
+
+// submission is an invite form being answered, or answered already, whose
+// response is kept for the same form submitted again.
+type submission struct {
+	done   chan struct{}
+	start  time.Time
+	code   int
+	header http.Header
+	body   bytes.Buffer
+}
+
+// submissions hold the recent submissions by session and address.
+type submissions struct {
+	mu    sync.Mutex
+	byKey map[string]*submission
+}
+
+// answered reports whether the response to sub was recorded.
+func (sub *submission) answered() bool {
+	select {
+	case <-sub.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// sessioned gives the landing page a form session, when ResubmitWindow is
+// set.
+func (s *Server) sessioned(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		landing := r.URL.Path == "/" || r.URL.Path == "/index.html"
+		if _, err := r.Cookie(formSessionName); s.ResubmitWindow > 0 && landing && err != nil {
+			b := make([]byte, 16)
+			rand.Read(b)
+			http.SetCookie(w, &http.Cookie{
+				Name:     formSessionName,
+				Value:    base64.RawURLEncoding.EncodeToString(b),
+				Path:     "/",
+				MaxAge:   int(formSessionTTL.Seconds()),
+				HttpOnly: true,
+				Secure:   r.TLS != nil,
+				SameSite: http.SameSiteStrictMode,
+			})
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// deduplicated answers the invite forms submitted again for the same
+// address from the same form session within ResubmitWindow, as double
+// clicks do, with the response to the first submission once h wrote it,
+// rather than running them through h again.
+func (s *Server) deduplicated(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie(formSessionName)
+		if s.ResubmitWindow == 0 || err != nil || r.Method != http.MethodPost || r.ParseForm() != nil {
+			h(w, r)
+			return
+		}
+		key := cookie.Value + "\x00" + r.Form.Get("address")
+
+		s.submissions.mu.Lock()
+		now := time.Now()
+		sub := s.submissions.byKey[key]
+		if sub != nil && (!sub.answered() || now.Sub(sub.start) < s.ResubmitWindow) {
+			s.submissions.mu.Unlock()
+			log.FromContext(r.Context()).WithField("ip", clientIP(r)).Info("answering resubmitted invite form")
+			select {
+			case <-sub.done:
+			case <-r.Context().Done():
+				return
+			}
+			replay(w, sub)
+			return
+		}
+
+		for k, old := range s.submissions.byKey {
+			if old.answered() && now.Sub(old.start) >= s.ResubmitWindow {
+				delete(s.submissions.byKey, k)
+			}
+		}
+		if s.submissions.byKey == nil {
+			s.submissions.byKey = make(map[string]*submission)
+		}
+		sub = &submission{done: make(chan struct{}), start: now, code: http.StatusOK}
+		s.submissions.byKey[key] = sub
+		s.submissions.mu.Unlock()
+
+		defer close(sub.done)
+		h(&tee{ResponseWriter: w, sub: sub}, r)
+	}
+}
+
+// replay writes the response recorded for sub, apart from the ID of the
+// request.
+func replay(w http.ResponseWriter, sub *submission) {
+	for name, values := range sub.header {
+		if name != RequestIDHeader {
+			w.Header()[name] = values
+		}
+	}
+	w.WriteHeader(sub.code)
+	w.Write(sub.body.Bytes())
+}
+
+// tee records the response written through it into sub.
+type tee struct {
+	http.ResponseWriter
+	sub         *submission
+	wroteHeader bool
+}
+
+func (t *tee) WriteHeader(code int) {
+	if !t.wroteHeader {
+		t.wroteHeader = true
+		t.sub.code = code
+		t.sub.header = t.Header().Clone()
+	}
+	t.ResponseWriter.WriteHeader(code)
+}
+
+func (t *tee) Write(b []byte) (int, error) {
+	if !t.wroteHeader {
+		t.WriteHeader(http.StatusOK)
+	}
+	t.sub.body.Write(b)
+	return t.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (t *tee) Unwrap() http.ResponseWriter {
+	return t.ResponseWriter
+}
@@ -0,0 +1,93 @@
+package web
+
+import "crypto/hmac"
+import "crypto/sha256"
+import "encoding/base64"
+import "fmt"
+import "net/http"
+import "strconv"
+import "strings"
+import "time"
+
+import log "github.com/apex/log"
+
+// sessionName names the cookie of the Discord users logged in as admins.
+const sessionName = "admin_session"
+
+// sessionTTL is how long admins stay logged in.
+const sessionTTL = 12 * time.Hour
+
+func (s *Server) signSession(payload string) string {
+	mac := hmac.New(sha256.New, s.sessionKey)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// sessionUser returns the Discord user whose admin session r carries, or ""
+// if it carries none or an expired one. Sessions are only valid for the
+// lifetime of the process.
+func (s *Server) sessionUser(r *http.Request) string {
+	cookie, err := r.Cookie(sessionName)
+	if err != nil {
+		return ""
+	}
+
+	i := strings.LastIndex(cookie.Value, ".")
+	if i < 0 || !hmac.Equal([]byte(cookie.Value[i+1:]), []byte(s.signSession(cookie.Value[:i]))) {
+		return ""
+	}
+	userID, expires, ok := strings.Cut(cookie.Value[:i], ".")
+	if !ok {
+		return ""
+	}
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > unix {
+		return ""
+	}
+	return userID
+}
+
+// isAdmin reports whether userID is one of the Admins.
+func (s *Server) isAdmin(userID string) bool {
+	for _, admin := range s.Admins {
+		if admin == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// handleLogin sends admins to Discord to identify themselves.
+func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	http.Redirect(w, r, s.Linker.LoginURL(), http.StatusFound)
+}
+
+// login completes the Discord authorization started at /admin/login, and
+// opens a session for the user if they are one of the Admins.
+func (s *Server) login(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	userID, err := s.Linker.Identify(query.Get("code"), query.Get("state"))
+	if err != nil {
+		log.FromContext(r.Context()).WithError(err).Warn("could not identify admin")
+		s.respond(w, r, http.StatusBadGateway, NewWebResp("web.discord.failed", ""))
+		return
+	}
+	if !s.isAdmin(userID) {
+		log.FromContext(r.Context()).WithField("user", userID).Warn("refused admin login")
+		s.respond(w, r, http.StatusForbidden, NewWebResp("web.admin.forbidden", ""))
+		return
+	}
+
+	payload := fmt.Sprintf("%v.%v", userID, time.Now().Add(sessionTTL).Unix())
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionName,
+		Value:    payload + "." + s.signSession(payload),
+		Path:     "/",
+		MaxAge:   int(sessionTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+	log.FromContext(r.Context()).WithField("user", userID).Info("admin logged in")
+	http.Redirect(w, r, "/admin/", http.StatusFound)
+}
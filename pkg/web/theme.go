@@ -0,0 +1,29 @@
+package web
+
+import "errors"
+import "io/fs"
+
+// Theme returns assets where the files of dir, such as index.html,
+// style.css, invite.html or payment.html, take precedence over the ones of
+// base. Missing files are served from base, so that a theme only has to
+// carry what it changes.
+//
+// invite.html, maintenance.html and error.html are executed with a WebResp
+// and payment.html with a PaymentResp. All can call t to translate a message
+// of the catalog, as in {{ t "web.page.status" }}, and lang to get the
+// language of the page.
+func Theme(dir, base fs.FS) fs.FS {
+	return theme{dir, base}
+}
+
+type theme struct {
+	dir, base fs.FS
+}
+
+func (t theme) Open(name string) (fs.File, error) {
+	f, err := t.dir.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return t.base.Open(name)
+	}
+	return f, err
+}
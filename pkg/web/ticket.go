@@ -0,0 +1,110 @@
+package web
+
+import "fmt"
+import "net/http"
+import "strings"
+import "time"
+
+import log "github.com/apex/log"
+
+import "github.com/aaronwinter/tezosagora/pkg/ticket"
+
+const invalidTicket = "web.ticket.invalid"
+
+// TicketRequest is the body of POST /api/v1/admin/tickets. TTL is in
+// seconds, Tickets.TTL being used when it is 0.
+type TicketRequest struct {
+	UserID   string `json:"user_id,omitempty"`
+	Campaign string `json:"campaign,omitempty"`
+	TTL      int    `json:"ttl,omitempty"`
+}
+
+// TicketResponse carries a signed ticket, and the link to the verification
+// page bearing it when PublicURL is set.
+type TicketResponse struct {
+	Token   string    `json:"token"`
+	URL     string    `json:"url,omitempty"`
+	Expires time.Time `json:"expires"`
+}
+
+// TicketURL returns the link to the verification page at publicURL bearing
+// token.
+func TicketURL(publicURL, token string) string {
+	return fmt.Sprintf("%v/?token=%v", strings.TrimSuffix(publicURL, "/"), token)
+}
+
+// checkTicket rejects the signed tickets that are invalid, expired or used
+// before r gets processed. It returns the status code and message to answer
+// with then, and 0 otherwise. Tickets recorded in the store are only checked
+// once taken, as they used to be.
+func (s *Server) checkTicket(r *http.Request, token string) (int, string) {
+	if !ticket.Signed(token) {
+		return 0, ""
+	}
+	if s.Tickets == nil {
+		return http.StatusForbidden, invalidTicket
+	}
+
+	claims, err := s.Tickets.Check(token)
+	if err != nil {
+		log.FromContext(r.Context()).WithError(err).WithField("ip", clientIP(r)).Info("rejected ticket")
+		return http.StatusForbidden, invalidTicket
+	}
+	spent, err := s.Verifier.Store.TicketSpent(claims.ID)
+	if err != nil {
+		log.FromContext(r.Context()).WithError(err).Error("could not check ticket")
+		return http.StatusInternalServerError, internalError
+	}
+	if spent {
+		log.FromContext(r.Context()).WithField("ticket", claims.ID).Info("rejected used ticket")
+		return http.StatusForbidden, invalidTicket
+	}
+	return 0, ""
+}
+
+// takeTicket consumes token and returns the Discord user and campaign it
+// binds, or empty strings if it is unknown, used or expired.
+func (s *Server) takeTicket(token string) (string, string, error) {
+	if !ticket.Signed(token) {
+		userID, err := s.Verifier.Store.TakeTicket(token)
+		return userID, "", err
+	}
+	if s.Tickets == nil {
+		return "", "", nil
+	}
+
+	claims, err := s.Tickets.Check(token)
+	if err != nil {
+		return "", "", err
+	}
+	fresh, err := s.Verifier.Store.SpendTicket(claims.ID, claims.Expires)
+	if err != nil || !fresh {
+		return "", "", err
+	}
+	return claims.UserID, claims.Campaign, nil
+}
+
+// handleTickets issues a signed ticket, for links sent by email or shared
+// for a campaign.
+func (s *Server) handleTickets(w http.ResponseWriter, r *http.Request) {
+	var req TicketRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	if req.TTL < 0 {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "ttl is negative", Code: CodeInvalidRequest})
+		return
+	}
+
+	token, claims := s.Tickets.Issue(req.UserID, req.Campaign, time.Duration(req.TTL)*time.Second)
+	response := TicketResponse{Token: token, Expires: claims.Expires}
+	if s.PublicURL != "" {
+		response.URL = TicketURL(s.PublicURL, token)
+	}
+	log.FromContext(r.Context()).WithFields(log.Fields{
+		"ticket":   claims.ID,
+		"user":     req.UserID,
+		"campaign": req.Campaign,
+	}).Info("issued ticket through the admin API")
+	writeJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,678 @@
+package web
+
+import "crypto/rand"
+import "encoding/json"
+import "fmt"
+import "html/template"
+import "io/fs"
+import "net/http"
+import "net/netip"
+import "strings"
+import "time"
+
+import log "github.com/apex/log"
+
+import "github.com/aaronwinter/tezosagora/pkg/captcha"
+import "github.com/aaronwinter/tezosagora/pkg/discord"
+import "github.com/aaronwinter/tezosagora/pkg/i18n"
+import "github.com/aaronwinter/tezosagora/pkg/payment"
+import "github.com/aaronwinter/tezosagora/pkg/pow"
+import "github.com/aaronwinter/tezosagora/pkg/ratelimit"
+import "github.com/aaronwinter/tezosagora/pkg/rules"
+import "github.com/aaronwinter/tezosagora/pkg/store"
+import "github.com/aaronwinter/tezosagora/pkg/ticket"
+import "github.com/aaronwinter/tezosagora/pkg/verify"
+
+// WebResp is the data handed to the invite template, or the body of the
+// response for clients asking for JSON.
+type WebResp struct {
+	// Status is the translated outcome of the request.
+	Status string `json:"status"`
+	// Body is the invite URL, if any.
+	Body string `json:"body,omitempty"`
+	// Report details which requirements the wallet met.
+	Report *rules.Report `json:"report,omitempty"`
+	// Link is the Discord authorization URL granting the member role.
+	Link string `json:"link,omitempty"`
+	// Request is the ID of the request, shown on server errors.
+	Request string `json:"request,omitempty"`
+	// Code is one of the Code constants, when the request failed.
+	Code string `json:"code,omitempty"`
+	// QR tells whether /qr.png draws the invite URL.
+	QR bool `json:"-"`
+}
+
+// PaymentResp is the data handed to the payment template: Wallet has to
+// send Amount XTZ to Destination before Expires.
+type PaymentResp struct {
+	Status      string
+	Wallet      string
+	Destination string
+	Amount      string
+	Expires     string
+}
+
+// NewWebResp returns a WebResp with the given status and body.
+func NewWebResp(status, body string) *WebResp {
+	return &WebResp{
+		Status: status,
+		Body:   body,
+	}
+}
+
+// statuses hold the keys of the messages describing each outcome.
+var statuses = map[verify.Outcome]string{
+	verify.Registered:        "web.registered",
+	verify.AlreadyRegistered: "web.already_registered",
+	verify.BadInput:          "web.bad_input",
+	verify.InvalidSignature:  "web.invalid_signature",
+	verify.NotEligible:       "web.not_eligible",
+	verify.Unavailable:       "web.unavailable",
+	verify.Blocked:           "web.blocked",
+	verify.NotRegistered:     "web.not_registered",
+	verify.Linked:            "web.linked",
+	verify.Unlinked:          "web.unlinked",
+	verify.TooManyAttempts:   "web.too_many_attempts",
+}
+
+const internalError = "web.internal_error"
+
+// paymentRetryAfter is when to come back while too many payment challenges
+// are pending.
+const paymentRetryAfter = time.Minute
+
+var claimStatuses = map[error]string{
+	store.ErrWalletClaimed:  "web.claim.wallet",
+	store.ErrAccountClaimed: "web.claim.account",
+}
+
+// Server serves the static files of Assets and handles invite requests.
+type Server struct {
+	Verifier *verify.Verifier
+	Assets   fs.FS
+	// Payments enables the /payment proof of ownership flow when set.
+	Payments *payment.Watcher
+	// PaymentLimiter, when set, caps the rate of payment challenges issued
+	// per client IP.
+	PaymentLimiter *ratelimit.Limiter
+	// AdminToken enables the authenticated endpoints, such as /batch, for
+	// requests bearing it.
+	AdminToken string
+	// Admins are the Discord users who may also use the authenticated
+	// endpoints once logged in at /admin/login, which takes Linker.
+	Admins []string
+	// Linker enables assigning the Discord role through OAuth2 once a
+	// wallet is registered.
+	Linker *discord.Linker
+	// Interactions, when set, serves Discord's HTTP interactions at
+	// /discord/interactions.
+	Interactions http.Handler
+	// Tickets, when set, checks the signed tickets of invite requests, and
+	// issues them through the admin API, linking to PublicURL.
+	Tickets   *ticket.Signer
+	PublicURL string
+	// InviteURL, when set, is the base of the invite URLs, which the
+	// invite page shows as QR codes drawn at /qr.png.
+	InviteURL string
+	// Name is the name of the default guild among the destinations.
+	Name string
+	// Guild, when set, returns the Verifier of the guild named in invite
+	// requests, or nil if it is unknown.
+	Guild func(id string) (*verify.Verifier, error)
+	// OnDiscordLinked, when set, is called after a registration of the
+	// guild guildID, empty for the default one, got linked to a Discord
+	// account.
+	OnDiscordLinked func(guildID string, reg *store.Registration)
+	// IPLimiter, when set, caps the rate of invite requests per client IP.
+	IPLimiter *ratelimit.Limiter
+	// OnRevoke, when set, revokes registrations for the admin API, which
+	// otherwise only deletes them.
+	OnRevoke func(reg *store.Registration, reason string) error
+	// Captcha, when set, requires invite requests to carry a solved
+	// CAPTCHA, checked before anything else is.
+	Captcha *captcha.Checker
+	// Pow, when set, requires invite requests to carry the solution of a
+	// proof of work challenge it issued.
+	Pow *pow.Issuer
+	// Suspicion, when set, limits the CAPTCHA and proof of work challenges
+	// to client IPs that exhausted it, each invite request and failed
+	// verification taking a token.
+	Suspicion *ratelimit.Limiter
+	// BotChecks rejects invite forms with the honeypot field filled, sent
+	// without the headers browsers send or submitted within MinFillTime of
+	// the page loading, as bots do.
+	BotChecks   bool
+	MinFillTime time.Duration
+	// CSRF requires invite and payment forms to carry the token handed out
+	// at /csrf.
+	CSRF bool
+	// ResubmitWindow, when set, gives the landing page a session, whose
+	// invite forms submitted again for the same address within the window
+	// get the response to the first submission.
+	ResubmitWindow time.Duration
+	// TrustedProxies are the reverse proxies whose X-Forwarded-For and
+	// X-Real-IP headers tell the address of the client.
+	TrustedProxies []netip.Prefix
+	// CORSOrigins are the origins whose pages may call the /api/ routes
+	// with the CORSMethods, "*" allowing any.
+	CORSOrigins []string
+	CORSMethods []string
+	// Reload parses the templates on each use rather than once, so that
+	// changes to them show without a restart.
+	Reload bool
+	// AccessLog, when set, records every request apart from the
+	// application logs.
+	AccessLog log.Interface
+	// Metrics serves the Prometheus metrics at /metrics, only to requests
+	// bearing AdminToken when it is set.
+	Metrics bool
+	// Pprof serves the runtime profiles at /debug/pprof/ to requests
+	// bearing AdminToken, which must be set.
+	Pprof bool
+	// SplitAdmin leaves the admin endpoints, /metrics and /debug/pprof/
+	// out of Handler, for AdminHandler to serve them on another listener.
+	// Admins can't log in with Discord then, as the callback is public.
+	SplitAdmin bool
+	// Ready holds the checks run by /readyz, by name, which fail when
+	// they return an error.
+	Ready map[string]func() error
+	// MaxBodySize, when set, caps the size of request bodies.
+	MaxBodySize int64
+	// HSTS, when set, is the max-age of the Strict-Transport-Security
+	// header, for sites only served over HTTPS.
+	HSTS time.Duration
+	// CSP is the Content-Security-Policy of all responses, built from
+	// DefaultCSP and the CAPTCHA sources when empty.
+	CSP string
+
+	// Catalog translates the messages into Language, or the language the
+	// client asked for.
+	Catalog  *i18n.Catalog
+	Language string
+
+	templates   *template.Template
+	progress    progress
+	activity    activity
+	etags       etags
+	submissions submissions
+	sessionKey  []byte
+}
+
+// templateNames are the templates read from the assets.
+var templateNames = []string{"invite.html", "payment.html", "maintenance.html", "error.html"}
+
+// NewServer returns a Server serving the assets and templates of assets,
+// with the messages of the bundled catalog.
+func NewServer(verifier *verify.Verifier, assets fs.FS) *Server {
+	catalog, err := i18n.New()
+	if err != nil {
+		panic(err)
+	}
+	key := make([]byte, 32)
+	rand.Read(key)
+	return &Server{
+		Verifier:   verifier,
+		Assets:     assets,
+		Catalog:    catalog,
+		Language:   i18n.Fallback,
+		templates:  template.Must(parseTemplates(assets)),
+		sessionKey: key,
+	}
+}
+
+// parseTemplates parses the templates of assets, whose "t" and "lang"
+// functions translate messages and name the language once bound by render.
+func parseTemplates(assets fs.FS) (*template.Template, error) {
+	funcs := template.FuncMap{
+		"t":    func(key string, args ...interface{}) string { return key },
+		"lang": func() string { return i18n.Fallback },
+	}
+	return template.New("").Funcs(funcs).ParseFS(assets, templateNames...)
+}
+
+// render executes the template name with data into w, in the language of r.
+// The templates are parsed anew when Reload is set.
+func (s *Server) render(w http.ResponseWriter, r *http.Request, name string, data interface{}) {
+	templates := s.templates
+	if s.Reload {
+		var err error
+		templates, err = parseTemplates(s.Assets)
+		if err != nil {
+			log.WithError(err).Error("could not parse templates")
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	templates, err := templates.Clone()
+	if err != nil {
+		log.WithError(err).Error("could not clone templates")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	lang := s.lang(r)
+	templates.Funcs(template.FuncMap{
+		"t": func(key string, args ...interface{}) string {
+			return s.Catalog.T(lang, key, args...)
+		},
+		"lang": func() string { return lang },
+	})
+
+	err = templates.ExecuteTemplate(w, name, data)
+	if err != nil {
+		log.WithError(err).WithField("template", name).Error("could not render template")
+	}
+}
+
+// Handler returns the http.Handler for the whole site, apart from the admin
+// endpoints when SplitAdmin is set.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/", s.sessioned(s.static()))
+	mux.HandleFunc("/invite", s.deduplicated(s.limited(s.handleInvite)))
+	mux.HandleFunc("/api/invite", s.deduplicated(s.limited(s.handleInvite)))
+	mux.Handle(APIVersion+"/", s.apiHandler())
+	mux.HandleFunc("/status", s.handleStatus)
+	mux.HandleFunc("/badge/", s.handleBadge)
+	mux.HandleFunc("/healthz", s.handleHealth)
+	mux.HandleFunc("/readyz", s.handleReady)
+	mux.HandleFunc("/version", s.handleVersion)
+	if s.Verifier.SIWT != nil {
+		mux.HandleFunc("/nonce", s.handleNonce)
+	}
+	if s.Payments != nil {
+		mux.HandleFunc("/payment", s.handlePayment)
+		mux.HandleFunc("/events", s.handleEvents)
+	}
+	if s.InviteURL != "" {
+		mux.HandleFunc("/qr.png", s.handleQR)
+	}
+	if s.Verifier.SignatureRequired() {
+		mux.HandleFunc("/link", s.handleLink)
+		mux.HandleFunc("/unlink", s.handleUnlink)
+	}
+	if s.Linker != nil {
+		mux.HandleFunc("/discord/callback", s.handleDiscordCallback)
+	}
+	if s.Interactions != nil {
+		mux.Handle("/discord/interactions", s.Interactions)
+	}
+	if s.CSRF {
+		mux.HandleFunc("/csrf", s.handleCSRF)
+	}
+	if s.Captcha != nil {
+		mux.HandleFunc("/captcha", s.handleCaptcha)
+	}
+	if s.Pow != nil {
+		mux.HandleFunc("/pow", s.handlePow)
+	}
+	if s.Guild != nil {
+		mux.HandleFunc("/destinations", s.handleDestinations)
+	}
+	if s.SplitAdmin {
+		// the dashboard's pages are among the assets
+		mux.HandleFunc("/admin/", s.notFound)
+	} else {
+		s.mountAdmin(mux)
+	}
+	return s.forwarded(traced(s.secured(s.logged(s.recovered(s.cors(compressed(noStore(s.maintained(instrumented(mux))))))))))
+}
+
+// AdminHandler returns the http.Handler for the admin endpoints alone, to
+// serve apart from Handler when SplitAdmin is set.
+func (s *Server) AdminHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.notFound)
+	// for the dashboard
+	mux.Handle("/style.css", s.static())
+	mux.Handle(APIVersion+"/", s.adminAPIHandler())
+	s.mountAdmin(mux)
+	return s.forwarded(traced(s.secured(s.logged(s.recovered(compressed(noStore(instrumented(mux))))))))
+}
+
+// mountAdmin adds the admin, metrics and profiling endpoints to mux.
+func (s *Server) mountAdmin(mux *http.ServeMux) {
+	if s.Metrics && s.AdminToken != "" {
+		mux.HandleFunc("/metrics", s.requireAdmin(s.handleMetrics))
+	} else if s.Metrics {
+		mux.HandleFunc("/metrics", s.handleMetrics)
+	}
+	if s.administered() {
+		mux.HandleFunc("/batch", s.requireAdmin(s.handleBatch))
+		mux.HandleFunc("/guilds", s.requireAdmin(s.handleGuilds))
+		mux.Handle("/admin/", s.dashboard())
+	}
+	if s.logins() {
+		mux.HandleFunc("/admin/login", s.handleLogin)
+	}
+	if s.AdminToken != "" && s.Pprof {
+		s.mountPprof(mux)
+	}
+}
+
+// allowed reports whether r uses method, answering 405 otherwise.
+func (s *Server) allowed(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method {
+		return true
+	}
+	w.Header().Set("Allow", method)
+	s.respond(w, r, http.StatusMethodNotAllowed, NewWebResp("web.method_not_allowed", ""))
+	return false
+}
+
+func (s *Server) handleInvite(w http.ResponseWriter, r *http.Request) {
+	if !s.allowed(w, r, http.MethodPost) {
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		log.FromContext(r.Context()).WithError(err).Error("could not parse form for /invite")
+		s.respond(w, r, http.StatusBadRequest, NewWebResp(statuses[verify.BadInput], ""))
+		return
+	}
+
+	if s.forged(r) {
+		log.FromContext(r.Context()).WithField("ip", clientIP(r)).Warn("rejected invite form without a valid CSRF token")
+		s.respond(w, r, http.StatusForbidden, NewWebResp("web.csrf", ""))
+		return
+	}
+
+	reason := s.automated(r)
+	if reason != "" {
+		log.FromContext(r.Context()).WithFields(log.Fields{
+			"ip":     clientIP(r),
+			"reason": reason,
+		}).Info("rejected bot")
+		s.respond(w, r, http.StatusBadRequest, NewWebResp(statuses[verify.BadInput], ""))
+		return
+	}
+
+	verifier := s.verifierFor(w, r)
+	if verifier == nil {
+		return
+	}
+
+	fields := 1
+	if verifier.SignatureRequired() {
+		fields = 4
+	}
+	optionals := []string{"token", "guild", "lang", honeypotField, elapsedField, csrfName}
+	if s.Captcha != nil {
+		optionals = append(optionals, s.Captcha.Field)
+	}
+	if s.Pow != nil {
+		optionals = append(optionals, "pow_challenge", "pow_nonce")
+	}
+	for _, optional := range optionals {
+		if _, ok := r.Form[optional]; ok {
+			fields++
+		}
+	}
+
+	if len(r.Form) != fields {
+		s.respond(w, r, http.StatusBadRequest, NewWebResp(statuses[verify.BadInput], ""))
+		return
+	}
+
+	log.FromContext(r.Context()).Debug("valid form size")
+
+	_, exists := r.Form["address"]
+
+	if !exists {
+		s.respond(w, r, http.StatusBadRequest, NewWebResp(statuses[verify.BadInput], ""))
+		return
+	}
+
+	log.FromContext(r.Context()).Debug("form has address field")
+
+	var response string
+	if s.Captcha != nil {
+		response = r.Form.Get(s.Captcha.Field)
+	}
+	code, status := s.challenge(r, response, r.Form.Get("pow_challenge"), r.Form.Get("pow_nonce"))
+	if code == 0 {
+		code, status = s.checkTicket(r, r.Form.Get("token"))
+	}
+	if code != 0 {
+		s.respond(w, r, code, NewWebResp(status, ""))
+		return
+	}
+
+	result, err := verifier.Verify(r.Context(), ownerRequest(r))
+	if err != nil {
+		s.respond(w, r, http.StatusInternalServerError, NewWebResp(internalError, ""))
+		return
+	}
+	s.failed(r, result)
+
+	token := r.Form.Get("token")
+	if token != "" && claimable(verifier, result) {
+		s.claim(r, verifier, r.Form.Get("guild"), result.Wallet, token)
+	}
+
+	s.writeResult(w, r, verifier, result)
+}
+
+// claimable reports whether the sender of the request that ended in result
+// may claim the registration, through a ticket or the Discord link: a wallet
+// registered before only goes to them once its owner signed for it, lest
+// anyone claims the wallets of others.
+func claimable(verifier *verify.Verifier, result verify.Result) bool {
+	switch result.Outcome {
+	case verify.Registered:
+		return true
+	case verify.AlreadyRegistered:
+		return verifier.SignatureRequired()
+	}
+	return false
+}
+
+// claim ties the registration of wallet with verifier, the pipeline of
+// guildID, to the Discord user, and records the campaign, bound by token:
+// either a ticket handed out by the /verify command or one signed by
+// Tickets.
+func (s *Server) claim(r *http.Request, verifier *verify.Verifier, guildID, wallet, token string) {
+	userID, campaign, err := s.takeTicket(token)
+	if err != nil || userID == "" && campaign == "" {
+		log.FromContext(r.Context()).WithError(err).WithField("wallet", wallet).Warn("ignoring invalid verification ticket")
+		return
+	}
+
+	if campaign != "" {
+		_, err := verifier.Store.Attribute(wallet, campaign)
+		if err != nil {
+			log.FromContext(r.Context()).WithError(err).WithField("wallet", wallet).Error("could not record campaign")
+		}
+	}
+	if userID == "" {
+		return
+	}
+
+	reg, err := verifier.Store.Claim(wallet, userID)
+	if err != nil || reg == nil {
+		log.FromContext(r.Context()).WithError(err).WithField("wallet", wallet).Error("could not record Discord user")
+		return
+	}
+
+	if verifier == s.Verifier {
+		guildID = ""
+	}
+	if s.OnDiscordLinked != nil {
+		s.OnDiscordLinked(guildID, reg)
+	}
+}
+
+// resultCode returns the status code matching the outcome of result,
+// setting the Retry-After header when it's Unavailable or TooManyAttempts.
+func resultCode(w http.ResponseWriter, result verify.Result) int {
+	switch result.Outcome {
+	case verify.BadInput, verify.InvalidSignature:
+		return http.StatusBadRequest
+	case verify.NotEligible, verify.Blocked:
+		return http.StatusForbidden
+	case verify.NotRegistered:
+		return http.StatusNotFound
+	case verify.Unavailable:
+		w.Header().Set("Retry-After", fmt.Sprint(int(result.RetryAfter.Seconds())+1))
+		return http.StatusServiceUnavailable
+	case verify.TooManyAttempts:
+		w.Header().Set("Retry-After", fmt.Sprint(int(result.RetryAfter.Seconds())+1))
+		return http.StatusTooManyRequests
+	}
+	return http.StatusOK
+}
+
+// writeResult renders result, as returned by verifier, with the status code
+// matching its outcome.
+func (s *Server) writeResult(w http.ResponseWriter, r *http.Request, verifier *verify.Verifier, result verify.Result) {
+	code := resultCode(w, result)
+	response := NewWebResp(statuses[result.Outcome], result.Invite)
+	response.Report = result.Report
+	if s.Linker != nil && claimable(verifier, result) {
+		response.Link = s.Linker.AuthURL(result.Wallet)
+	}
+	s.respond(w, r, code, response)
+}
+
+// wantsJSON reports whether the client of r asked for JSON, either in the
+// Accept header or through the /api/ routes.
+func wantsJSON(r *http.Request) bool {
+	return strings.HasPrefix(r.URL.Path, "/api/") || strings.Contains(r.Header.Get("Accept"), "application/json")
+}
+
+// respond writes resp with the status code, as JSON or through the invite
+// template depending on what the client asked for.
+func (s *Server) respond(w http.ResponseWriter, r *http.Request, code int, resp *WebResp) {
+	resp.Code = codes[resp.Status]
+	resp.QR = s.InviteURL != "" && resp.Body != ""
+	resp.Status = s.tr(r, resp.Status)
+	if code >= http.StatusInternalServerError {
+		resp.Request = w.Header().Get(RequestIDHeader)
+	}
+	if wantsJSON(r) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+
+	w.WriteHeader(code)
+	s.render(w, r, "invite.html", resp)
+}
+
+// handleDiscordCallback completes the OAuth2 flow started from the invite
+// page and records who claimed the registration.
+func (s *Server) handleDiscordCallback(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("error") != "" {
+		s.respond(w, r, http.StatusBadRequest, NewWebResp("web.discord.denied", ""))
+		return
+	}
+	if s.logins() && s.Linker.IsLogin(query.Get("state")) {
+		s.login(w, r)
+		return
+	}
+
+	wallet, userID, err := s.Linker.Complete(r.Context(), query.Get("code"), query.Get("state"))
+	if err == discord.ErrBadState {
+		s.respond(w, r, http.StatusBadRequest, NewWebResp(statuses[verify.BadInput], ""))
+		return
+	}
+	if claimStatuses[err] != "" {
+		s.respond(w, r, http.StatusConflict, NewWebResp(claimStatuses[err], ""))
+		return
+	}
+	if err != nil {
+		s.respond(w, r, http.StatusBadGateway, NewWebResp("web.discord.failed", ""))
+		return
+	}
+
+	reg, err := s.Verifier.Store.Claim(wallet, userID)
+	if err != nil {
+		log.FromContext(r.Context()).WithError(err).WithField("wallet", wallet).Error("could not record Discord user")
+	} else if reg != nil && s.OnDiscordLinked != nil {
+		s.OnDiscordLinked("", reg)
+	}
+
+	s.respond(w, r, http.StatusOK, NewWebResp("web.discord.granted", ""))
+}
+
+func (s *Server) handleNonce(w http.ResponseWriter, r *http.Request) {
+	nonce, err := s.Verifier.SIWT.Nonce()
+	if err != nil {
+		log.FromContext(r.Context()).WithError(err).Error("could not generate SIWT nonce")
+		s.writeError(w, r, http.StatusInternalServerError, internalError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"nonce": nonce})
+}
+
+func (s *Server) handlePayment(w http.ResponseWriter, r *http.Request) {
+	if !s.allowed(w, r, http.MethodPost) {
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		log.FromContext(r.Context()).WithError(err).Error("could not parse form for /payment")
+		s.respond(w, r, http.StatusBadRequest, NewWebResp(statuses[verify.BadInput], ""))
+		return
+	}
+
+	if s.forged(r) {
+		log.FromContext(r.Context()).WithField("ip", clientIP(r)).Warn("rejected payment form without a valid CSRF token")
+		s.respond(w, r, http.StatusForbidden, NewWebResp("web.csrf", ""))
+		return
+	}
+
+	address := r.Form.Get("address")
+	if !s.Verifier.ValidAddress(address) {
+		s.respond(w, r, http.StatusBadRequest, NewWebResp(statuses[verify.BadInput], ""))
+		return
+	}
+
+	inviteURL, err := s.Verifier.Store.Invite(address)
+	if err != nil {
+		log.FromContext(r.Context()).WithError(err).Error("could not check registration")
+		s.respond(w, r, http.StatusInternalServerError, NewWebResp(internalError, ""))
+		return
+	}
+
+	if inviteURL != "" {
+		s.respond(w, r, http.StatusOK, NewWebResp(statuses[verify.AlreadyRegistered], inviteURL))
+		return
+	}
+
+	if s.PaymentLimiter != nil && !s.Payments.Pending(address) {
+		ok, wait := s.PaymentLimiter.Allow(clientIP(r))
+		if !ok {
+			log.FromContext(r.Context()).WithField("ip", clientIP(r)).Warn("payment challenges rate limited")
+			w.Header().Set("Retry-After", fmt.Sprint(int(wait.Seconds())+1))
+			s.respond(w, r, http.StatusTooManyRequests, NewWebResp("web.rate_limited", ""))
+			return
+		}
+	}
+	c, err := s.Payments.Challenge(address)
+	if err == payment.ErrTooManyPending {
+		log.FromContext(r.Context()).WithField("wallet", address).Warn("too many pending payment challenges")
+		w.Header().Set("Retry-After", fmt.Sprint(int(paymentRetryAfter.Seconds())))
+		s.respond(w, r, http.StatusServiceUnavailable, NewWebResp("web.payment.busy", ""))
+		return
+	}
+	if err != nil {
+		log.FromContext(r.Context()).WithError(err).WithField("wallet", address).Error("could not issue payment challenge")
+		s.respond(w, r, http.StatusInternalServerError, NewWebResp(internalError, ""))
+		return
+	}
+	response := &PaymentResp{
+		Status:      s.tr(r, "web.payment.waiting"),
+		Wallet:      c.Wallet,
+		Destination: c.Destination,
+		Amount:      fmt.Sprintf("%d.%06d", c.Amount/1000000, c.Amount%1000000),
+		Expires:     c.Issued.Add(s.Payments.Timeout).UTC().Format(time.RFC1123),
+	}
+	s.render(w, r, "payment.html", response)
+}
@@ -0,0 +1,18 @@
+// Package tezosagora bundles the landing page, its assets and the templates
+// of the web frontend, so that the service runs as a single binary.
+package tezosagora
+
+import "embed"
+import "io/fs"
+
+//go:embed www
+var www embed.FS
+
+// WWW returns the bundled www directory.
+func WWW() fs.FS {
+	sub, err := fs.Sub(www, "www")
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
@@ -0,0 +1,166 @@
+var api = "/api/v1/admin/";
+var params = new URLSearchParams(window.location.search);
+
+function call(method, path, body) {
+    var headers = {"Accept": "application/json"};
+    var token = sessionStorage.getItem("token");
+    if (token) {
+        headers["Authorization"] = "Bearer " + token;
+    }
+    if (body) {
+        headers["Content-Type"] = "application/json";
+        body = JSON.stringify(body);
+    }
+    return fetch(api + path, {method: method, headers: headers, body: body}).then(function (r) {
+        if (r.status === 401) {
+            document.getElementById("auth").hidden = false;
+            throw new Error("unauthorized");
+        }
+        if (r.status === 204) {
+            return null;
+        }
+        return r.json().then(function (data) {
+            if (!r.ok) {
+                throw new Error(data.error);
+            }
+            return data;
+        });
+    });
+}
+
+function row(table, cells, wallet) {
+    var tr = document.createElement("tr");
+    cells.forEach(function (cell, i) {
+        var td = document.createElement("td");
+        if (i === 0 && wallet) {
+            var a = document.createElement("a");
+            a.href = "?wallet=" + encodeURIComponent(wallet);
+            a.textContent = cell;
+            td.appendChild(a);
+        } else {
+            td.textContent = cell === undefined || cell === null ? "" : cell;
+        }
+        tr.appendChild(td);
+    });
+    table.appendChild(tr);
+    return tr;
+}
+
+function date(t) {
+    return t && !t.startsWith("0001") ? new Date(t).toLocaleString() : "";
+}
+
+function registrations(list, append) {
+    var table = document.getElementById("registrations");
+    if (!append) {
+        table.textContent = "";
+        row(table, ["Wallet", "Registered", "Discord user", "Redeemed"]);
+    }
+    list.forEach(function (reg) {
+        row(table, [reg.wallet, date(reg.registered), reg.discord_id, date(reg.redeemed)], reg.wallet);
+    });
+}
+
+function overview() {
+    document.getElementById("overview").hidden = false;
+    call("GET", "stats").then(function (stats) {
+        var counts = document.getElementById("counts");
+        ["registrations", "claimed", "redeemed", "disqualified", "blocks"].forEach(function (name) {
+            row(counts, [name, stats[name]]);
+        });
+        registrations(stats.recent);
+        var activity = document.getElementById("activity");
+        row(activity, ["Wallet", "Time", "Outcome"]);
+        stats.activity.forEach(function (a) {
+            var tr = row(activity, [a.wallet, date(a.time), a.outcome], a.wallet);
+            if (a.outcome !== "registered") {
+                tr.className = "failed";
+            }
+        });
+    });
+    var maintenance = document.getElementById("maintenance");
+    var toggle = function (state) {
+        maintenance.textContent = state.enabled ? "Resume invites" : "Pause invites for maintenance";
+        maintenance.onclick = function () {
+            call("PUT", "maintenance", {enabled: !state.enabled}).then(toggle);
+        };
+    };
+    call("GET", "maintenance").then(toggle);
+    var more = document.getElementById("more");
+    var search = function (query, append) {
+        call("GET", "registrations?" + query.toString()).then(function (data) {
+            document.getElementById("listing").textContent = "Search results";
+            registrations(data.registrations, append);
+            more.hidden = !data.next;
+            more.onclick = function () {
+                query.set("cursor", data.next);
+                search(query, true);
+            };
+        });
+    };
+    document.getElementById("search").addEventListener("submit", function (e) {
+        e.preventDefault();
+        var query = new URLSearchParams();
+        ["q", "prefix", "from", "to"].forEach(function (name) {
+            if (e.target.elements[name].value) {
+                query.set(name, e.target.elements[name].value);
+            }
+        });
+        search(query, false);
+    });
+}
+
+function detail(wallet) {
+    document.getElementById("detail").hidden = false;
+    var result = document.getElementById("result");
+    var done = function (message) {
+        return function () { result.textContent = message; };
+    };
+    var failed = function (err) { result.textContent = err.message; };
+
+    call("GET", "registrations/" + encodeURIComponent(wallet)).then(function (reg) {
+        var fields = document.getElementById("fields");
+        row(fields, ["Wallet", reg.wallet]);
+        row(fields, ["Linked wallets", (reg.linked || []).join(", ")]);
+        row(fields, ["Invite", reg.invite]);
+        row(fields, ["Registered", date(reg.registered)]);
+        row(fields, ["Redeemed", date(reg.redeemed)]);
+        row(fields, ["Discord user", reg.discord_id]);
+        row(fields, ["Last checked", date(reg.checked)]);
+        row(fields, ["Disqualified", date(reg.disqualified)]);
+
+        document.getElementById("reissue").onclick = function () {
+            call("POST", "registrations/" + encodeURIComponent(reg.wallet) + "/reissue").then(function (data) {
+                result.textContent = "New invite: " + data.invite;
+            }, failed);
+        };
+        document.getElementById("revoke").onclick = function () {
+            var reason = prompt("Reason for revoking " + reg.wallet);
+            if (reason === null) {
+                return;
+            }
+            call("DELETE", "registrations/" + encodeURIComponent(reg.wallet) + "?reason=" + encodeURIComponent(reason)).then(done("Revoked."), failed);
+        };
+        document.getElementById("block").onclick = function () {
+            var reason = prompt("Reason for blocking " + reg.wallet);
+            if (reason === null) {
+                return;
+            }
+            [reg.wallet].concat(reg.linked || []).reduce(function (p, address) {
+                return p.then(function () { return call("POST", "blocks", {address: address, reason: reason}); });
+            }, Promise.resolve()).then(done("Blocked."), failed);
+        };
+    }, failed);
+}
+
+document.getElementById("auth").addEventListener("submit", function (e) {
+    e.preventDefault();
+    sessionStorage.setItem("token", e.target.elements["token"].value);
+    window.location.reload();
+});
+
+if (params.get("wallet")) {
+    detail(params.get("wallet"));
+} else {
+    overview();
+}
@@ -0,0 +1,100 @@
+var params = new URLSearchParams(window.location.search);
+var loaded = Date.now();
+document.forms[0].addEventListener("submit", function () {
+    var elapsed = document.forms[0].elements["elapsed"];
+    if (!elapsed) {
+        elapsed = document.createElement("input");
+        elapsed.type = "hidden";
+        elapsed.name = "elapsed";
+        document.forms[0].appendChild(elapsed);
+    }
+    elapsed.value = Date.now() - loaded;
+});
+["token", "guild", "lang"].forEach(function (name) {
+    if (params.get(name)) {
+        var input = document.createElement("input");
+        input.type = "hidden";
+        input.name = name;
+        input.value = params.get(name);
+        document.forms[0].appendChild(input);
+    }
+});
+fetch("/csrf").then(function (r) { return r.ok ? r.json() : null; }).then(function (csrf) {
+    if (!csrf) {
+        return;
+    }
+    var input = document.createElement("input");
+    input.type = "hidden";
+    input.name = "csrf";
+    input.value = csrf.token;
+    document.forms[0].appendChild(input);
+});
+fetch("/captcha").then(function (r) { return r.status === 200 ? r.json() : null; }).then(function (captcha) {
+    if (!captcha) {
+        return;
+    }
+    var widget = document.createElement("div");
+    widget.className = captcha.class;
+    widget.dataset.sitekey = captcha.site_key;
+    var submit = document.forms[0].querySelector("button").parentNode;
+    document.forms[0].insertBefore(widget, submit);
+    var script = document.createElement("script");
+    script.src = captcha.script;
+    script.async = true;
+    document.head.appendChild(script);
+});
+fetch("/pow").then(function (r) { return r.status === 200 ? r.json() : null; }).then(function (pow) {
+    if (!pow) {
+        return;
+    }
+    var form = document.forms[0];
+    form.addEventListener("submit", function (event) {
+        event.preventDefault();
+        form.querySelector("button").disabled = true;
+        solve(pow).then(function (nonce) {
+            [["pow_challenge", pow.challenge], ["pow_nonce", nonce]].forEach(function (field) {
+                var input = document.createElement("input");
+                input.type = "hidden";
+                input.name = field[0];
+                input.value = field[1];
+                form.appendChild(input);
+            });
+            form.submit();
+        });
+    });
+});
+// solve finds a nonce such that SHA-256(challenge + ":" + nonce)
+// starts with pow.difficulty zero bits.
+function solve(pow) {
+    var encoder = new TextEncoder();
+    function attempt(nonce) {
+        return crypto.subtle.digest("SHA-256", encoder.encode(pow.challenge + ":" + nonce)).then(function (digest) {
+            var bytes = new Uint8Array(digest);
+            var bits = 0;
+            for (var i = 0; i < bytes.length && bits < pow.difficulty; i++) {
+                if (bytes[i] !== 0) {
+                    bits += Math.clz32(bytes[i]) - 24;
+                    break;
+                }
+                bits += 8;
+            }
+            return bits >= pow.difficulty ? String(nonce) : attempt(nonce + 1);
+        });
+    }
+    return attempt(0);
+}
+if (!params.get("guild")) {
+    fetch("/destinations").then(function (r) { return r.ok ? r.json() : []; }).then(function (destinations) {
+        if (destinations.length < 2) {
+            return;
+        }
+        var p = document.createElement("p");
+        var select = document.createElement("select");
+        select.name = "guild";
+        destinations.forEach(function (d) {
+            select.appendChild(new Option(d.name, d.id));
+        });
+        p.append("Community: ", select);
+        document.forms[0].insertBefore(p, document.forms[0].firstChild);
+    });
+}
@@ -0,0 +1,34 @@
+fetch("/csrf").then(function (r) { return r.ok ? r.json() : null; }).then(function (csrf) {
+    if (!csrf || !document.forms[0]) {
+        return;
+    }
+    var input = document.createElement("input");
+    input.type = "hidden";
+    input.name = "csrf";
+    input.value = csrf.token;
+    document.forms[0].appendChild(input);
+});
+var main = document.getElementById("main");
+var source = new EventSource("/events?wallet=" + encodeURIComponent(main.dataset.wallet) + "&lang=" + encodeURIComponent(document.documentElement.lang));
+source.onmessage = function (e) {
+    var event = JSON.parse(e.data);
+    document.getElementById("status").textContent = event.status;
+    if (!event.done) {
+        return;
+    }
+    source.close();
+    document.forms[0].remove();
+    if (event.invite) {
+        var p = document.createElement("p");
+        var a = document.createElement("a");
+        a.href = event.invite;
+        a.textContent = event.invite;
+        p.appendChild(a);
+        main.appendChild(p);
+        var qr = document.createElement("img");
+        qr.className = "qr";
+        qr.src = "/qr.png?url=" + encodeURIComponent(event.invite);
+        qr.onerror = function () { qr.remove(); };
+        main.appendChild(qr);
+    }
+};